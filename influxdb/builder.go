@@ -2,16 +2,18 @@ package influxdb
 
 import (
 	"context"
-	"strconv"
+	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 )
 
 type QueryBuilder struct {
-	dialect SQLDialect
-	clauses SelectClauses
-	err     error
+	dialect        SQLDialect
+	dialectOptions *SQLDialectOptions
+	clauses        SelectClauses
+	err            error
 }
 
 type UnionBuilder struct {
@@ -20,10 +22,13 @@ type UnionBuilder struct {
 	tz     string
 }
 
+var defaultDialect = newDialect(defaultDialectOptions)
+
 func newQueryBuilder() *QueryBuilder {
 	return &QueryBuilder{
-		dialect: newDialect(defaultDialectOptions),
-		clauses: newSelectClauses(),
+		dialect:        defaultDialect,
+		dialectOptions: defaultDialectOptions,
+		clauses:        newSelectClauses(),
 	}
 }
 
@@ -50,6 +55,14 @@ func (qb *QueryBuilder) From(table string) *QueryBuilder {
 	return qb
 }
 
+// Dialect 指定生成 SQL 使用的方言，如 InfluxQLDialectOptions()。
+func (qb *QueryBuilder) Dialect(do *SQLDialectOptions) *QueryBuilder {
+	qb.dialect = newDialect(do)
+	qb.dialectOptions = do
+
+	return qb
+}
+
 func (qb *QueryBuilder) Select(selects ...interface{}) *QueryBuilder {
 	if len(selects) == 0 {
 		return qb.ClearSelect()
@@ -72,6 +85,20 @@ func (qb *QueryBuilder) Where(expressions ...Expression) *QueryBuilder {
 	return qb
 }
 
+// Having 对聚合结果进行过滤，多次调用以 AND 连接。
+func (qb *QueryBuilder) Having(expressions ...Expression) *QueryBuilder {
+	qb.clauses.HavingAppend(expressions...)
+
+	return qb
+}
+
+// Into 设置 InfluxQL 的 SELECT ... INTO 目标，原样输出，如 "db"."rp".:MEASUREMENT。
+func (qb *QueryBuilder) Into(into string) *QueryBuilder {
+	qb.clauses.SetInto(into)
+
+	return qb
+}
+
 func (qb *QueryBuilder) Order(order ...OrderedExpression) *QueryBuilder {
 	qb.clauses.SetOrder(order...)
 
@@ -102,7 +129,16 @@ func (qb *QueryBuilder) Interval(interval string) *QueryBuilder {
 	return qb
 }
 
-func (qb *QueryBuilder) Fill(fill interface{}) *QueryBuilder {
+// Window 设置窗口子句，如 NewIntervalWindow(time.Hour).WithSliding(10 * time.Minute)、
+// NewStateWindow("status")、NewSessionWindow("ts", time.Minute) 和 NewEventWindow(start, end)。
+func (qb *QueryBuilder) Window(w Window) *QueryBuilder {
+	qb.clauses.SetWindow(w)
+
+	return qb
+}
+
+// Fill 设置窗口的填充方式，如 FillPrev()、FillValue(0)。
+func (qb *QueryBuilder) Fill(fill FillExpression) *QueryBuilder {
 	qb.clauses.SetFill(fill)
 
 	return qb
@@ -132,15 +168,46 @@ func (qb *QueryBuilder) Offset(offset int) *QueryBuilder {
 	return qb
 }
 
+// SLimit 限制返回的序列（分组）数量，配合 PartitionBy 或按标签 GroupBy 使用。
+func (qb *QueryBuilder) SLimit(slimit int) *QueryBuilder {
+	if slimit > 0 {
+		qb.clauses.SetSLimit(slimit)
+
+		return qb
+	}
+
+	qb.clauses.ClearSLimit()
+
+	return qb
+}
+
+func (qb *QueryBuilder) SOffset(soffset int) *QueryBuilder {
+	qb.clauses.SetSOffset(uint(soffset))
+
+	return qb
+}
+
 func (qb *QueryBuilder) Clone() *QueryBuilder {
 	return &QueryBuilder{
-		dialect: newDialect(defaultDialectOptions),
-		clauses: qb.clauses.Clone(),
-		err:     qb.err,
+		dialect:        qb.dialect,
+		dialectOptions: qb.dialectOptions,
+		clauses:        qb.clauses.Clone(),
+		err:            qb.err,
 	}
 }
 
-func needOffset(interval string) bool {
+func needOffset(interval interface{}) bool {
+	switch v := interval.(type) {
+	case time.Duration:
+		return v >= 24*time.Hour && v%(24*time.Hour) == 0
+	case string:
+		return needOffsetString(v)
+	}
+
+	return false
+}
+
+func needOffsetString(interval string) bool {
 	if strings.HasSuffix(interval, "d") {
 		return true
 	}
@@ -159,31 +226,43 @@ func needOffset(interval string) bool {
 func (qb *QueryBuilder) ToSQL() (string, string, error) {
 	tz := qb.clauses.Timezone()
 
+	if w := qb.timezoneWindow(); w != nil {
+		qb.clauses.SetWindow(w)
+	}
+
+	sql, err := qb.selectSQLBuilder().ToSQL()
+
+	qb.release()
+
+	return sql, tz, err
+}
+
+// timezoneWindow 为按天、月、年划分的 INTERVAL 窗口补充时区偏移，不需要时返回 nil。
+func (qb *QueryBuilder) timezoneWindow() Window {
+	tz := qb.clauses.Timezone()
+
 	// d:日 n:月 y:年
-	interval := qb.clauses.Interval()
+	w := qb.clauses.Window()
 
-	if tz != "" && interval != "" && needOffset(interval) {
+	if tz != "" && w != nil && w.Type() == IntervalWindowType && w.Offset() == nil && needOffset(w.Interval()) {
 		loc, _ := time.LoadLocation(tz)
 		_, offset := time.Now().In(loc).Zone()
 
 		offset = 8 - (offset / 3600)
 
 		if offset > 0 {
-			qb.clauses.SetInterval(interval + "," + strconv.Itoa(offset) + "h")
-		} else if offset < 0 {
-			offset = 24 + offset
+			return w.WithOffset(time.Duration(offset) * time.Hour)
 		}
 	}
 
-	sql, err := qb.selectSQLBuilder().ToSQL()
-
-	qb.clear()
-	queryBuilderPool.Put(qb)
-
-	return sql, tz, err
+	return nil
 }
 
-func (qb *QueryBuilder) Query(ctx context.Context, conn *InfluxDB, dest interface{}, format ...FormatType) error {
+func (qb *QueryBuilder) Query(ctx context.Context, conn Querier, dest interface{}, format ...FormatType) error {
+	if err := guard(conn, qb); err != nil {
+		return err
+	}
+
 	sql, _, err := qb.ToSQL()
 	if err != nil {
 		return err
@@ -192,7 +271,11 @@ func (qb *QueryBuilder) Query(ctx context.Context, conn *InfluxDB, dest interfac
 	return conn.Query(ctx, sql, dest, format...)
 }
 
-func (qb *QueryBuilder) QueryTaos(ctx context.Context, conn *InfluxDB, dest interface{}) error {
+func (qb *QueryBuilder) QueryTaos(ctx context.Context, conn Querier, dest interface{}) error {
+	if err := guard(conn, qb); err != nil {
+		return err
+	}
+
 	sql, tz, err := qb.ToSQL()
 	if err != nil {
 		return err
@@ -201,10 +284,59 @@ func (qb *QueryBuilder) QueryTaos(ctx context.Context, conn *InfluxDB, dest inte
 	return conn.Query2(ctx, sql, dest, tz)
 }
 
+// run 按方言执行查询，InfluxQL 使用 Query，否则使用 QueryTaos。
+func (qb *QueryBuilder) run(ctx context.Context, conn Querier, dest interface{}) error {
+	if qb.dialectOptions.InfluxQL {
+		return qb.Query(ctx, conn, dest)
+	}
+
+	return qb.QueryTaos(ctx, conn, dest)
+}
+
+func (qb *QueryBuilder) release() {
+	qb.clear()
+	queryBuilderPool.Put(qb)
+}
+
 func (qb *QueryBuilder) clear() {
+	qb.dialect = defaultDialect
+	qb.dialectOptions = defaultDialectOptions
 	qb.clauses.Clear()
 }
 
+// EachSeriesPage 以 SLIMIT/SOFFSET 分页遍历 qb 的所有序列，每页最多 seriesPerPage 个序列，
+// 直到没有数据或 fn 返回错误。InfluxQL 方言使用 Query，否则使用 QueryTaos。qb 在遍历结束后被回收。
+func EachSeriesPage[T any](
+	ctx context.Context, conn Querier, qb *QueryBuilder, seriesPerPage int, fn func(page []T) error,
+) error {
+	defer qb.release()
+
+	if seriesPerPage <= 0 {
+		return fmt.Errorf("invalid series page size %d", seriesPerPage)
+	}
+
+	for soffset := 0; ; soffset += seriesPerPage {
+		var page []T
+
+		err := qb.Clone().SLimit(seriesPerPage).SOffset(soffset).run(ctx, conn, &page)
+		if errors.Is(err, ErrNoData) || errors.Is(err, ErrNoSeries) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(page) == 0 {
+			return nil
+		}
+
+		if err = fn(page); err != nil {
+			return err
+		}
+	}
+}
+
 func (qb *QueryBuilder) selectSQLBuilder() SQLBuilder {
 	buf := newSQLBuilder(true)
 	if qb.err != nil {
@@ -222,7 +354,19 @@ func (ub *UnionBuilder) Timezone(tz string) *UnionBuilder {
 	return ub
 }
 
-func (ub *UnionBuilder) Query(ctx context.Context, conn *InfluxDB, dest interface{}) error {
+func (ub *UnionBuilder) Query(ctx context.Context, conn Querier, dest interface{}) error {
+	if err := guard(conn, ub.query1); err != nil {
+		ub.query2.release()
+
+		return err
+	}
+
+	if err := guard(conn, ub.query2); err != nil {
+		ub.query1.release()
+
+		return err
+	}
+
 	sql1, _, err := ub.query1.ToSQL()
 	if err != nil {
 		return err
@@ -0,0 +1,39 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+)
+
+type sqlTest struct {
+	name    string
+	qb      *QueryBuilder
+	sql     string
+	wantErr string
+}
+
+func runSQLTests(t *testing.T, tests []sqlTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		sql, _, err := test.qb.ToSQL()
+
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: ToSQL() = %q, %v; want error %q", test.name, sql, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: ToSQL() error: %v", test.name, err)
+
+			continue
+		}
+
+		if sql != test.sql {
+			t.Errorf("%s: ToSQL() =\n%s\nwant\n%s", test.name, sql, test.sql)
+		}
+	}
+}
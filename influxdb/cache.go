@@ -0,0 +1,240 @@
+package influxdb
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiurenm/mare/syncx/singleflight"
+)
+
+type cacheKind uint8
+
+const (
+	cacheKindJSON cacheKind = iota
+	cacheKindCSV
+	cacheKindTaos
+)
+
+type cacheKey struct {
+	// dst 为 CacheDecorator 缓存的解析目标类型，同一查询解析到不同类型时分别缓存。
+	dst  reflect.Type
+	sql  string
+	tz   string
+	kind cacheKind
+}
+
+func (k cacheKey) String() string {
+	s := strconv.Itoa(int(k.kind)) + "|" + k.tz + "|" + k.sql
+	if k.dst != nil {
+		s = fmt.Sprintf("%p|", k.dst) + s
+	}
+
+	return s
+}
+
+type cacheEntry struct {
+	expires time.Time
+	body    []byte
+	key     cacheKey
+}
+
+// queryCache 缓存查询的响应体，容量满时淘汰最久未使用的条目，并合并并发的相同查询。
+type queryCache struct {
+	group singleflight.Group[[]byte]
+	ll    *list.List
+	items map[cacheKey]*list.Element
+	ttl   time.Duration
+	size  int
+	mu    sync.Mutex
+}
+
+func newQueryCache(ttl time.Duration, size int) *queryCache {
+	return &queryCache{
+		ll:    list.New(),
+		items: make(map[cacheKey]*list.Element, size),
+		ttl:   ttl,
+		size:  size,
+	}
+}
+
+func (c *queryCache) get(key cacheKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.removeElement(e)
+
+		return nil, false
+	}
+
+	c.ll.MoveToFront(e)
+
+	return entry.body, true
+}
+
+func (c *queryCache) set(key cacheKey, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+
+	if e, ok := c.items[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		entry.body = body
+		entry.expires = expires
+		c.ll.MoveToFront(e)
+
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, body: body, expires: expires})
+
+	for c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// invalidate 删除 sql 对应的所有条目，不区分时区和格式。
+func (c *queryCache) invalidate(sql string) {
+	sql = normalizeSQL(sql)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, e := range c.items {
+		if key.sql == sql {
+			c.removeElement(e)
+		}
+	}
+}
+
+func (c *queryCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[cacheKey]*list.Element, c.size)
+}
+
+func (c *queryCache) removeElement(e *list.Element) {
+	c.ll.Remove(e)
+	delete(c.items, e.Value.(*cacheEntry).key)
+}
+
+func (c *queryCache) do(ctx context.Context, key cacheKey, fn func() ([]byte, error)) ([]byte, error) {
+	key.sql = normalizeSQL(key.sql)
+
+	if bypass, _ := ctx.Value(noCacheKey{}).(bool); bypass {
+		body, err := fn()
+		if err == nil {
+			c.set(key, body)
+		}
+
+		return body, err
+	}
+
+	if body, ok := c.get(key); ok {
+		return body, nil
+	}
+
+	for {
+		var leader bool
+
+		body, err, _ := c.group.Do(key.String(), func() ([]byte, error) {
+			leader = true
+
+			body, err := fn()
+			if err == nil {
+				c.set(key, body)
+			}
+
+			return body, err
+		})
+
+		// 合并的查询使用第一个调用者的 context，它被取消或超时后，context 仍有效的调用者重新查询。
+		if leader || err == nil || ctx.Err() != nil || !isContextError(err) {
+			return body, err
+		}
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// normalizeSQL 合并引号外的连续空白，使仅空白不同的查询共用缓存。字符串和用 " 或 ` 引起来的标识符中的空白保持不变。
+func normalizeSQL(sql string) string {
+	var (
+		b       strings.Builder
+		quote   rune
+		spacing bool
+	)
+
+	b.Grow(len(sql))
+
+	for _, r := range strings.TrimSpace(sql) {
+		if quote == 0 && (r == ' ' || r == '\t' || r == '\n' || r == '\r') {
+			spacing = true
+
+			continue
+		}
+
+		if spacing {
+			b.WriteRune(' ')
+			spacing = false
+		}
+
+		switch {
+		case quote == 0 && (r == '\'' || r == '"' || r == '`'):
+			quote = r
+		case r == quote:
+			quote = 0
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+type noCacheKey struct{}
+
+// WithoutCache 返回跳过缓存读取的 context，查询结果仍会刷新缓存。
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func (i *InfluxDB) cached(ctx context.Context, key cacheKey, fn func() ([]byte, error)) ([]byte, error) {
+	if i.cache == nil {
+		return fn()
+	}
+
+	return i.cache.do(ctx, key, fn)
+}
+
+// InvalidateCache 删除 query 的缓存结果。
+func (i *InfluxDB) InvalidateCache(query string) {
+	if i.cache != nil {
+		i.cache.invalidate(query)
+	}
+}
+
+// PurgeCache 清空所有缓存结果。
+func (i *InfluxDB) PurgeCache() {
+	if i.cache != nil {
+		i.cache.purge()
+	}
+}
@@ -0,0 +1,136 @@
+package influxdb_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+	"github.com/jiurenm/mare/influxdb/influxdbtest"
+)
+
+// failingServer 在 srv 前返回 failures 次 status，之后转发给 srv。
+func failingServer(t *testing.T, srv *influxdbtest.Server, status int, failures int32) influxdb.Config {
+	t.Helper()
+
+	var n atomic.Int32
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Add(1) <= failures {
+			http.Error(w, "bad gateway", status)
+
+			return
+		}
+
+		srv.Server.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(proxy.URL, "http://"))
+	p, _ := strconv.Atoi(port)
+
+	cfg := srv.Config()
+	cfg.Host = "http://" + host
+	cfg.Port = p
+
+	return cfg
+}
+
+func TestQueryDoesNotCacheFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{name: "error field", wantErr: "database not found"},
+		{name: "bad status", status: http.StatusBadGateway, wantErr: "502 Bad Gateway"},
+	}
+
+	for _, test := range tests {
+		srv := influxdbtest.NewServer()
+		t.Cleanup(srv.Close)
+		insertCPU(srv, []string{"a"}, 3)
+
+		cfg := srv.Config()
+		if test.status != 0 {
+			cfg = failingServer(t, srv, test.status, 1)
+		} else {
+			srv.FailNext(test.wantErr)
+		}
+
+		cfg.CacheTTL = time.Minute
+		cfg.CacheSize = 10
+
+		db, closeDB, err := influxdb.NewInfluxDB(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(closeDB)
+
+		query := func() error {
+			var rows []map[string]any
+
+			return db.Query(context.Background(), "SELECT sum(value) AS s FROM cpu", &rows)
+		}
+
+		if err = query(); err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: first query error = %v; want %q", test.name, err, test.wantErr)
+		}
+
+		if err = query(); err != nil {
+			t.Errorf("%s: second query error = %v; want the failure not cached", test.name, err)
+		}
+	}
+}
+
+func TestCacheDecoratorKeysByDestinationType(t *testing.T) {
+	db, srv := newTestDB(t)
+	insertCPU(srv, []string{"a", "b"}, 3)
+
+	conn := influxdb.Decorate(db, influxdb.CacheDecorator(time.Minute, 10))
+	ctx := context.Background()
+	sql := "SELECT host, SUM(value) AS s FROM cpu GROUP BY host ORDER BY host"
+
+	type hostOnly struct {
+		Host string `json:"host"`
+	}
+
+	var hosts []hostOnly
+
+	tests := []struct {
+		name    string
+		dst     any
+		want    string
+		queries int
+	}{
+		{name: "struct", dst: &hosts, want: "&[{a} {b}]", queries: 1},
+		// 结构体只解析了 host，缓存不能让 map 丢失 s。
+		{name: "map", dst: &[]map[string]any{}, want: "&[map[host:a s:3] map[host:b s:3]]", queries: 2},
+		{name: "map cached", dst: &[]map[string]any{}, want: "&[map[host:a s:3] map[host:b s:3]]", queries: 2},
+		{name: "struct cached", dst: &[]hostOnly{}, want: "&[{a} {b}]", queries: 2},
+	}
+
+	for _, test := range tests {
+		if err := conn.Query2(ctx, sql, test.dst); err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if got := fmt.Sprint(test.dst); got != test.want {
+			t.Errorf("%s: rows = %s; want %s", test.name, got, test.want)
+		}
+
+		if n := len(srv.Queries()); n != test.queries {
+			t.Errorf("%s: %d queries sent; want %d", test.name, n, test.queries)
+		}
+	}
+}
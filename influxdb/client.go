@@ -0,0 +1,284 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Querier 执行查询，Query 使用 InfluxQL，Query2 使用 TDengine SQL。
+type Querier interface {
+	Query(ctx context.Context, query string, dst interface{}, format ...FormatType) error
+	Query2(ctx context.Context, query string, dst interface{}, tz ...string) error
+}
+
+// Writer 写入数据点。
+type Writer interface {
+	WriteContext(ctx context.Context, data []Writable) error
+}
+
+// Executor 执行 DELETE、DDL 等非查询语句，返回受影响的行数。
+type Executor interface {
+	Exec(ctx context.Context, query string) (int64, error)
+}
+
+// Client 为 *InfluxDB 提供的全部操作，构建器只依赖其中需要的接口，便于替换为 mock 或装饰器。
+type Client interface {
+	Querier
+	Writer
+	Executor
+}
+
+var _ Client = (*InfluxDB)(nil)
+
+// fetchRows 执行 TDengine 查询并返回按列名组织的行，*InfluxDB 直接返回解析后的行，避免再次编解码。
+func fetchRows(ctx context.Context, conn Querier, query, tz string) ([]map[string]any, error) {
+	if i, ok := conn.(*InfluxDB); ok {
+		return i.queryRows(ctx, query, tz)
+	}
+
+	var rows []map[string]any
+
+	err := conn.Query2(ctx, query, &rows, tz)
+
+	return rows, err
+}
+
+// Decorator 包装 Client 以添加日志、缓存、重试等行为。
+type Decorator func(Client) Client
+
+// Decorate 依次用 decorators 包装 c，第一个 decorator 在最外层：
+//
+//	c := Decorate(conn,
+//		HookDecorator(NewSlogHooks(logger, time.Second)),
+//		CacheDecorator(time.Minute, 100),
+//		RetryDecorator(3, 100*time.Millisecond),
+//	)
+func Decorate(c Client, decorators ...Decorator) Client {
+	for j := len(decorators) - 1; j >= 0; j-- {
+		c = decorators[j](c)
+	}
+
+	return c
+}
+
+// HookDecorator 在每次操作前后调用 hooks，与 AddHooks 相同，但可用于任意 Client。
+// OpInfo 中只有 Op、SQL、Start、Duration、Rows 和 Err 有效。
+func HookDecorator(hooks ...Hooks) Decorator {
+	return func(c Client) Client {
+		return &hookedClient{Client: c, hooks: hooks}
+	}
+}
+
+type hookedClient struct {
+	Client
+	hooks []Hooks
+}
+
+func (h *hookedClient) before(ctx context.Context, op Operation, sql string) (context.Context, *OpInfo) {
+	info := &OpInfo{Op: op, SQL: sql, Start: time.Now()}
+
+	for _, hook := range h.hooks {
+		ctx = hook.Before(ctx, info)
+	}
+
+	return ctx, info
+}
+
+func (h *hookedClient) after(ctx context.Context, info *OpInfo, err error) {
+	info.Err = err
+	info.Duration = time.Since(info.Start)
+
+	for j := len(h.hooks) - 1; j >= 0; j-- {
+		h.hooks[j].After(ctx, info)
+	}
+}
+
+func (h *hookedClient) guardrails() *Guardrails {
+	return clientGuardrails(h.Client)
+}
+
+func (h *hookedClient) Query(ctx context.Context, query string, dst interface{}, format ...FormatType) error {
+	ctx, info := h.before(ctx, QueryOperation, query)
+	err := h.Client.Query(ctx, query, dst, format...)
+	h.after(ctx, info, err)
+
+	return err
+}
+
+func (h *hookedClient) Query2(ctx context.Context, query string, dst interface{}, tz ...string) error {
+	ctx, info := h.before(ctx, Query2Operation, query)
+	err := h.Client.Query2(ctx, query, dst, tz...)
+	h.after(ctx, info, err)
+
+	return err
+}
+
+func (h *hookedClient) WriteContext(ctx context.Context, data []Writable) error {
+	ctx, info := h.before(ctx, WriteOperation, "")
+	info.Rows = len(data)
+	err := h.Client.WriteContext(ctx, data)
+	h.after(ctx, info, err)
+
+	return err
+}
+
+func (h *hookedClient) Exec(ctx context.Context, query string) (int64, error) {
+	ctx, info := h.before(ctx, ExecOperation, query)
+	n, err := h.Client.Exec(ctx, query)
+	info.Rows = int(n)
+	h.after(ctx, info, err)
+
+	return n, err
+}
+
+func (h *hookedClient) ExecInfluxQL(ctx context.Context, query string) error {
+	ctx, info := h.before(ctx, ExecOperation, query)
+	err := execInfluxQL(ctx, h.Client, query)
+	h.after(ctx, info, err)
+
+	return err
+}
+
+// CacheDecorator 缓存查询解析后的结果，最多缓存 size 个查询，行为与 Config.CacheTTL 相同，
+// 包括合并并发的相同查询和 WithoutCache。缓存以 JSON 保存并按 dst 的类型区分，dst 需要能被 encoding/json 编解码。
+func CacheDecorator(ttl time.Duration, size int) Decorator {
+	return func(c Client) Client {
+		return &cachedClient{Client: c, cache: newQueryCache(ttl, size)}
+	}
+}
+
+type cachedClient struct {
+	Client
+	cache *queryCache
+}
+
+func (c *cachedClient) guardrails() *Guardrails {
+	return clientGuardrails(c.Client)
+}
+
+func (c *cachedClient) Query(ctx context.Context, query string, dst interface{}, format ...FormatType) error {
+	key := cacheKey{sql: query, kind: cacheKindJSON, dst: reflect.TypeOf(dst)}
+	if len(format) > 0 && format[0] == CSV {
+		key.kind = cacheKindCSV
+	}
+
+	return c.do(ctx, key, dst, func() error {
+		return c.Client.Query(ctx, query, dst, format...)
+	})
+}
+
+func (c *cachedClient) Query2(ctx context.Context, query string, dst interface{}, tz ...string) error {
+	key := cacheKey{sql: query, kind: cacheKindTaos, dst: reflect.TypeOf(dst)}
+	if len(tz) > 0 {
+		key.tz = tz[0]
+	}
+
+	return c.do(ctx, key, dst, func() error {
+		return c.Client.Query2(ctx, query, dst, tz...)
+	})
+}
+
+// do 在未命中时执行 query 并缓存 dst，命中或合并到其他调用时从缓存解析到 dst。key 包含 dst 的类型，
+// 缓存的 JSON 总是由同一类型编码，不会因为字段或标签不同而丢失数据。
+func (c *cachedClient) do(ctx context.Context, key cacheKey, dst interface{}, query func() error) error {
+	var filled bool
+
+	body, err := c.cache.do(ctx, key, func() ([]byte, error) {
+		if err := query(); err != nil {
+			return nil, err
+		}
+
+		filled = true
+
+		return json.Marshal(dst)
+	})
+	if err != nil || filled {
+		return err
+	}
+
+	return json.Unmarshal(body, dst)
+}
+
+func (c *cachedClient) ExecInfluxQL(ctx context.Context, query string) error {
+	return execInfluxQL(ctx, c.Client, query)
+}
+
+// InvalidateCache 删除 query 的缓存结果。
+func (c *cachedClient) InvalidateCache(query string) {
+	c.cache.invalidate(query)
+}
+
+// RetryDecorator 在查询和写入失败时最多重试 attempts-1 次，每次等待的时间从 backoff 开始翻倍。
+// ErrNoData、ErrNoSeries 和 context 的错误不重试；Exec 和 ExecInfluxQL 执行的语句不一定幂等，不会重试。
+func RetryDecorator(attempts int, backoff time.Duration) Decorator {
+	return func(c Client) Client {
+		return &retryClient{Client: c, attempts: attempts, backoff: backoff}
+	}
+}
+
+type retryClient struct {
+	Client
+	attempts int
+	backoff  time.Duration
+}
+
+func (r *retryClient) guardrails() *Guardrails {
+	return clientGuardrails(r.Client)
+}
+
+func (r *retryClient) Query(ctx context.Context, query string, dst interface{}, format ...FormatType) error {
+	return r.retry(ctx, func() error {
+		return r.Client.Query(ctx, query, dst, format...)
+	})
+}
+
+func (r *retryClient) Query2(ctx context.Context, query string, dst interface{}, tz ...string) error {
+	return r.retry(ctx, func() error {
+		return r.Client.Query2(ctx, query, dst, tz...)
+	})
+}
+
+func (r *retryClient) WriteContext(ctx context.Context, data []Writable) error {
+	return r.retry(ctx, func() error {
+		return r.Client.WriteContext(ctx, data)
+	})
+}
+
+func (r *retryClient) ExecInfluxQL(ctx context.Context, query string) error {
+	return execInfluxQL(ctx, r.Client, query)
+}
+
+func (r *retryClient) retry(ctx context.Context, fn func() error) error {
+	backoff := r.backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.attempts || !retryable(ctx, err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return !errors.Is(err, ErrNoData) && !errors.Is(err, ErrNoSeries) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,74 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jiurenm/mare/syncx/semaphore"
+)
+
+// ErrQueueTimeout 表示请求在并发队列中等待超过了 Config.QueueTimeout。
+var ErrQueueTimeout = errors.New("timed out waiting for an in-flight request slot")
+
+type priorityKey struct{}
+
+// WithPriority 指定本次操作在并发队列中的优先级，默认为 semaphore.Interactive。
+func WithPriority(ctx context.Context, p semaphore.Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// WithBatch 将本次操作放入 semaphore.Batch 队列，只有没有交互式请求等待时才会执行。
+func WithBatch(ctx context.Context) context.Context {
+	return WithPriority(ctx, semaphore.Batch)
+}
+
+func newSemaphore(cfg Config) *semaphore.Weighted {
+	if cfg.Semaphore != nil {
+		return cfg.Semaphore
+	}
+
+	if cfg.MaxInFlight > 0 {
+		return semaphore.NewWeighted(cfg.MaxInFlight)
+	}
+
+	return nil
+}
+
+// Semaphore 返回限制并发请求数的信号量，可用于查看在途请求数和队列深度，未配置时为 nil。
+func (i *InfluxDB) Semaphore() *semaphore.Weighted {
+	return i.sem
+}
+
+// acquire 占用一个并发请求名额并记录排队时间，返回的函数用于释放名额。
+func (i *InfluxDB) acquire(ctx context.Context) (func(), error) {
+	if i.sem == nil {
+		return func() {}, nil
+	}
+
+	p, _ := ctx.Value(priorityKey{}).(semaphore.Priority)
+
+	qctx := ctx
+	if i.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		qctx, cancel = context.WithTimeout(ctx, i.queueTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := i.sem.AcquirePriority(qctx, 1, p)
+
+	if info, ok := ctx.Value(opInfoKey{}).(*OpInfo); ok {
+		info.QueueWait += time.Since(start)
+	}
+
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrQueueTimeout
+		}
+
+		return nil, err
+	}
+
+	return func() { i.sem.Release(1) }, nil
+}
@@ -0,0 +1,174 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/syncx/semaphore"
+)
+
+func TestAcquire(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		held    int64
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{name: "unlimited", cfg: Config{}},
+		{name: "free slot", cfg: Config{MaxInFlight: 2}, held: 1},
+		{
+			name:    "queue timeout",
+			cfg:     Config{MaxInFlight: 1, QueueTimeout: 10 * time.Millisecond},
+			held:    1,
+			wantErr: ErrQueueTimeout,
+		},
+		{
+			name: "caller deadline",
+			cfg:  Config{MaxInFlight: 1, QueueTimeout: time.Minute},
+			held: 1,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "caller cancelled",
+			cfg:  Config{MaxInFlight: 1},
+			held: 1,
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		i := &InfluxDB{sem: newSemaphore(test.cfg), queueTimeout: test.cfg.QueueTimeout}
+		if i.sem != nil && test.held > 0 && !i.sem.TryAcquire(test.held) {
+			t.Fatalf("%s: TryAcquire(%d) failed", test.name, test.held)
+		}
+
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if test.ctx != nil {
+			ctx, cancel = test.ctx()
+		}
+
+		info := &OpInfo{}
+		release, err := i.acquire(context.WithValue(ctx, opInfoKey{}, info))
+
+		cancel()
+
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: acquire error = %v; want %v", test.name, err, test.wantErr)
+
+			continue
+		}
+
+		if err != nil {
+			if i.sem.InFlight() != test.held {
+				t.Errorf("%s: in flight after failure = %d; want %d", test.name, i.sem.InFlight(), test.held)
+			}
+
+			if test.wantErr == ErrQueueTimeout && info.QueueWait < 10*time.Millisecond {
+				t.Errorf("%s: QueueWait = %v; want the time spent queued", test.name, info.QueueWait)
+			}
+
+			continue
+		}
+
+		release()
+
+		if i.sem != nil && i.sem.InFlight() != test.held {
+			t.Errorf("%s: in flight after release = %d; want %d", test.name, i.sem.InFlight(), test.held)
+		}
+	}
+}
+
+func TestAcquirePriority(t *testing.T) {
+	i := &InfluxDB{sem: semaphore.NewWeighted(1)}
+
+	// 占满唯一的名额，依次排入批处理和交互式请求。
+	release, err := i.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+
+	enqueue := func(ctx context.Context, name string, queued func() bool) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			r, err := i.acquire(ctx)
+			if err != nil {
+				t.Errorf("%s: acquire error: %v", name, err)
+
+				return
+			}
+
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			r()
+		}()
+
+		for !queued() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	enqueue(WithBatch(context.Background()), "batch", func() bool { return i.sem.Queued(semaphore.Batch) == 1 })
+	enqueue(context.Background(), "interactive", func() bool { return i.sem.Queued(semaphore.Interactive) == 1 })
+
+	if got := i.sem.MaxQueued(); got != 2 {
+		t.Errorf("MaxQueued = %d; want 2", got)
+	}
+
+	release()
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != "interactive" || order[1] != "batch" {
+		t.Errorf("acquire order = %v; want [interactive batch]", order)
+	}
+}
+
+func TestSharedSemaphore(t *testing.T) {
+	sem := semaphore.NewWeighted(3)
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want *semaphore.Weighted
+		size int64
+	}{
+		{name: "shared", cfg: Config{Semaphore: sem, MaxInFlight: 10}, want: sem, size: 3},
+		{name: "max in flight", cfg: Config{MaxInFlight: 10}, size: 10},
+		{name: "unlimited", cfg: Config{}},
+	}
+
+	for _, test := range tests {
+		got := newSemaphore(test.cfg)
+
+		switch {
+		case test.size == 0 && got != nil:
+			t.Errorf("%s: newSemaphore = %v; want nil", test.name, got)
+		case test.size > 0 && (got == nil || got.Size() != test.size):
+			t.Errorf("%s: newSemaphore size = %v; want %d", test.name, got, test.size)
+		case test.want != nil && got != test.want:
+			t.Errorf("%s: newSemaphore did not use Config.Semaphore", test.name)
+		}
+	}
+}
@@ -5,14 +5,16 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"path"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/jiurenm/mare/syncx/semaphore"
 	"github.com/jszwec/csvutil"
 )
 
@@ -24,11 +26,21 @@ const (
 )
 
 type InfluxDB struct {
-	Conn    *InfluxClient
-	limiter *Limiter
+	Conn                *InfluxClient
+	limiters            *Limiters
+	sem                 *semaphore.Weighted
+	queueTimeout        time.Duration
+	cache               *queryCache
+	hooks               []Hooks
+	writePointsPerToken int
+	backend             Backend
+	guards              *Guardrails
 }
 
-var ErrNoData = fmt.Errorf("no data found")
+var (
+	ErrNoData   = fmt.Errorf("no data found")
+	ErrNoSeries = errors.New("no series found")
+)
 
 type Config struct {
 	Host     string
@@ -36,84 +48,170 @@ type Config struct {
 	Username string
 	Password string
 	Database string
+	// WriteDatabase 为写入使用的数据库，为空时写入 hypon 以兼容旧版本，新配置应显式设置。
+	WriteDatabase string
+	// CacheTTL 和 CacheSize 均大于 0 时缓存查询结果，CacheSize 为最多缓存的查询数。
+	CacheTTL  time.Duration
+	CacheSize int
+	// HTTPClient 不为空时直接使用，忽略下面的连接配置。
+	HTTPClient *http.Client
+	// Transport 不为空时作为 http.Client 的 Transport，忽略 TLS、连接池和 HTTP/2 配置。
+	Transport http.RoundTripper
+	// Timeout 为单次请求的超时时间，默认为 1 分钟。
+	Timeout             time.Duration
+	TLS                 *TLSConfig
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	DisableHTTP2        bool
+	// Gzip 为 true 时使用 gzip 压缩写入和 Query2（TDengine SQL）的请求体，响应的 gzip 解压由 http.Transport 自动完成。
+	// Query 的 InfluxQL 查询通过 GET 放在 URL 中发送，没有请求体，不受影响。
+	Gzip bool
+	// ReadEndpoints 为查询使用的节点，为空时使用 Host 和 Port。
+	ReadEndpoints []Endpoint
+	// WriteEndpoints 为写入和删除使用的节点，为空时与 ReadEndpoints 相同。
+	WriteEndpoints []Endpoint
+	// Balance 为查询节点的选择策略，默认为 RoundRobin。
+	Balance BalanceStrategy
+	// HealthCheckInterval 大于 0 时定期探测节点健康状态，HealthCheckPath 默认为 /-/ping。
+	HealthCheckInterval time.Duration
+	HealthCheckPath     string
+	// QueryRateLimit 为查询的限流，默认为每秒 150 次；WriteRateLimit 为写入和删除的限流，默认不限流。
+	QueryRateLimit *RateLimit
+	WriteRateLimit *RateLimit
+	// Limiters 不为空时直接使用，忽略 QueryRateLimit 和 WriteRateLimit，可在多个 InfluxDB 之间共享限流。
+	Limiters *Limiters
+	// WritePointsPerToken 大于 0 时每次写入消耗 len(data)/WritePointsPerToken（向上取整）个令牌，
+	// 最多为 WriteRateLimit 的 Burst。
+	WritePointsPerToken int
+	// MaxInFlight 大于 0 时限制同时执行的请求数，超出的请求按 WithPriority 指定的优先级排队。
+	MaxInFlight int64
+	// QueueTimeout 大于 0 时排队超时返回 ErrQueueTimeout。
+	QueueTimeout time.Duration
+	// Semaphore 不为空时直接使用，忽略 MaxInFlight，可在多个 InfluxDB 之间共享并发限制。
+	Semaphore *semaphore.Weighted
+	// Backend 为数据库类型，决定 Databases、Measurements 等 schema 查询使用的语句，默认为 TDengine。
+	Backend Backend
+	// Guardrails 不为空时检查 QueryBuilder 执行的查询，默认不检查。
+	Guardrails *Guardrails
 }
 
 func NewInfluxDB(cfg Config) (*InfluxDB, func(), error) {
-	u, err := url.Parse(fmt.Sprintf("%s:%d/influxdb/v1", cfg.Host, cfg.Port))
+	readEndpoints := cfg.ReadEndpoints
+	if len(readEndpoints) == 0 {
+		readEndpoints = []Endpoint{{Host: cfg.Host, Port: cfg.Port}}
+	}
+
+	writeEndpoints := cfg.WriteEndpoints
+	if len(writeEndpoints) == 0 {
+		writeEndpoints = readEndpoints
+	}
+
+	reads, err := newNodePool(readEndpoints, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	writes, err := newNodePool(writeEndpoints, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := newHTTPClient(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	u.Path = path.Join(u.Path, "write")
-	params := u.Query()
-	params.Set("db", "hypon")
-	params.Set("rp", "")
-	params.Set("precision", "s")
-	params.Set("consistency", "")
-	u.RawQuery = params.Encode()
-
-	i := &InfluxDB{Conn: &InfluxClient{
-		readHost:  fmt.Sprintf("%s:%d/rest/sql/%s", cfg.Host, cfg.Port, cfg.Database),
-		writeHost: u.String(),
-		auth:      "Basic " + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", cfg.Username, cfg.Password))),
-		username:  cfg.Username,
-		password:  cfg.Password,
-		client: &http.Client{
-			Timeout: time.Minute,
+	i := &InfluxDB{
+		Conn: &InfluxClient{
+			reads:    reads,
+			writes:   writes,
+			stop:     make(chan struct{}),
+			auth:     "Basic " + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", cfg.Username, cfg.Password))),
+			username: cfg.Username,
+			password: cfg.Password,
+			gzip:     cfg.Gzip,
+			client:   client,
 		},
-	}, limiter: NewLimiter(150, 1)}
+		limiters:            newLimiters(cfg),
+		sem:                 newSemaphore(cfg),
+		queueTimeout:        cfg.QueueTimeout,
+		writePointsPerToken: cfg.WritePointsPerToken,
+		backend:             cfg.Backend,
+		guards:              cfg.Guardrails,
+	}
+
+	if cfg.CacheTTL > 0 && cfg.CacheSize > 0 {
+		i.cache = newQueryCache(cfg.CacheTTL, cfg.CacheSize)
+	}
+
+	if cfg.HealthCheckInterval > 0 {
+		go reads.healthCheck(client, cfg.HealthCheckInterval, i.Conn.stop)
+
+		if writes != reads {
+			go writes.healthCheck(client, cfg.HealthCheckInterval, i.Conn.stop)
+		}
+	}
 
 	return i, i.Close, nil
 }
 
 // Close 关闭连接。
 func (i *InfluxDB) Close() {
+	i.Conn.closeOnce.Do(func() {
+		if i.Conn.stop != nil {
+			close(i.Conn.stop)
+		}
+	})
 	i.Conn.client.CloseIdleConnections()
 }
 
 type InfluxClient struct {
 	client    *http.Client
-	readHost  string
-	writeHost string
-	baseURL   string
+	reads     *nodePool
+	writes    *nodePool
+	stop      chan struct{}
+	closeOnce sync.Once
 	auth      string
 	username  string
 	password  string
+	gzip      bool
 }
 
 func (i *InfluxDB) Query(ctx context.Context, query string, dst interface{}, format ...FormatType) error {
-	q := url.QueryEscape(query)
-	u := i.Conn.readHost + q
+	ctx, info := i.before(ctx, QueryOperation, query)
+	err := i.query(ctx, info, query, dst, format...)
+	i.after(ctx, info, err)
 
-	ctx1, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	return err
+}
 
-	err := i.limiter.Wait(ctx1)
-	if err != nil {
-		return err
+func (i *InfluxDB) query(ctx context.Context, info *OpInfo, query string, dst interface{}, format ...FormatType) error {
+	isCSV := len(format) > 0 && format[0] == CSV
+
+	key := cacheKey{sql: query, kind: cacheKindJSON}
+	if isCSV {
+		key.kind = cacheKindCSV
 	}
 
-	req, err := http.NewRequestWithContext(ctx1, http.MethodGet, u, nil)
+	body, err := i.cached(ctx, key, func() ([]byte, error) {
+		return i.queryInflux(ctx, query, isCSV)
+	})
 	if err != nil {
 		return err
 	}
 
-	if len(format) > 0 && format[0] == CSV {
-		req.Header.Set("Accept", "application/csv")
-	}
+	info.Bytes = len(body)
 
-	resp, err := i.Conn.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	if isCSV {
+		lines := bytes.Count(body, []byte("\n"))
+		if len(body) > 0 && body[len(body)-1] != '\n' {
+			lines++
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+		if lines > 1 {
+			info.Rows = lines - 1
+		}
 
-	if len(format) > 0 && format[0] == CSV {
 		err = csvutil.Unmarshal(body, dst)
 
 		if err != nil {
@@ -136,12 +234,89 @@ func (i *InfluxDB) Query(ctx context.Context, query string, dst interface{}, for
 
 	results := res.Results[0]
 	if len(results.Series) == 0 {
-		return fmt.Errorf("no series found")
+		return ErrNoSeries
+	}
+
+	for _, series := range results.Series {
+		info.Rows += len(series.Values)
 	}
 
 	return handleSeries(results.Series, dst)
 }
 
+func (i *InfluxDB) queryInflux(ctx context.Context, query string, isCSV bool) ([]byte, error) {
+	q := url.QueryEscape(query)
+
+	ctx1, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := i.wait(ctx1)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := i.acquire(ctx1)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resp, err := i.Conn.reads.do(ctx1, i.Conn.client, func(n *node) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx1, http.MethodGet, n.readHost+q, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if isCSV {
+			req.Header.Set("Accept", "application/csv")
+		}
+
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回错误的响应不写入缓存。
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query failed: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	if !isCSV {
+		if err = influxError(body); err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+// influxError 返回 InfluxDB JSON 响应中的 error 字段。
+func influxError(body []byte) error {
+	var res Response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return err
+	}
+
+	if res.Error != "" {
+		return errors.New(res.Error)
+	}
+
+	for _, r := range res.Results {
+		if r.Error != "" {
+			return errors.New(r.Error)
+		}
+	}
+
+	return nil
+}
+
 func handleSeries(series []Series, dst interface{}) error {
 	var jsonStr []byte
 
@@ -176,66 +351,266 @@ func handleSeries(series []Series, dst interface{}) error {
 	return json.Unmarshal(jsonStr, &dst)
 }
 
+// Delete 执行删除语句。
+//
+// Deprecated: 使用 DeleteFrom 构建删除语句，或使用 DeleteContext。
 func (i *InfluxDB) Delete(query string) error {
-	uri := i.Conn.baseURL
+	_, err := i.DeleteContext(context.Background(), query)
+
+	return err
+}
+
+// DeleteContext 执行删除语句并返回删除的行数。
+func (i *InfluxDB) DeleteContext(ctx context.Context, query string) (int64, error) {
+	ctx, info := i.before(ctx, DeleteOperation, query)
+	n, err := i.exec(ctx, query)
+	info.Rows = int(n)
+	i.after(ctx, info, err)
 
-	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBufferString(query))
+	return n, err
+}
+
+// Exec 在写入节点上执行 DDL 等非查询语句，返回受影响的行数。
+func (i *InfluxDB) Exec(ctx context.Context, query string) (int64, error) {
+	ctx, info := i.before(ctx, ExecOperation, query)
+	n, err := i.exec(ctx, query)
+	info.Rows = int(n)
+	i.after(ctx, info, err)
+
+	return n, err
+}
+
+// exec 执行非查询语句，使用写入限流和写入节点。
+func (i *InfluxDB) exec(ctx context.Context, query string) (int64, error) {
+	if err := i.waitWrite(ctx, 1); err != nil {
+		return 0, err
+	}
+
+	release, err := i.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	resp, err := i.Conn.writes.do(ctx, i.Conn.client, func(n *node) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL, strings.NewReader(query))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", i.Conn.auth)
+
+		return req, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var res TDResponse
+	if err = json.Unmarshal(body, &res); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("exec failed: %s: %s", resp.Status, body)
+		}
+
+		return 0, err
+	}
+
+	if res.Code != 0 {
+		return 0, errors.New(res.Desc)
+	}
+
+	return affectedRows(res), nil
+}
+
+// ExecInfluxQL 在写入节点的 /query 接口上执行 InfluxQL 的 DDL、DELETE 等非查询语句，如 CREATE RETENTION POLICY
+// 和 DROP MEASUREMENT，使用 ExecOperation 调用钩子。
+func (i *InfluxDB) ExecInfluxQL(ctx context.Context, query string) error {
+	ctx, info := i.before(ctx, ExecOperation, query)
+	err := i.execInfluxQL(ctx, query)
+	i.after(ctx, info, err)
+
+	return err
+}
+
+func (i *InfluxDB) execInfluxQL(ctx context.Context, query string) error {
+	if err := i.waitWrite(ctx, 1); err != nil {
+		return err
+	}
+
+	release, err := i.acquire(ctx)
 	if err != nil {
 		return err
 	}
+	defer release()
 
-	req.Header.Set("Authorization", i.Conn.auth)
+	form := url.Values{"q": {query}}.Encode()
 
-	resp, err := i.Conn.client.Do(req)
+	resp, err := i.Conn.writes.do(ctx, i.Conn.client, func(n *node) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.queryURL, strings.NewReader(form))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		if i.Conn.username != "" {
+			req.SetBasicAuth(i.Conn.username, i.Conn.password)
+		}
+
+		return req, nil
+	})
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	return nil
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("exec failed: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	return influxError(body)
+}
+
+// affectedRows 读取 TDengine 非查询语句返回的 affected_rows。
+func affectedRows(res TDResponse) int64 {
+	if len(res.Data) == 0 || len(res.Data[0]) == 0 {
+		return 0
+	}
+
+	n, _ := toFloat(res.Data[0][0])
+
+	return int64(n)
 }
 
 func (i *InfluxDB) Query2(ctx context.Context, query string, dst interface{}, tz ...string) error {
+	rows, err := i.queryRows(ctx, query, tz...)
+	if err != nil {
+		return err
+	}
+
+	return decodeRows(zeroNulls(rows), dst)
+}
+
+// queryRows 执行 TDengine 查询并返回按列名组织的行。
+func (i *InfluxDB) queryRows(ctx context.Context, query string, tz ...string) ([]map[string]any, error) {
+	ctx, info := i.before(ctx, Query2Operation, query)
+	rows, err := i.queryTaosRows(ctx, info, query, tz...)
+	i.after(ctx, info, err)
+
+	return rows, err
+}
+
+func (i *InfluxDB) queryTaosRows(ctx context.Context, info *OpInfo, query string, tz ...string) ([]map[string]any, error) {
+	var timezone string
+	if len(tz) > 0 {
+		timezone = tz[0]
+	}
+
+	body, err := i.cached(ctx, cacheKey{sql: query, tz: timezone, kind: cacheKindTaos}, func() ([]byte, error) {
+		return i.queryTaos(ctx, query, timezone)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info.Bytes = len(body)
+
+	var res TDResponse
+	err = json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tdError(res); err != nil {
+		return nil, err
+	}
+
+	rows := tdRows(res)
+	info.Rows = len(rows)
+
+	return rows, nil
+}
+
+// queryTaos 执行 TDengine 查询，仅返回成功且有数据的响应体。
+func (i *InfluxDB) queryTaos(ctx context.Context, query string, tz string) ([]byte, error) {
 	ctx1, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	err := i.limiter.Wait(ctx1)
+	err := i.wait(ctx1)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	uri := i.Conn.baseURL
-	if len(tz) > 0 && tz[0] != "" {
-		uri = uri + "?tz=" + tz[0]
+	release, err := i.acquire(ctx1)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
 	method := http.MethodPost
-	payload := strings.NewReader(query)
+	payload := []byte(query)
 
-	req, err := http.NewRequest(method, uri, payload)
-	if err != nil {
-		return err
+	if i.Conn.gzip {
+		payload, err = gzipBytes(payload)
+		if err != nil {
+			return nil, err
+		}
 	}
-	req.Header.Add("Authorization", i.Conn.auth)
 
-	resp, err := i.Conn.client.Do(req)
+	resp, err := i.Conn.reads.do(ctx1, i.Conn.client, func(n *node) (*http.Request, error) {
+		uri := n.baseURL
+		if tz != "" {
+			uri = uri + "?tz=" + tz
+		}
+
+		req, err := http.NewRequestWithContext(ctx1, method, uri, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("Authorization", i.Conn.auth)
+
+		if i.Conn.gzip {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		return req, nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body := new(bytes.Buffer)
 	_, err = io.Copy(body, resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var res TDResponse
 	err = json.Unmarshal(body.Bytes(), &res)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err = tdError(res); err != nil {
+		return nil, err
 	}
 
+	return body.Bytes(), nil
+}
+
+func tdError(res TDResponse) error {
 	if res.Code != 0 {
 		if strings.Contains(res.Desc, "Table does not exist") {
 			return ErrNoData
@@ -252,16 +627,18 @@ func (i *InfluxDB) Query2(ctx context.Context, query string, dst interface{}, tz
 		return ErrNoData
 	}
 
-	return handleSeries2(res, dst)
+	return nil
 }
 
 type Response struct {
 	Results []Result `json:"results"`
+	Error   string   `json:"error"`
 }
 
 type Result struct {
 	Series      []Series `json:"series"`
 	StatementID int      `json:"statement_id"`
+	Error       string   `json:"error"`
 }
 
 type Series struct {
@@ -279,7 +656,7 @@ type TDResponse struct {
 	Rows       int              `json:"rows"`
 }
 
-func handleSeries2(series TDResponse, dst any) error {
+func tdRows(series TDResponse) []map[string]any {
 	head := make([]string, len(series.ColumnMeta))
 
 	for i := 0; i < len(series.ColumnMeta); i++ {
@@ -293,17 +670,30 @@ func handleSeries2(series TDResponse, dst any) error {
 		val := make(map[string]any, len(head))
 
 		for j := 0; j < len(head); j++ {
-			if data[j] == nil {
-				val[head[j]] = 0
-			} else {
-				val[head[j]] = data[j]
-			}
+			val[head[j]] = data[j]
 		}
 
 		res = append(res, val)
 	}
 
-	jsonStr, err := json.Marshal(res)
+	return res
+}
+
+// zeroNulls 将 NULL 替换为 0，Query2 解析的结果中 NULL 一直为 0。
+func zeroNulls(rows []map[string]any) []map[string]any {
+	for _, row := range rows {
+		for col, v := range row {
+			if v == nil {
+				row[col] = 0
+			}
+		}
+	}
+
+	return rows
+}
+
+func decodeRows(rows []map[string]any, dst any) error {
+	jsonStr, err := json.Marshal(rows)
 	if err != nil {
 		return err
 	}
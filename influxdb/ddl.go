@@ -0,0 +1,607 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrAlterOneChange = errors.New("ALTER STABLE supports exactly one change per statement")
+
+func errDDLNotSupported(stmt string) error {
+	return fmt.Errorf("%s not supported by dialect", stmt)
+}
+
+// ColumnDef 为建表时的列或标签定义，Type 原样输出，如 TIMESTAMP、FLOAT、BINARY(64)。
+type ColumnDef struct {
+	Name string
+	Type string
+}
+
+func NewColumnDef(name, typ string) ColumnDef {
+	return ColumnDef{Name: name, Type: typ}
+}
+
+// ddlSQL 为各 DDL 构建器共用的 SQL 生成工具。
+type ddlSQL struct {
+	sb  SQLBuilder
+	esg ExpressionSQLGenerator
+	do  *SQLDialectOptions
+}
+
+func newDDLSQL(do *SQLDialectOptions) *ddlSQL {
+	if do == nil {
+		do = defaultDialectOptions
+	}
+
+	return &ddlSQL{sb: newSQLBuilder(false), esg: newExpressionSQLGenerator(do), do: do}
+}
+
+// begin 写入语句开头，fragment 为 nil 或 cond 为 true 而 condFragment 为 nil 时设置不支持的错误。
+func (d *ddlSQL) begin(stmt string, fragment []byte, cond bool, condFragment []byte) {
+	if fragment == nil {
+		d.sb.SetError(errDDLNotSupported(stmt))
+		return
+	}
+
+	if cond && condFragment == nil {
+		if strings.HasPrefix(stmt, "DROP") {
+			d.sb.SetError(errDDLNotSupported(stmt + " IF EXISTS"))
+		} else {
+			d.sb.SetError(errDDLNotSupported(stmt + " IF NOT EXISTS"))
+		}
+
+		return
+	}
+
+	d.sb.Write(fragment)
+
+	if cond {
+		d.sb.Write(condFragment)
+	}
+}
+
+// option 写入可选项，v 为 nil 时跳过，fragment 为 nil 时设置不支持的错误。
+func (d *ddlSQL) option(name string, fragment []byte, v interface{}) {
+	if v == nil || d.sb.Error() != nil {
+		return
+	}
+
+	if fragment == nil {
+		d.sb.SetError(errDDLNotSupported(name))
+		return
+	}
+
+	d.sb.Write(fragment)
+	d.value(v)
+}
+
+// literal 与 option 相同，但 string 按字符串字面量输出。
+func (d *ddlSQL) literal(name string, fragment []byte, v interface{}) {
+	if v == nil || d.sb.Error() != nil {
+		return
+	}
+
+	if fragment == nil {
+		d.sb.SetError(errDDLNotSupported(name))
+		return
+	}
+
+	d.sb.Write(fragment)
+	d.esg.Generate(d.sb, v)
+}
+
+// value 写入选项值，string 原样输出，其他类型按字面量输出，如 time.Duration 输出为 10d。
+func (d *ddlSQL) value(v interface{}) {
+	if s, ok := v.(string); ok {
+		d.sb.WriteStrings(s)
+		return
+	}
+
+	d.esg.Generate(d.sb, v)
+}
+
+func (d *ddlSQL) ident(name string) {
+	d.esg.Generate(d.sb, ParseIdentifier(name))
+}
+
+func (d *ddlSQL) columnDefs(defs []ColumnDef) {
+	d.sb.WriteRunes(d.do.LeftParenRune)
+
+	for i, def := range defs {
+		if i > 0 {
+			d.sb.WriteRunes(d.do.CommaRune, d.do.SpaceRune)
+		}
+
+		d.columnDef(def)
+	}
+
+	d.sb.WriteRunes(d.do.RightParenRune)
+}
+
+func (d *ddlSQL) columnDef(def ColumnDef) {
+	d.ident(def.Name)
+	d.sb.WriteRunes(d.do.SpaceRune).WriteStrings(def.Type)
+}
+
+func (d *ddlSQL) toSQL() (string, error) {
+	return d.sb.ToSQL()
+}
+
+// DatabaseBuilder 构建 CREATE DATABASE 和 DROP DATABASE 语句。
+type DatabaseBuilder struct {
+	do        *SQLDialectOptions
+	keep      interface{}
+	duration  interface{}
+	precision interface{}
+	replica   interface{}
+	name      string
+	cond      bool
+	drop      bool
+}
+
+func CreateDatabase(name string) *DatabaseBuilder {
+	return &DatabaseBuilder{name: name}
+}
+
+func DropDatabase(name string) *DatabaseBuilder {
+	return &DatabaseBuilder{name: name, drop: true}
+}
+
+// Dialect 指定生成 SQL 使用的方言，如 InfluxQLDialectOptions()。
+func (b *DatabaseBuilder) Dialect(do *SQLDialectOptions) *DatabaseBuilder {
+	b.do = do
+
+	return b
+}
+
+// IfNotExists 在创建时添加 IF NOT EXISTS，删除时添加 IF EXISTS。
+func (b *DatabaseBuilder) IfNotExists() *DatabaseBuilder {
+	b.cond = true
+
+	return b
+}
+
+// IfExists 与 IfNotExists 相同，用于 DropDatabase。
+func (b *DatabaseBuilder) IfExists() *DatabaseBuilder {
+	return b.IfNotExists()
+}
+
+// Keep 设置数据保留时长，可以是 time.Duration、int（天）或原样输出的 string，如 "365d"。
+func (b *DatabaseBuilder) Keep(keep interface{}) *DatabaseBuilder {
+	b.keep = keep
+
+	return b
+}
+
+// Duration 设置数据文件（InfluxQL 为 shard）的时间跨度。
+func (b *DatabaseBuilder) Duration(duration interface{}) *DatabaseBuilder {
+	b.duration = duration
+
+	return b
+}
+
+// Precision 设置时间戳精度，如 ms、us、ns。
+func (b *DatabaseBuilder) Precision(precision string) *DatabaseBuilder {
+	b.precision = precision
+
+	return b
+}
+
+func (b *DatabaseBuilder) Replica(replica int) *DatabaseBuilder {
+	b.replica = replica
+
+	return b
+}
+
+func (b *DatabaseBuilder) ToSQL() (string, error) {
+	d := newDDLSQL(b.do)
+
+	if b.drop {
+		d.begin("DROP DATABASE", d.do.DropDatabaseFragment, b.cond, d.do.IfExistsFragment)
+		d.ident(b.name)
+
+		return d.toSQL()
+	}
+
+	d.begin("CREATE DATABASE", d.do.CreateDatabaseFragment, b.cond, d.do.IfNotExistsFragment)
+	d.ident(b.name)
+
+	if b.keep != nil || b.duration != nil || b.precision != nil || b.replica != nil {
+		d.sb.Write(d.do.DatabaseOptionsFragment)
+	}
+
+	d.option("KEEP", d.do.KeepFragment, b.keep)
+	d.option("DURATION", d.do.DatabaseDurationFragment, b.duration)
+	d.literal("PRECISION", d.do.PrecisionFragment, b.precision)
+	d.option("REPLICA", d.do.ReplicationFragment, b.replica)
+
+	return d.toSQL()
+}
+
+func (b *DatabaseBuilder) Exec(ctx context.Context, conn Executor) error {
+	return execDDL(ctx, conn, b.do, b.ToSQL)
+}
+
+type alterAction struct {
+	fragment func(do *SQLDialectOptions) []byte
+	name     string
+	def      ColumnDef
+	drop     bool
+}
+
+// STableBuilder 构建超级表的 CREATE STABLE、DROP STABLE 和 ALTER STABLE 语句。
+type STableBuilder struct {
+	do      *SQLDialectOptions
+	name    string
+	columns []ColumnDef
+	tags    []ColumnDef
+	alters  []alterAction
+	cond    bool
+	drop    bool
+	alter   bool
+}
+
+// CreateSTable 创建超级表，第一列必须为 TIMESTAMP 类型。
+func CreateSTable(name string) *STableBuilder {
+	return &STableBuilder{name: name}
+}
+
+func DropSTable(name string) *STableBuilder {
+	return &STableBuilder{name: name, drop: true}
+}
+
+// AlterSTable 修改超级表，每条语句只能包含一个 AddColumn、DropColumn、AddTag 或 DropTag。
+func AlterSTable(name string) *STableBuilder {
+	return &STableBuilder{name: name, alter: true}
+}
+
+func (b *STableBuilder) Dialect(do *SQLDialectOptions) *STableBuilder {
+	b.do = do
+
+	return b
+}
+
+// IfNotExists 在创建时添加 IF NOT EXISTS，删除时添加 IF EXISTS。
+func (b *STableBuilder) IfNotExists() *STableBuilder {
+	b.cond = true
+
+	return b
+}
+
+func (b *STableBuilder) IfExists() *STableBuilder {
+	return b.IfNotExists()
+}
+
+func (b *STableBuilder) Columns(defs ...ColumnDef) *STableBuilder {
+	b.columns = append(b.columns, defs...)
+
+	return b
+}
+
+func (b *STableBuilder) Tags(defs ...ColumnDef) *STableBuilder {
+	b.tags = append(b.tags, defs...)
+
+	return b
+}
+
+func (b *STableBuilder) AddColumn(def ColumnDef) *STableBuilder {
+	b.alters = append(b.alters, alterAction{
+		fragment: func(do *SQLDialectOptions) []byte { return do.AddColumnFragment },
+		def:      def,
+	})
+
+	return b
+}
+
+func (b *STableBuilder) DropColumn(name string) *STableBuilder {
+	b.alters = append(b.alters, alterAction{
+		fragment: func(do *SQLDialectOptions) []byte { return do.DropColumnFragment },
+		name:     name,
+		drop:     true,
+	})
+
+	return b
+}
+
+func (b *STableBuilder) AddTag(def ColumnDef) *STableBuilder {
+	b.alters = append(b.alters, alterAction{
+		fragment: func(do *SQLDialectOptions) []byte { return do.AddTagFragment },
+		def:      def,
+	})
+
+	return b
+}
+
+func (b *STableBuilder) DropTag(name string) *STableBuilder {
+	b.alters = append(b.alters, alterAction{
+		fragment: func(do *SQLDialectOptions) []byte { return do.DropTagFragment },
+		name:     name,
+		drop:     true,
+	})
+
+	return b
+}
+
+func (b *STableBuilder) ToSQL() (string, error) {
+	d := newDDLSQL(b.do)
+
+	switch {
+	case b.drop:
+		d.begin("DROP STABLE", d.do.DropSTableFragment, b.cond, d.do.IfExistsFragment)
+		d.ident(b.name)
+	case b.alter:
+		if len(b.alters) != 1 {
+			return "", ErrAlterOneChange
+		}
+
+		d.begin("ALTER STABLE", d.do.AlterSTableFragment, false, nil)
+		d.ident(b.name)
+
+		a := b.alters[0]
+		d.sb.Write(a.fragment(d.do))
+
+		if a.drop {
+			d.ident(a.name)
+		} else {
+			d.columnDef(a.def)
+		}
+	default:
+		if len(b.columns) == 0 || len(b.tags) == 0 {
+			return "", errors.New("CREATE STABLE requires at least one column and one tag")
+		}
+
+		d.begin("CREATE STABLE", d.do.CreateSTableFragment, b.cond, d.do.IfNotExistsFragment)
+		d.ident(b.name)
+		d.sb.WriteRunes(d.do.SpaceRune)
+		d.columnDefs(b.columns)
+		d.sb.Write(d.do.TagsFragment)
+		d.columnDefs(b.tags)
+	}
+
+	return d.toSQL()
+}
+
+func (b *STableBuilder) Exec(ctx context.Context, conn Executor) error {
+	return execDDL(ctx, conn, b.do, b.ToSQL)
+}
+
+// TableBuilder 构建普通表或子表的 CREATE TABLE 和 DROP TABLE 语句，InfluxQL 中 DROP TABLE 为 DROP MEASUREMENT。
+type TableBuilder struct {
+	do        *SQLDialectOptions
+	name      string
+	stable    string
+	columns   []ColumnDef
+	tagNames  []string
+	tagValues []interface{}
+	cond      bool
+	drop      bool
+}
+
+func CreateTable(name string) *TableBuilder {
+	return &TableBuilder{name: name}
+}
+
+func DropTable(name string) *TableBuilder {
+	return &TableBuilder{name: name, drop: true}
+}
+
+func (b *TableBuilder) Dialect(do *SQLDialectOptions) *TableBuilder {
+	b.do = do
+
+	return b
+}
+
+// IfNotExists 在创建时添加 IF NOT EXISTS，删除时添加 IF EXISTS。
+func (b *TableBuilder) IfNotExists() *TableBuilder {
+	b.cond = true
+
+	return b
+}
+
+func (b *TableBuilder) IfExists() *TableBuilder {
+	return b.IfNotExists()
+}
+
+// Columns 设置普通表的列，与 Using 互斥。
+func (b *TableBuilder) Columns(defs ...ColumnDef) *TableBuilder {
+	b.columns = append(b.columns, defs...)
+
+	return b
+}
+
+// Using 以超级表 stable 为模板创建子表，values 为标签值，按超级表的标签顺序排列。
+func (b *TableBuilder) Using(stable string, values ...interface{}) *TableBuilder {
+	b.stable = stable
+	b.tagValues = values
+
+	return b
+}
+
+// TagNames 指定 Using 中标签值对应的标签名，未指定的标签为 NULL。
+func (b *TableBuilder) TagNames(names ...string) *TableBuilder {
+	b.tagNames = names
+
+	return b
+}
+
+func (b *TableBuilder) ToSQL() (string, error) {
+	d := newDDLSQL(b.do)
+
+	if b.drop {
+		d.begin("DROP TABLE", d.do.DropTableFragment, b.cond, d.do.IfExistsFragment)
+		d.ident(b.name)
+
+		return d.toSQL()
+	}
+
+	d.begin("CREATE TABLE", d.do.CreateTableFragment, b.cond, d.do.IfNotExistsFragment)
+	d.ident(b.name)
+
+	switch {
+	case b.stable != "" && len(b.columns) > 0:
+		return "", errors.New("CREATE TABLE can not have both columns and USING")
+	case b.stable != "":
+		if len(b.tagNames) > 0 && len(b.tagNames) != len(b.tagValues) {
+			return "", fmt.Errorf("CREATE TABLE has %d tag names but %d tag values", len(b.tagNames), len(b.tagValues))
+		}
+
+		d.sb.Write(d.do.UsingFragment)
+		d.ident(b.stable)
+
+		if len(b.tagNames) > 0 {
+			d.sb.WriteRunes(d.do.SpaceRune)
+			d.esg.Generate(d.sb, identifiers(b.tagNames))
+		}
+
+		d.sb.Write(d.do.TagsFragment)
+		d.esg.Generate(d.sb, b.tagValues)
+	default:
+		if len(b.columns) == 0 {
+			return "", errors.New("CREATE TABLE requires columns or USING")
+		}
+
+		d.sb.WriteRunes(d.do.SpaceRune)
+		d.columnDefs(b.columns)
+	}
+
+	return d.toSQL()
+}
+
+func (b *TableBuilder) Exec(ctx context.Context, conn Executor) error {
+	return execDDL(ctx, conn, b.do, b.ToSQL)
+}
+
+// RetentionPolicyBuilder 构建 InfluxQL 的 CREATE RETENTION POLICY 和 DROP RETENTION POLICY 语句。
+type RetentionPolicyBuilder struct {
+	do            *SQLDialectOptions
+	duration      interface{}
+	shardDuration interface{}
+	name          string
+	database      string
+	replication   int
+	isDefault     bool
+	drop          bool
+}
+
+// CreateRetentionPolicy 创建保留策略，默认副本数为 1。
+func CreateRetentionPolicy(name, database string) *RetentionPolicyBuilder {
+	return &RetentionPolicyBuilder{name: name, database: database, replication: 1}
+}
+
+func DropRetentionPolicy(name, database string) *RetentionPolicyBuilder {
+	return &RetentionPolicyBuilder{name: name, database: database, drop: true}
+}
+
+func (b *RetentionPolicyBuilder) Dialect(do *SQLDialectOptions) *RetentionPolicyBuilder {
+	b.do = do
+
+	return b
+}
+
+// Duration 设置数据保留时长，可以是 time.Duration 或原样输出的 string，如 "INF"。
+func (b *RetentionPolicyBuilder) Duration(duration interface{}) *RetentionPolicyBuilder {
+	b.duration = duration
+
+	return b
+}
+
+func (b *RetentionPolicyBuilder) ShardDuration(duration interface{}) *RetentionPolicyBuilder {
+	b.shardDuration = duration
+
+	return b
+}
+
+func (b *RetentionPolicyBuilder) Replication(n int) *RetentionPolicyBuilder {
+	b.replication = n
+
+	return b
+}
+
+// Default 将保留策略设置为数据库的默认策略。
+func (b *RetentionPolicyBuilder) Default() *RetentionPolicyBuilder {
+	b.isDefault = true
+
+	return b
+}
+
+func (b *RetentionPolicyBuilder) ToSQL() (string, error) {
+	d := newDDLSQL(b.do)
+
+	if b.drop {
+		d.begin("DROP RETENTION POLICY", d.do.DropRetentionPolicyFragment, false, nil)
+		d.ident(b.name)
+		d.sb.Write(d.do.OnFragment)
+		d.ident(b.database)
+
+		return d.toSQL()
+	}
+
+	if b.duration == nil {
+		return "", errors.New("CREATE RETENTION POLICY requires a duration")
+	}
+
+	d.begin("CREATE RETENTION POLICY", d.do.CreateRetentionPolicyFragment, false, nil)
+	d.ident(b.name)
+	d.sb.Write(d.do.OnFragment)
+	d.ident(b.database)
+	d.option("DURATION", d.do.KeepFragment, b.duration)
+	d.option("REPLICATION", d.do.ReplicationFragment, b.replication)
+	d.option("SHARD DURATION", d.do.DatabaseDurationFragment, b.shardDuration)
+
+	if b.isDefault {
+		d.option("DEFAULT", d.do.DefaultPolicyFragment, "")
+	}
+
+	return d.toSQL()
+}
+
+func (b *RetentionPolicyBuilder) Exec(ctx context.Context, conn Executor) error {
+	return execDDL(ctx, conn, b.do, b.ToSQL)
+}
+
+func execDDL(ctx context.Context, conn Executor, do *SQLDialectOptions, toSQL func() (string, error)) error {
+	sql, err := toSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = execStatement(ctx, conn, do, sql)
+
+	return err
+}
+
+// influxQLExecutor 在 InfluxQL 的 /query 接口上执行非查询语句，*InfluxDB 和各装饰器实现了它。
+type influxQLExecutor interface {
+	ExecInfluxQL(ctx context.Context, query string) error
+}
+
+// execStatement 执行非查询语句，InfluxQL 方言的语句发送到 /query，不返回受影响的行数。
+func execStatement(ctx context.Context, conn Executor, do *SQLDialectOptions, query string) (int64, error) {
+	if do != nil && do.InfluxQL {
+		return 0, execInfluxQL(ctx, conn, query)
+	}
+
+	return conn.Exec(ctx, query)
+}
+
+// execInfluxQL 在 conn 支持时使用 ExecInfluxQL，否则退回到 Exec，如测试使用的 mock。
+func execInfluxQL(ctx context.Context, conn Executor, query string) error {
+	if e, ok := conn.(influxQLExecutor); ok {
+		return e.ExecInfluxQL(ctx, query)
+	}
+
+	_, err := conn.Exec(ctx, query)
+
+	return err
+}
+
+func identifiers(names []string) []interface{} {
+	res := make([]interface{}, len(names))
+	for i, name := range names {
+		res[i] = ParseIdentifier(name)
+	}
+
+	return res
+}
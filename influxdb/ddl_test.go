@@ -0,0 +1,274 @@
+package influxdb_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+	"github.com/jiurenm/mare/influxdb/influxdbtest"
+)
+
+func TestDDLToSQL(t *testing.T) {
+	influxQL := influxdb.InfluxQLDialectOptions()
+	year := 365 * 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		toSQL   func() (string, error)
+		sql     string
+		wantErr string
+	}{
+		{
+			name: "create database",
+			toSQL: influxdb.CreateDatabase("power").IfNotExists().Keep(year).Duration("10d").
+				Precision("ms").Replica(3).ToSQL,
+			sql: "CREATE DATABASE IF NOT EXISTS power KEEP 365d DURATION 10d PRECISION 'ms' REPLICA 3",
+		},
+		{
+			name:  "drop database",
+			toSQL: influxdb.DropDatabase("power").IfExists().ToSQL,
+			sql:   "DROP DATABASE IF EXISTS power",
+		},
+		{
+			name:  "influxql create database",
+			toSQL: influxdb.CreateDatabase("power").Dialect(influxQL).Keep(30 * 24 * time.Hour).Duration(24 * time.Hour).ToSQL,
+			sql:   "CREATE DATABASE power WITH DURATION 30d SHARD DURATION 1d",
+		},
+		{
+			name:    "influxql create database if not exists",
+			toSQL:   influxdb.CreateDatabase("power").Dialect(influxQL).IfNotExists().ToSQL,
+			wantErr: "CREATE DATABASE IF NOT EXISTS not supported by dialect",
+		},
+		{
+			name:    "influxql precision",
+			toSQL:   influxdb.CreateDatabase("power").Dialect(influxQL).Precision("ms").ToSQL,
+			wantErr: "PRECISION not supported by dialect",
+		},
+		{
+			name: "create stable",
+			toSQL: influxdb.CreateSTable("meters").IfNotExists().
+				Columns(influxdb.NewColumnDef("ts", "TIMESTAMP"), influxdb.NewColumnDef("current", "FLOAT")).
+				Tags(influxdb.NewColumnDef("location", "BINARY(64)"), influxdb.NewColumnDef("group_id", "INT")).ToSQL,
+			sql: "CREATE STABLE IF NOT EXISTS meters (ts TIMESTAMP, current FLOAT) TAGS (location BINARY(64), group_id INT)",
+		},
+		{
+			name:    "create stable without tags",
+			toSQL:   influxdb.CreateSTable("meters").Columns(influxdb.NewColumnDef("ts", "TIMESTAMP")).ToSQL,
+			wantErr: "requires at least one column and one tag",
+		},
+		{
+			name:  "alter stable add column",
+			toSQL: influxdb.AlterSTable("meters").AddColumn(influxdb.NewColumnDef("phase", "FLOAT")).ToSQL,
+			sql:   "ALTER STABLE meters ADD COLUMN phase FLOAT",
+		},
+		{
+			name:  "alter stable drop tag",
+			toSQL: influxdb.AlterSTable("meters").DropTag("group_id").ToSQL,
+			sql:   "ALTER STABLE meters DROP TAG group_id",
+		},
+		{
+			name:    "alter stable two changes",
+			toSQL:   influxdb.AlterSTable("meters").AddTag(influxdb.NewColumnDef("a", "INT")).DropTag("b").ToSQL,
+			wantErr: influxdb.ErrAlterOneChange.Error(),
+		},
+		{
+			name:  "drop stable",
+			toSQL: influxdb.DropSTable("meters").IfExists().ToSQL,
+			sql:   "DROP STABLE IF EXISTS meters",
+		},
+		{
+			name:    "influxql drop stable",
+			toSQL:   influxdb.DropSTable("meters").Dialect(influxQL).ToSQL,
+			wantErr: "DROP STABLE not supported by dialect",
+		},
+		{
+			name: "create child table",
+			toSQL: influxdb.CreateTable("d1001").IfNotExists().Using("meters", "beijing", 2).
+				TagNames("location", "group_id").ToSQL,
+			sql: "CREATE TABLE IF NOT EXISTS d1001 USING meters (location, group_id) TAGS ('beijing', 2)",
+		},
+		{
+			name:    "create child table with mismatched tags",
+			toSQL:   influxdb.CreateTable("d1001").Using("meters", "beijing").TagNames("location", "group_id").ToSQL,
+			wantErr: "2 tag names but 1 tag values",
+		},
+		{
+			name:  "create table",
+			toSQL: influxdb.CreateTable("t").Columns(influxdb.NewColumnDef("ts", "TIMESTAMP"), influxdb.NewColumnDef("v", "INT")).ToSQL,
+			sql:   "CREATE TABLE t (ts TIMESTAMP, v INT)",
+		},
+		{
+			name:  "influxql drop measurement",
+			toSQL: influxdb.DropTable("cpu").Dialect(influxQL).ToSQL,
+			sql:   "DROP MEASUREMENT cpu",
+		},
+		{
+			name:    "influxql drop measurement if exists",
+			toSQL:   influxdb.DropTable("cpu").Dialect(influxQL).IfExists().ToSQL,
+			wantErr: "DROP TABLE IF EXISTS not supported by dialect",
+		},
+		{
+			name: "create retention policy",
+			toSQL: influxdb.CreateRetentionPolicy("one_year", "power").Dialect(influxQL).Duration(year).
+				ShardDuration("7d").Default().ToSQL,
+			sql: "CREATE RETENTION POLICY one_year ON power DURATION 365d REPLICATION 1 SHARD DURATION 7d DEFAULT",
+		},
+		{
+			name:  "drop retention policy",
+			toSQL: influxdb.DropRetentionPolicy("one_year", "power").Dialect(influxQL).ToSQL,
+			sql:   "DROP RETENTION POLICY one_year ON power",
+		},
+		{
+			name:    "tdengine retention policy",
+			toSQL:   influxdb.CreateRetentionPolicy("one_year", "power").Duration("1d").ToSQL,
+			wantErr: "CREATE RETENTION POLICY not supported by dialect",
+		},
+	}
+
+	for _, test := range tests {
+		sql, err := test.toSQL()
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: error = %v; want %q", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if sql != test.sql {
+			t.Errorf("%s: ToSQL() = %s; want %s", test.name, sql, test.sql)
+		}
+	}
+}
+
+func TestDDLExecRouting(t *testing.T) {
+	influxQL := influxdb.InfluxQLDialectOptions()
+	ctx := context.Background()
+	sqlPath := "POST /rest/sql/" + influxdbtest.DefaultDatabase
+
+	tests := []struct {
+		name string
+		exec func(conn influxdb.Executor) error
+		path string
+		// left 为执行后 cpu 中剩余的数据点数。
+		left int
+	}{
+		{
+			name: "tdengine drop table",
+			exec: func(conn influxdb.Executor) error { return influxdb.DropTable("cpu").IfExists().Exec(ctx, conn) },
+			path: sqlPath,
+		},
+		{
+			name: "influxql drop measurement",
+			exec: func(conn influxdb.Executor) error { return influxdb.DropTable("cpu").Dialect(influxQL).Exec(ctx, conn) },
+			path: "POST /query",
+		},
+		{
+			name: "influxql retention policy",
+			exec: func(conn influxdb.Executor) error {
+				return influxdb.CreateRetentionPolicy("rp", influxdbtest.DefaultDatabase).Dialect(influxQL).
+					Duration(time.Hour).Exec(ctx, conn)
+			},
+			path: "POST /query",
+			left: 4,
+		},
+		{
+			name: "influxql database",
+			exec: func(conn influxdb.Executor) error {
+				return influxdb.CreateDatabase("other").Dialect(influxQL).Exec(ctx, conn)
+			},
+			path: "POST /query",
+			left: 4,
+		},
+		{
+			name: "influxql delete",
+			exec: func(conn influxdb.Executor) error {
+				_, err := influxdb.DeleteFrom("cpu").Dialect(influxQL).Where(influxdb.C("host").Eq("a")).Exec(ctx, conn)
+				return err
+			},
+			path: "POST /query",
+			left: 2,
+		},
+		{
+			name: "influxql drop retention policy",
+			exec: func(conn influxdb.Executor) error {
+				return influxdb.DropRetentionPolicy("rp", "db").Dialect(influxQL).Exec(ctx, conn)
+			},
+			path: "POST /query",
+			left: 4,
+		},
+	}
+
+	for _, test := range tests {
+		for _, decorate := range []bool{false, true} {
+			rt := &recordTransport{}
+			db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.Transport = rt })
+			insertCPU(srv, []string{"a", "b"}, 2)
+
+			var conn influxdb.Executor = db
+			if decorate {
+				conn = influxdb.Decorate(db, influxdb.HookDecorator(&influxdb.Stats{}), influxdb.RetryDecorator(2, time.Millisecond))
+			}
+
+			name := fmt.Sprintf("%s (decorated %v)", test.name, decorate)
+
+			if err := test.exec(conn); err != nil {
+				t.Errorf("%s: Exec error: %v", name, err)
+
+				continue
+			}
+
+			if got := rt.take(); len(got) != 1 || got[0] != test.path {
+				t.Errorf("%s: requests = %v; want %s", name, got, test.path)
+			}
+
+			if left := len(srv.Points("cpu")); left != test.left {
+				t.Errorf("%s: %d points left; want %d", name, left, test.left)
+			}
+		}
+	}
+}
+
+func TestExecInfluxQLError(t *testing.T) {
+	db, srv := newTestDB(t)
+	srv.FailNext("retention policy not found: rp")
+
+	err := influxdb.DropRetentionPolicy("rp", "db").Dialect(influxdb.InfluxQLDialectOptions()).Exec(context.Background(), db)
+	if err == nil || !strings.Contains(err.Error(), "retention policy not found") {
+		t.Errorf("Exec error = %v; want the error from the response", err)
+	}
+}
+
+// recordExecutor 记录 Exec 收到的语句，不实现 ExecInfluxQL。
+type recordExecutor struct {
+	queries []string
+}
+
+func (e *recordExecutor) Exec(_ context.Context, query string) (int64, error) {
+	e.queries = append(e.queries, query)
+
+	return 0, nil
+}
+
+func TestDDLExecFallback(t *testing.T) {
+	e := &recordExecutor{}
+
+	err := influxdb.DropTable("cpu").Dialect(influxdb.InfluxQLDialectOptions()).Exec(context.Background(), e)
+	if err != nil || len(e.queries) != 1 || e.queries[0] != "DROP MEASUREMENT cpu" {
+		t.Errorf("Exec = %v, queries %v; want DROP MEASUREMENT cpu through Exec", err, e.queries)
+	}
+
+	err = influxdb.DropTable("cpu").Dialect(influxdb.InfluxQLDialectOptions()).IfExists().Exec(context.Background(), e)
+	if err == nil || len(e.queries) != 1 {
+		t.Errorf("Exec with IfExists = %v; want an error without executing", err)
+	}
+}
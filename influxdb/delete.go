@@ -0,0 +1,240 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDeleteWithoutWhere 表示 DELETE 没有 WHERE 条件，删除整张表需要调用 All。
+var ErrDeleteWithoutWhere = errors.New("delete without where clause, call All to delete every row")
+
+type DeleteClauses interface {
+	From() ColumnListExpression
+	SetFrom(cl ColumnListExpression) DeleteClauses
+
+	Where() ExpressionList
+	WhereAppend(expressions ...Expression) DeleteClauses
+
+	Clone() DeleteClauses
+}
+
+type deleteClauses struct {
+	from  ColumnListExpression
+	where ExpressionList
+}
+
+func newDeleteClauses() DeleteClauses {
+	return &deleteClauses{}
+}
+
+func (dc *deleteClauses) From() ColumnListExpression {
+	return dc.from
+}
+
+func (dc *deleteClauses) SetFrom(cl ColumnListExpression) DeleteClauses {
+	dc.from = cl
+
+	return dc
+}
+
+func (dc *deleteClauses) Where() ExpressionList {
+	return dc.where
+}
+
+func (dc *deleteClauses) WhereAppend(expressions ...Expression) DeleteClauses {
+	if len(expressions) == 0 {
+		return dc
+	}
+
+	if dc.where == nil {
+		dc.where = NewExpressionList(AndType, expressions...)
+	} else {
+		dc.where = dc.where.Append(expressions...)
+	}
+
+	return dc
+}
+
+func (dc *deleteClauses) Clone() DeleteClauses {
+	return &deleteClauses{
+		from:  dc.from,
+		where: dc.where,
+	}
+}
+
+// DeleteBuilder 构建 DELETE 语句，如
+//
+//	DeleteFrom("meters").TimeRange("ts", start, end).Where(C("location").Eq("beijing"))
+type DeleteBuilder struct {
+	dialect        SQLDialect
+	dialectOptions *SQLDialectOptions
+	clauses        DeleteClauses
+	table          string
+	dryRun         bool
+	all            bool
+}
+
+func DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{
+		dialect: defaultDialect,
+		clauses: newDeleteClauses().SetFrom(newColumnListExpression(table)),
+		table:   table,
+	}
+}
+
+// Dialect 指定生成 SQL 使用的方言，如 InfluxQLDialectOptions()。
+func (db *DeleteBuilder) Dialect(do *SQLDialectOptions) *DeleteBuilder {
+	db.dialect = newDialect(do)
+	db.dialectOptions = do
+
+	return db
+}
+
+func (db *DeleteBuilder) Where(expressions ...Expression) *DeleteBuilder {
+	db.clauses.WhereAppend(expressions...)
+
+	return db
+}
+
+// TimeRange 删除 [start, end) 内的数据。
+func (db *DeleteBuilder) TimeRange(timeCol string, start, end time.Time) *DeleteBuilder {
+	return db.Where(C(timeCol).Gte(start), C(timeCol).Lt(end))
+}
+
+// All 允许没有 WHERE 条件的删除，即删除表中的所有数据。
+func (db *DeleteBuilder) All() *DeleteBuilder {
+	db.all = true
+
+	return db
+}
+
+// DryRun 开启后 Exec 不执行删除，只通过 COUNT 查询返回将被删除的行数。
+func (db *DeleteBuilder) DryRun() *DeleteBuilder {
+	db.dryRun = true
+
+	return db
+}
+
+func (db *DeleteBuilder) Clone() *DeleteBuilder {
+	return &DeleteBuilder{
+		dialect:        db.dialect,
+		dialectOptions: db.dialectOptions,
+		clauses:        db.clauses.Clone(),
+		table:          db.table,
+		dryRun:         db.dryRun,
+		all:            db.all,
+	}
+}
+
+// ToSQL 生成 DELETE 语句，没有 WHERE 条件且没有调用 All 时返回 ErrDeleteWithoutWhere。
+func (db *DeleteBuilder) ToSQL() (string, error) {
+	if err := db.checkWhere(); err != nil {
+		return "", err
+	}
+
+	sb := newSQLBuilder(true)
+	db.dialect.ToDeleteSQL(sb, db.clauses)
+
+	return sb.ToSQL()
+}
+
+func (db *DeleteBuilder) checkWhere() error {
+	if where := db.clauses.Where(); !db.all && (where == nil || where.IsEmpty()) {
+		return ErrDeleteWithoutWhere
+	}
+
+	return nil
+}
+
+// Exec 执行删除并返回删除的行数，DryRun 时返回将被删除的行数，此时 conn 还需要实现 Querier。
+// InfluxQL 方言的删除发送到 /query，不返回删除的行数。
+func (db *DeleteBuilder) Exec(ctx context.Context, conn Executor) (int64, error) {
+	if db.dryRun {
+		q, ok := conn.(Querier)
+		if !ok {
+			return 0, errors.New("dry run requires a Querier")
+		}
+
+		return db.Count(ctx, q)
+	}
+
+	sql, err := db.ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	if db.dialectOptions != nil && db.dialectOptions.InfluxQL {
+		return execStatement(ctx, conn, db.dialectOptions, sql)
+	}
+
+	// *InfluxDB 以 DeleteOperation 调用钩子。
+	if d, ok := conn.(deleter); ok {
+		return d.DeleteContext(ctx, sql)
+	}
+
+	return conn.Exec(ctx, sql)
+}
+
+type deleter interface {
+	DeleteContext(ctx context.Context, query string) (int64, error)
+}
+
+// Count 返回满足删除条件的行数，InfluxQL 方言使用 Query，否则使用 QueryTaos。
+func (db *DeleteBuilder) Count(ctx context.Context, conn Querier) (int64, error) {
+	if err := db.checkWhere(); err != nil {
+		return 0, err
+	}
+
+	qb := From(db.table)
+	if db.dialectOptions != nil {
+		qb.Dialect(db.dialectOptions)
+	}
+
+	// InfluxQL 的 COUNT(*) 按字段分别计数，输出 count_<field> 列，不能使用别名。
+	if qb.dialectOptions.InfluxQL {
+		qb.Select(Count(Star()))
+	} else {
+		qb.Select(Count(Star()).As("cnt"))
+	}
+
+	if where := db.clauses.Where(); where != nil {
+		qb.Where(where)
+	}
+
+	var rows []map[string]any
+
+	err := qb.run(ctx, conn, &rows)
+	if errors.Is(err, ErrNoData) || errors.Is(err, ErrNoSeries) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+
+	for _, row := range rows {
+		n += rowCount(row)
+	}
+
+	return n, nil
+}
+
+// rowCount 返回 COUNT 查询一行中最大的计数，即各字段计数中最大的一个。
+func rowCount(row map[string]any) int64 {
+	var n float64
+
+	for col, v := range row {
+		if col == "time" {
+			continue
+		}
+
+		if f, ok := toFloat(v); ok && f > n {
+			n = f
+		}
+	}
+
+	return int64(n)
+}
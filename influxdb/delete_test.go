@@ -0,0 +1,152 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+func TestDeleteBuilderToSQL(t *testing.T) {
+	start, end := epoch, epoch.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		db      *influxdb.DeleteBuilder
+		sql     string
+		wantErr error
+	}{
+		{
+			name: "time range and tag",
+			db:   influxdb.DeleteFrom("cpu").TimeRange("ts", start, end).Where(influxdb.C("host").Eq("a")),
+			sql:  "DELETE FROM cpu WHERE ((ts >= '2024-01-01T00:00:00Z') AND (ts < '2024-01-01T01:00:00Z') AND (host = 'a'))",
+		},
+		{
+			name: "influxql",
+			db: influxdb.DeleteFrom("cpu").Dialect(influxdb.InfluxQLDialectOptions()).
+				TimeRange("time", start, end),
+			sql: "DELETE FROM cpu WHERE ((time >= '2024-01-01T00:00:00Z') AND (time < '2024-01-01T01:00:00Z'))",
+		},
+		{name: "without where", db: influxdb.DeleteFrom("cpu"), wantErr: influxdb.ErrDeleteWithoutWhere},
+		{name: "empty where", db: influxdb.DeleteFrom("cpu").Where(), wantErr: influxdb.ErrDeleteWithoutWhere},
+		{name: "all", db: influxdb.DeleteFrom("cpu").All(), sql: "DELETE FROM cpu"},
+		{name: "cloned all", db: influxdb.DeleteFrom("cpu").All().Clone(), sql: "DELETE FROM cpu"},
+	}
+
+	for _, test := range tests {
+		sql, err := test.db.ToSQL()
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s: error = %v; want %v", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if sql != test.sql {
+			t.Errorf("%s: ToSQL() = %s; want %s", test.name, sql, test.sql)
+		}
+	}
+}
+
+func TestDeleteBuilderExec(t *testing.T) {
+	influxQL := influxdb.InfluxQLDialectOptions()
+
+	tests := []struct {
+		name    string
+		db      func() *influxdb.DeleteBuilder
+		n       int64
+		left    int
+		query   string
+		wantErr error
+	}{
+		{
+			name: "delete by tag",
+			db:   func() *influxdb.DeleteBuilder { return influxdb.DeleteFrom("cpu").Where(influxdb.C("host").Eq("a")) },
+			n:    3, left: 3,
+		},
+		{
+			name: "dry run",
+			db: func() *influxdb.DeleteBuilder {
+				return influxdb.DeleteFrom("cpu").Where(influxdb.C("host").Eq("a")).DryRun()
+			},
+			n: 3, left: 6,
+			query: "SELECT COUNT(*) AS cnt FROM cpu WHERE (host = 'a')",
+		},
+		{
+			name: "influxql dry run",
+			db: func() *influxdb.DeleteBuilder {
+				return influxdb.DeleteFrom("cpu").Dialect(influxQL).Where(influxdb.C("host").Eq("b")).DryRun()
+			},
+			n: 3, left: 6,
+			query: "SELECT COUNT(*) FROM cpu WHERE (host = 'b')",
+		},
+		{
+			name: "dry run without matches",
+			db: func() *influxdb.DeleteBuilder {
+				return influxdb.DeleteFrom("cpu").Where(influxdb.C("host").Eq("z")).DryRun()
+			},
+			n: 0, left: 6,
+		},
+		{
+			name: "all",
+			db:   func() *influxdb.DeleteBuilder { return influxdb.DeleteFrom("cpu").All() },
+			n:    6, left: 0,
+		},
+		{
+			name:    "without where",
+			db:      func() *influxdb.DeleteBuilder { return influxdb.DeleteFrom("cpu") },
+			left:    6,
+			wantErr: influxdb.ErrDeleteWithoutWhere,
+		},
+		{
+			name:    "dry run without where",
+			db:      func() *influxdb.DeleteBuilder { return influxdb.DeleteFrom("cpu").DryRun() },
+			left:    6,
+			wantErr: influxdb.ErrDeleteWithoutWhere,
+		},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t)
+		insertCPU(srv, []string{"a", "b"}, 3)
+
+		n, err := test.db().Exec(context.Background(), db)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: error = %v; want %v", test.name, err, test.wantErr)
+
+			continue
+		}
+
+		if n != test.n {
+			t.Errorf("%s: Exec() = %d; want %d", test.name, n, test.n)
+		}
+
+		if left := len(srv.Points("cpu")); left != test.left {
+			t.Errorf("%s: %d points left; want %d", test.name, left, test.left)
+		}
+
+		if test.query != "" && srv.LastQuery() != test.query {
+			t.Errorf("%s: query = %s; want %s", test.name, srv.LastQuery(), test.query)
+		}
+	}
+}
+
+type execOnly struct{}
+
+func (execOnly) Exec(context.Context, string) (int64, error) { return 0, nil }
+
+func TestDeleteBuilderDryRunRequiresQuerier(t *testing.T) {
+	_, err := influxdb.DeleteFrom("cpu").Where(influxdb.C("host").Eq("a")).DryRun().Exec(context.Background(), execOnly{})
+	if err == nil {
+		t.Error("dry run with an Executor that can not query succeeded; want error")
+	}
+}
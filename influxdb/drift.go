@@ -0,0 +1,359 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type DriftKind int8
+
+const (
+	// MissingColumn 表示结构体中的字段在表中不存在，查询时该字段始终为零值。
+	MissingColumn DriftKind = iota
+	// ExtraColumn 表示表中的列在结构体中没有对应字段。
+	ExtraColumn
+	// TypeMismatch 表示字段类型无法保存列的值。
+	TypeMismatch
+)
+
+func (k DriftKind) String() string {
+	switch k {
+	case MissingColumn:
+		return "missing"
+	case ExtraColumn:
+		return "extra"
+	case TypeMismatch:
+		return "type mismatch"
+	}
+
+	return fmt.Sprintf("%d", k)
+}
+
+// Drift 为结构体与表结构的一处差异。
+type Drift struct {
+	Column string
+	// GoType 为结构体字段的类型，ExtraColumn 时为空。
+	GoType string
+	// DBType 为列的类型，MissingColumn 时为建议使用的类型。
+	DBType string
+	Kind   DriftKind
+	Tag    bool
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s %s (go: %s, db: %s)", d.Kind, d.Column, d.GoType, d.DBType)
+}
+
+// SchemaDiff 为 CheckSchema 的结果。
+type SchemaDiff struct {
+	do          *SQLDialectOptions
+	Measurement string
+	Drifts      []Drift
+}
+
+func (sd *SchemaDiff) HasDrift() bool {
+	return len(sd.Drifts) > 0
+}
+
+// AlterStatements 返回为表补齐缺失列和标签的 ALTER STABLE 语句，dropExtra 为 true 时同时删除多余的列和标签。
+// 类型不一致的列需要人工处理，不会生成语句。
+func (sd *SchemaDiff) AlterStatements(dropExtra bool) ([]string, error) {
+	var stmts []string
+
+	for _, d := range sd.Drifts {
+		b := AlterSTable(sd.Measurement).Dialect(sd.do)
+
+		switch {
+		case d.Kind == MissingColumn && d.Tag:
+			b.AddTag(NewColumnDef(d.Column, d.DBType))
+		case d.Kind == MissingColumn:
+			b.AddColumn(NewColumnDef(d.Column, d.DBType))
+		case d.Kind == ExtraColumn && dropExtra && d.Tag:
+			b.DropTag(d.Column)
+		case d.Kind == ExtraColumn && dropExtra:
+			b.DropColumn(d.Column)
+		default:
+			continue
+		}
+
+		sql, err := b.ToSQL()
+		if err != nil {
+			return nil, err
+		}
+
+		stmts = append(stmts, sql)
+	}
+
+	return stmts, nil
+}
+
+// structColumn 为结构体字段对应的列。
+type structColumn struct {
+	typ    reflect.Type
+	name   string
+	dbType string
+	tag    bool
+}
+
+// CheckSchema 比较 model（结构体或结构体指针）与 measurement 的表结构，报告缺失、多余和类型不一致的列。
+//
+// 列名取自字段的 influx 标签，没有时取 json 标签，都没有时使用字段名，"-" 表示忽略。influx 标签支持
+// tag 选项标记为标签列，type=XXX 选项指定补齐时使用的类型，如 `influx:"location,tag,type=VARCHAR(64)"`。
+func (i *InfluxDB) CheckSchema(ctx context.Context, measurement string, model interface{}) (*SchemaDiff, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct, got %T", model)
+	}
+
+	// 表结构可能刚被修改，跳过查询缓存。
+	cols, err := i.columns(WithoutCache(ctx), measurement)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("measurement %s: %w", measurement, ErrNoData)
+	}
+
+	diff := &SchemaDiff{Measurement: measurement, do: i.dialectOptions()}
+	dbCols := make(map[string]describedColumn, len(cols))
+
+	for _, col := range cols {
+		dbCols[strings.ToLower(col.Name)] = col
+	}
+
+	seen := map[string]struct{}{}
+
+	for _, sc := range structColumns(t) {
+		key := strings.ToLower(sc.name)
+		seen[key] = struct{}{}
+
+		col, ok := dbCols[key]
+		if !ok {
+			diff.Drifts = append(diff.Drifts, Drift{
+				Kind: MissingColumn, Column: sc.name, GoType: sc.typ.String(), DBType: sc.dbType, Tag: sc.tag,
+			})
+
+			continue
+		}
+
+		if !compatibleType(sc.typ, col.Type) {
+			diff.Drifts = append(diff.Drifts, Drift{
+				Kind: TypeMismatch, Column: col.Name, GoType: sc.typ.String(), DBType: col.Type, Tag: col.tag,
+			})
+		}
+	}
+
+	for _, col := range cols {
+		if _, ok := seen[strings.ToLower(col.Name)]; !ok {
+			diff.Drifts = append(diff.Drifts, Drift{Kind: ExtraColumn, Column: col.Name, DBType: col.Type, Tag: col.tag})
+		}
+	}
+
+	return diff, nil
+}
+
+// columns 返回表的所有列，InfluxDB 的标签类型为 tag。
+func (i *InfluxDB) columns(ctx context.Context, measurement string) ([]describedColumn, error) {
+	if i.backend != InfluxQLBackend {
+		return i.describe(ctx, measurement)
+	}
+
+	tags, err := i.TagKeys(ctx, measurement)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := i.FieldKeys(ctx, measurement)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]describedColumn, 0, len(tags)+len(fields)+1)
+	cols = append(cols, describedColumn{FieldKey: FieldKey{Name: "time", Type: "timestamp"}})
+
+	for _, tag := range tags {
+		cols = append(cols, describedColumn{FieldKey: FieldKey{Name: tag, Type: "tag"}, tag: true})
+	}
+
+	for _, f := range fields {
+		cols = append(cols, describedColumn{FieldKey: f})
+	}
+
+	return cols, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func structColumns(t reflect.Type) []structColumn {
+	var cols []structColumn
+
+	for j := 0; j < t.NumField(); j++ {
+		f := t.Field(j)
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		name, opts, hasTag := columnTag(f)
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && !hasTag && ft.Kind() == reflect.Struct && ft != timeType {
+			cols = append(cols, structColumns(ft)...)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		sc := structColumn{name: name, typ: ft, dbType: defaultDBType(ft)}
+
+		for _, opt := range opts {
+			switch {
+			case opt == "tag":
+				sc.tag = true
+			case strings.HasPrefix(opt, "type="):
+				sc.dbType = strings.TrimPrefix(opt, "type=")
+			}
+		}
+
+		cols = append(cols, sc)
+	}
+
+	return cols
+}
+
+// columnTag 返回字段的列名和 influx 标签的选项。
+func columnTag(f reflect.StructField) (string, []string, bool) {
+	if tag, ok := f.Tag.Lookup("influx"); ok {
+		parts := strings.Split(tag, ",")
+		if parts[0] == "" {
+			parts[0] = f.Name
+		}
+
+		return parts[0], parts[1:], true
+	}
+
+	if tag, ok := f.Tag.Lookup("json"); ok {
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		return name, nil, true
+	}
+
+	return f.Name, nil, false
+}
+
+func defaultDBType(t reflect.Type) string {
+	if t == timeType {
+		return "TIMESTAMP"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "BOOL"
+	case reflect.Int8:
+		return "TINYINT"
+	case reflect.Int16:
+		return "SMALLINT"
+	case reflect.Int32:
+		return "INT"
+	case reflect.Int, reflect.Int64:
+		return "BIGINT"
+	case reflect.Uint8:
+		return "TINYINT UNSIGNED"
+	case reflect.Uint16:
+		return "SMALLINT UNSIGNED"
+	case reflect.Uint32:
+		return "INT UNSIGNED"
+	case reflect.Uint, reflect.Uint64:
+		return "BIGINT UNSIGNED"
+	case reflect.Float32:
+		return "FLOAT"
+	case reflect.Float64:
+		return "DOUBLE"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "VARBINARY(64)"
+		}
+	}
+
+	return "VARCHAR(64)"
+}
+
+type typeClass int8
+
+const (
+	anyClass typeClass = iota
+	boolClass
+	intClass
+	floatClass
+	stringClass
+	timeClass
+)
+
+// dbTypeClass 将 TDengine 和 InfluxDB 的列类型归类。
+func dbTypeClass(typ string) typeClass {
+	typ = strings.ToUpper(typ)
+	if i := strings.IndexByte(typ, '('); i >= 0 {
+		typ = typ[:i]
+	}
+
+	typ = strings.TrimSpace(strings.TrimSuffix(typ, " UNSIGNED"))
+
+	switch typ {
+	case "BOOL", "BOOLEAN":
+		return boolClass
+	case "TINYINT", "SMALLINT", "INT", "BIGINT", "INTEGER", "UNSIGNED":
+		return intClass
+	case "FLOAT", "DOUBLE":
+		return floatClass
+	case "BINARY", "VARCHAR", "NCHAR", "VARBINARY", "STRING", "TAG", "JSON", "GEOMETRY":
+		return stringClass
+	case "TIMESTAMP":
+		return timeClass
+	}
+
+	return anyClass
+}
+
+// compatibleType 判断 Go 类型能否保存列的值，整数列可以解析到浮点字段，时间戳可以解析到字符串字段。
+func compatibleType(t reflect.Type, dbType string) bool {
+	class := dbTypeClass(dbType)
+	if class == anyClass {
+		return true
+	}
+
+	if t == timeType {
+		return class == timeClass
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return class == boolClass
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return class == intClass
+	case reflect.Float32, reflect.Float64:
+		return class == intClass || class == floatClass
+	case reflect.String:
+		return class == stringClass || class == timeClass
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8 || class == stringClass
+	}
+
+	return true
+}
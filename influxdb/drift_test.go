@@ -0,0 +1,192 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+	"github.com/jiurenm/mare/influxdb/influxdbtest"
+)
+
+type meter struct {
+	Ts       time.Time `json:"ts"`
+	Current  float64   `json:"current"`
+	Location string    `influx:"location,tag"`
+}
+
+type meterV2 struct {
+	meter
+	Voltage int32  `influx:"voltage"`
+	Phase   string `influx:"phase,type=NCHAR(8)"`
+	GroupID int    `influx:"group_id,tag,type=INT"`
+	Ignored string `influx:"-"`
+}
+
+type meterWrongType struct {
+	Ts       time.Time `json:"ts"`
+	Current  bool      `json:"current"`
+	Location string    `influx:"location,tag"`
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		backend   influxdb.Backend
+		model     any
+		fields    map[string]any
+		want      []string
+		wantAlter []string
+		wantDrop  []string
+		wantErr   error
+	}{
+		{
+			name:   "in sync",
+			model:  meter{},
+			fields: map[string]any{"current": 1.5},
+		},
+		{
+			name:   "pointer model",
+			model:  &meter{},
+			fields: map[string]any{"current": 1.5},
+		},
+		{
+			name:   "missing columns and tags",
+			model:  meterV2{},
+			fields: map[string]any{"current": 1.5},
+			want: []string{
+				"missing voltage (go: int32, db: INT)",
+				"missing phase (go: string, db: NCHAR(8))",
+				"missing group_id (go: int, db: INT)",
+			},
+			wantAlter: []string{
+				"ALTER STABLE meters ADD COLUMN voltage INT",
+				"ALTER STABLE meters ADD COLUMN phase NCHAR(8)",
+				"ALTER STABLE meters ADD TAG group_id INT",
+			},
+		},
+		{
+			name:   "extra column",
+			model:  meter{},
+			fields: map[string]any{"current": 1.5, "power": int64(3)},
+			want:   []string{"extra power (go: , db: BIGINT)"},
+			wantDrop: []string{
+				"ALTER STABLE meters DROP COLUMN power",
+			},
+		},
+		{
+			name:   "type mismatch",
+			model:  meterWrongType{},
+			fields: map[string]any{"current": 1.5},
+			want:   []string{"type mismatch current (go: bool, db: DOUBLE)"},
+		},
+		{
+			name:   "integer column into float field",
+			model:  meter{},
+			fields: map[string]any{"current": int64(2)},
+		},
+		{
+			name:    "influxql extra field",
+			backend: influxdb.InfluxQLBackend,
+			model: struct {
+				Time     time.Time `json:"time"`
+				Current  float64   `json:"current"`
+				Location string    `influx:"location,tag"`
+			}{},
+			fields: map[string]any{"current": 1.5, "power": int64(3)},
+			want:   []string{"extra power (go: , db: integer)"},
+		},
+		{
+			name:    "not a struct",
+			model:   1,
+			fields:  map[string]any{"current": 1.5},
+			wantErr: errors.New("model must be a struct, got int"),
+		},
+		{
+			name:    "no such measurement",
+			model:   meter{},
+			wantErr: influxdb.ErrNoData,
+		},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.Backend = test.backend })
+
+		if test.fields != nil {
+			srv.Insert(influxdbtest.Point{
+				Measurement: "meters",
+				Time:        epoch,
+				Tags:        map[string]string{"location": "sh"},
+				Fields:      test.fields,
+			})
+		}
+
+		diff, err := db.CheckSchema(context.Background(), "meters", test.model)
+		if test.wantErr != nil {
+			if err == nil || !errors.Is(err, test.wantErr) && err.Error() != test.wantErr.Error() {
+				t.Errorf("%s: error = %v; want %v", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		got := make([]string, 0, len(diff.Drifts))
+		for _, d := range diff.Drifts {
+			got = append(got, d.String())
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(test.want) || diff.HasDrift() != (len(test.want) > 0) {
+			t.Errorf("%s: drifts = %q; want %q", test.name, got, test.want)
+		}
+
+		if test.backend == influxdb.InfluxQLBackend {
+			continue
+		}
+
+		alter, err := diff.AlterStatements(false)
+		if err != nil || fmt.Sprint(alter) != fmt.Sprint(test.wantAlter) {
+			t.Errorf("%s: AlterStatements(false) = %q, %v; want %q", test.name, alter, err, test.wantAlter)
+		}
+
+		alter, err = diff.AlterStatements(true)
+		if want := append(test.wantAlter, test.wantDrop...); err != nil || fmt.Sprint(alter) != fmt.Sprint(want) {
+			t.Errorf("%s: AlterStatements(true) = %q, %v; want %q", test.name, alter, err, want)
+		}
+	}
+}
+
+func TestCheckSchemaSkipsCache(t *testing.T) {
+	db, srv := newTestDB(t, func(cfg *influxdb.Config) {
+		cfg.CacheTTL = time.Minute
+		cfg.CacheSize = 10
+	})
+
+	srv.Insert(influxdbtest.Point{Measurement: "meters", Time: epoch, Fields: map[string]any{"current": 1.5}})
+
+	model := struct {
+		Ts      time.Time `json:"ts"`
+		Current float64   `json:"current"`
+		Voltage int64     `json:"voltage"`
+	}{}
+
+	diff, err := db.CheckSchema(context.Background(), "meters", model)
+	if err != nil || len(diff.Drifts) != 1 {
+		t.Fatalf("CheckSchema() = %v, %v; want voltage missing", diff, err)
+	}
+
+	// 补齐列后再次检查，结果不能来自缓存。
+	srv.Insert(influxdbtest.Point{Measurement: "meters", Time: epoch.Add(time.Second), Fields: map[string]any{"voltage": int64(220)}})
+
+	diff, err = db.CheckSchema(context.Background(), "meters", model)
+	if err != nil || diff.HasDrift() {
+		t.Errorf("CheckSchema() after ALTER = %v, %v; want no drift", diff, err)
+	}
+}
@@ -1,10 +1,13 @@
 package influxdb
 
 import (
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -32,6 +35,26 @@ func errUnsupportedComputerExpressionOperator(op Operator) error {
 	return fmt.Errorf("operator '%+v' not supported", op)
 }
 
+func errUnsupportedValueType(v interface{}) error {
+	return fmt.Errorf("encode error: unable to encode value %+v of type %T", v, v)
+}
+
+func errUnsupportedFunction(name string) error {
+	return fmt.Errorf("function %s not supported by dialect", name)
+}
+
+func errFunctionArgs(name string, n int, arity FunctionArity) error {
+	if arity.Max < 0 {
+		return fmt.Errorf("function %s expects at least %d arguments, received %d", name, arity.Min, n)
+	}
+
+	if arity.Min == arity.Max {
+		return fmt.Errorf("function %s expects %d arguments, received %d", name, arity.Min, n)
+	}
+
+	return fmt.Errorf("function %s expects %d to %d arguments, received %d", name, arity.Min, arity.Max, n)
+}
+
 func errUnsupportedRangeExpressionOperator(op RangeOperation) error {
 	return fmt.Errorf("range operator %+v not supported", op)
 }
@@ -145,6 +168,20 @@ func (esg *expressionSQLGenerator) Generate(sb SQLBuilder, val any) {
 	switch v := val.(type) {
 	case Expression:
 		esg.expressionSQL(sb, v)
+	case time.Time:
+		esg.literalTime(sb, v)
+	case *time.Time:
+		if v == nil {
+			esg.literalNil(sb)
+
+			return
+		}
+
+		esg.literalTime(sb, *v)
+	case time.Duration:
+		esg.literalDuration(sb, v)
+	case bool:
+		esg.literalBool(sb, v)
 	case int:
 		esg.literalInt(sb, int64(v))
 	case int32:
@@ -157,11 +194,27 @@ func (esg *expressionSQLGenerator) Generate(sb SQLBuilder, val any) {
 		esg.literalFloat(sb, v)
 	case string:
 		esg.literalString(sb, v)
+	case []byte:
+		esg.literalBytes(sb, v)
+	case driver.Valuer:
+		esg.valuerSQL(sb, v)
+	case fmt.Stringer:
+		esg.stringerSQL(sb, v)
 	default:
 		esg.reflectSQL(sb, v)
 	}
 }
 
+// stringerSQL renders numeric and bool kinds such as enums by their value and other kinds by String().
+func (esg *expressionSQLGenerator) stringerSQL(sb SQLBuilder, v fmt.Stringer) {
+	switch k := reflect.Indirect(reflect.ValueOf(v)).Kind(); {
+	case IsBool(k), IsInt(k), IsUint(k), IsFloat(k):
+		esg.reflectSQL(sb, v)
+	default:
+		esg.literalString(sb, v.String())
+	}
+}
+
 func (esg *expressionSQLGenerator) expressionSQL(sb SQLBuilder, expression Expression) {
 	switch e := expression.(type) {
 	case ColumnListExpression:
@@ -304,10 +357,37 @@ func (esg *expressionSQLGenerator) identifierExpressionSQL(sb SQLBuilder, ident
 }
 
 func (esg *expressionSQLGenerator) sqlFunctionExpressionSQL(sb SQLBuilder, sqlFunc SQLFunctionExpression) {
+	if err := esg.checkFunctionArgs(sqlFunc); err != nil {
+		sb.SetError(err)
+
+		return
+	}
+
 	sb.WriteStrings(sqlFunc.Name())
 	esg.Generate(sb, sqlFunc.Args())
 }
 
+func (esg *expressionSQLGenerator) checkFunctionArgs(sqlFunc SQLFunctionExpression) error {
+	arities := esg.dialectOptions.FunctionArities
+	if sfe, ok := sqlFunc.(SqlFunctionExpression); arities == nil || !ok || !sfe.catalog {
+		return nil
+	}
+
+	name := strings.ToUpper(sqlFunc.Name())
+
+	arity, ok := arities[name]
+	if !ok {
+		return errUnsupportedFunction(name)
+	}
+
+	n := len(sqlFunc.Args())
+	if n < arity.Min || (arity.Max >= 0 && n > arity.Max) {
+		return errFunctionArgs(name, n, arity)
+	}
+
+	return nil
+}
+
 func (esg *expressionSQLGenerator) aliasedExpressionSQL(sb SQLBuilder, aliased AliasedExpression) {
 	esg.Generate(sb, aliased.Aliased())
 	sb.Write(esg.dialectOptions.AsFragment)
@@ -424,6 +504,72 @@ func (esg *expressionSQLGenerator) literalFloat(sb SQLBuilder, f float64) {
 	sb.WriteStrings(strconv.FormatFloat(f, 'f', -1, 64))
 }
 
+func (esg *expressionSQLGenerator) literalBool(sb SQLBuilder, b bool) {
+	if b {
+		sb.Write(esg.dialectOptions.True)
+	} else {
+		sb.Write(esg.dialectOptions.False)
+	}
+}
+
+func (esg *expressionSQLGenerator) literalTime(sb SQLBuilder, t time.Time) {
+	if esg.dialectOptions.TimeLocation != nil {
+		t = t.In(esg.dialectOptions.TimeLocation)
+	}
+
+	if esg.dialectOptions.TimePrecision > 0 {
+		t = t.Truncate(esg.dialectOptions.TimePrecision)
+	}
+
+	esg.literalString(sb, t.Format(esg.dialectOptions.TimeFormat))
+}
+
+// literalDuration renders d with the largest unit that divides it exactly, e.g. 5m or 1h.
+func (esg *expressionSQLGenerator) literalDuration(sb SQLBuilder, d time.Duration) {
+	if d < 0 {
+		sb.WriteRunes('-')
+		d = -d
+	}
+
+	units := esg.dialectOptions.DurationUnits
+	if len(units) == 0 {
+		sb.SetError(errUnsupportedValueType(d))
+
+		return
+	}
+
+	unit := units[len(units)-1]
+
+	if d != 0 {
+		for _, u := range units {
+			if u.Duration > 0 && d%u.Duration == 0 {
+				unit = u
+
+				break
+			}
+		}
+	}
+
+	sb.WriteStrings(strconv.FormatInt(int64(d/unit.Duration), 10), unit.Suffix)
+}
+
+func (esg *expressionSQLGenerator) valuerSQL(sb SQLBuilder, valuer driver.Valuer) {
+	val, err := valuer.Value()
+	if err != nil {
+		sb.SetError(err)
+
+		return
+	}
+
+	if _, ok := val.(driver.Valuer); ok {
+		sb.SetError(errUnsupportedValueType(valuer))
+
+		return
+	}
+
+	esg.Generate(sb, val)
+}
+
 func (esg *expressionSQLGenerator) literalString(sb SQLBuilder, s string) {
 	sb.WriteRunes(esg.dialectOptions.StringQuote)
 
@@ -477,6 +623,8 @@ func (esg *expressionSQLGenerator) reflectSQL(sb SQLBuilder, val interface{}) {
 	switch {
 	case IsInvalid(valKind):
 		esg.literalNil(sb)
+	case IsBool(valKind):
+		esg.literalBool(sb, v.Bool())
 	case IsSlice(valKind):
 		switch t := val.(type) {
 		case []byte:
@@ -492,8 +640,11 @@ func (esg *expressionSQLGenerator) reflectSQL(sb SQLBuilder, val interface{}) {
 		esg.Generate(sb, v.Float())
 	case IsString(valKind):
 		esg.Generate(sb, v.String())
+	case v.CanInterface() && v.Type() != reflect.TypeOf(val):
+		// dereferenced values such as *time.Time get another pass through Generate
+		esg.Generate(sb, v.Interface())
 	default:
-		sb.SetError(fmt.Errorf("encode error: Unable to encode value %+v", v))
+		sb.SetError(errUnsupportedValueType(val))
 	}
 }
 
@@ -501,6 +652,7 @@ type SQLFunctionExpression interface {
 	Expression
 	Aliaseable
 	Computable
+	Comparable
 
 	Name() string
 	Args() []interface{}
@@ -0,0 +1,117 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+type status int
+
+func (s status) String() string {
+	if s == 1 {
+		return "active"
+	}
+
+	return "inactive"
+}
+
+type region string
+
+func (r region) String() string {
+	return "region-" + string(r)
+}
+
+type point struct{ x, y int }
+
+func (p point) String() string {
+	return "point"
+}
+
+func generate(t *testing.T, val any) string {
+	t.Helper()
+
+	sb := newSQLBuilder(false)
+	newExpressionSQLGenerator(DefaultDialectOptions()).Generate(sb, val)
+
+	sql, err := sb.ToSQL()
+	if err != nil {
+		t.Fatalf("Generate(%#v) error: %v", val, err)
+	}
+
+	return sql
+}
+
+func TestGenerateLiterals(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	var nilTime *time.Time
+
+	tests := []struct {
+		val    any
+		result string
+	}{
+		{ts, "'2024-01-02T03:04:05.0000006Z'"},
+		{&ts, "'2024-01-02T03:04:05.0000006Z'"},
+		{nilTime, "NULL"},
+		{5 * time.Minute, "5m"},
+		{90 * time.Minute, "90m"},
+		{48 * time.Hour, "2d"},
+		{1500 * time.Millisecond, "1500a"},
+		{-time.Hour, "-1h"},
+		{time.Duration(0), "0b"},
+		{true, "true"},
+		{false, "false"},
+		{status(1), "1"},
+		{region("eu"), "'region-eu'"},
+		{point{1, 2}, "'point'"},
+		{"it's", "'it''s'"},
+	}
+
+	for _, test := range tests {
+		got := generate(t, test.val)
+		if got != test.result {
+			t.Errorf("Generate(%#v) = %s; want %s", test.val, got, test.result)
+		}
+	}
+}
+
+func TestBooleanExpressionLiterals(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		exp    Expression
+		result string
+	}{
+		{C("ok").Eq(true), "(ok IS true)"},
+		{C("ok").Neq(false), "(ok IS NOT false)"},
+		{C("v").Eq(status(1)), "(v = 1)"},
+		{C("r").Eq(region("eu")), "(r = 'region-eu')"},
+		{C("ts").Gte(ts), "(ts >= '2024-01-02T00:00:00Z')"},
+		{C("v").Eq(nil), "(v IS NULL)"},
+	}
+
+	for _, test := range tests {
+		got := generate(t, test.exp)
+		if got != test.result {
+			t.Errorf("Generate(%#v) = %s; want %s", test.exp, got, test.result)
+		}
+	}
+}
+
+func TestDialectTimeOptions(t *testing.T) {
+	opts := DefaultDialectOptions()
+	opts.TimeLocation = time.FixedZone("CST", 8*3600)
+	opts.TimePrecision = time.Second
+
+	sb := newSQLBuilder(false)
+	newExpressionSQLGenerator(opts).Generate(sb, time.Date(2024, 1, 2, 0, 0, 0, 123, time.UTC))
+
+	got, err := sb.ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "'2024-01-02T08:00:00+08:00'"; got != want {
+		t.Errorf("Generate = %s; want %s", got, want)
+	}
+}
@@ -1,29 +1,164 @@
 package influxdb
 
+import "time"
+
 func Count(col interface{}) SQLFunctionExpression {
-	return newIdentifierFunc("COUNT", col)
+	return newCatalogFunc("COUNT", col)
+}
+
+func Sum(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("SUM", col)
+}
+
+// Mean is the InfluxQL average, TDengine uses Avg.
+func Mean(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("MEAN", col)
+}
+
+func Avg(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("AVG", col)
+}
+
+func Min(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("MIN", col)
+}
+
+func Max(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("MAX", col)
+}
+
+func Spread(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("SPREAD", col)
+}
+
+func Stddev(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("STDDEV", col)
+}
+
+// Percentile 返回第 p 百分位的值，TDengine 支持一次传入多个 p。
+func Percentile(col interface{}, p ...float64) SQLFunctionExpression {
+	args := make([]interface{}, 0, len(p))
+	for _, v := range p {
+		args = append(args, v)
+	}
+
+	return newCatalogFunc("PERCENTILE", col, args...)
+}
+
+func Median(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("MEDIAN", col)
+}
+
+func Mode(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("MODE", col)
+}
+
+func Top(col interface{}, n int) SQLFunctionExpression {
+	return newCatalogFunc("TOP", col, n)
+}
+
+func Bottom(col interface{}, n int) SQLFunctionExpression {
+	return newCatalogFunc("BOTTOM", col, n)
+}
+
+func First(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("FIRST", col)
+}
+
+func Last(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("LAST", col)
+}
+
+func Abs(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("ABS", col)
+}
+
+func Twa(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("TWA", col)
 }
 
+// Elapsed 计算时间跨度，unit 为结果的时间单位，如 time.Second。
+func Elapsed(col interface{}, unit ...time.Duration) SQLFunctionExpression {
+	return newCatalogFunc("ELAPSED", col, durationArgs(unit)...)
+}
+
+func Integral(col interface{}, unit ...time.Duration) SQLFunctionExpression {
+	return newCatalogFunc("INTEGRAL", col, durationArgs(unit)...)
+}
+
+// Derivative 在 InfluxQL 中为 Derivative(col[, unit])，
+// 在 TDengine 中为 Derivative(col, interval, ignoreNegative)。
+func Derivative(col interface{}, args ...interface{}) SQLFunctionExpression {
+	return newCatalogFunc("DERIVATIVE", col, args...)
+}
+
+func NonNegativeDerivative(col interface{}, unit ...time.Duration) SQLFunctionExpression {
+	return newCatalogFunc("NON_NEGATIVE_DERIVATIVE", col, durationArgs(unit)...)
+}
+
+func Difference(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("DIFFERENCE", col)
+}
+
+// Diff 为 TDengine 的差值函数，ignoreNegative 可选。
+func Diff(col interface{}, ignoreNegative ...int) SQLFunctionExpression {
+	args := make([]interface{}, 0, len(ignoreNegative))
+	for _, v := range ignoreNegative {
+		args = append(args, v)
+	}
+
+	return newCatalogFunc("DIFF", col, args...)
+}
+
+func CumulativeSum(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("CUMULATIVE_SUM", col)
+}
+
+// Csum 为 TDengine 的累加和，对应 InfluxQL 的 CumulativeSum。
+func Csum(col interface{}) SQLFunctionExpression {
+	return newCatalogFunc("CSUM", col)
+}
+
+func MovingAverage(col interface{}, n int) SQLFunctionExpression {
+	return newCatalogFunc("MOVING_AVERAGE", col, n)
+}
+
+// Mavg 为 TDengine 的移动平均，对应 InfluxQL 的 MovingAverage。
+func Mavg(col interface{}, n int) SQLFunctionExpression {
+	return newCatalogFunc("MAVG", col, n)
+}
+
+// StateCount 返回满足条件的连续记录数，op 为 LT、GT、LE、GE、NE、EQ 之一。
+func StateCount(col interface{}, op string, val interface{}) SQLFunctionExpression {
+	return newCatalogFunc("STATECOUNT", col, op, val)
+}
+
+// Deprecated: use Sum.
 func SUM(col interface{}) SQLFunctionExpression {
 	return newIdentifierFunc("SUM", col)
 }
 
+// Deprecated: use First.
 func FIRST(col interface{}) SQLFunctionExpression {
 	return newIdentifierFunc("FIRST", col)
 }
 
+// Deprecated: use Last.
 func LAST(col interface{}) SQLFunctionExpression {
 	return newIdentifierFunc("LAST", col)
 }
 
+// Deprecated: use Abs.
 func ABS(col interface{}) SQLFunctionExpression {
 	return newIdentifierFunc("ABS", col)
 }
 
+// Deprecated: use Derivative.
 func DERIVATIVE(col interface{}) SQLFunctionExpression {
 	return newIdentifierFunc("DERIVATIVE", col)
 }
 
+// Deprecated: use Difference.
 func DIFFERENCE(col interface{}) SQLFunctionExpression {
 	return newIdentifierFunc("DIFFERENCE", col)
 }
@@ -44,6 +179,24 @@ func newIdentifierFunc(name string, col interface{}) SQLFunctionExpression {
 	return Func(name, col)
 }
 
+// newCatalogFunc 创建目录函数，生成 SQL 时按方言的 FunctionArities 校验。
+func newCatalogFunc(name string, col interface{}, args ...interface{}) SQLFunctionExpression {
+	if s, ok := col.(string); ok {
+		col = I(s)
+	}
+
+	return SqlFunctionExpression{name: name, args: append([]interface{}{col}, args...), catalog: true}
+}
+
+func durationArgs(ds []time.Duration) []interface{} {
+	args := make([]interface{}, 0, len(ds))
+	for _, d := range ds {
+		args = append(args, d)
+	}
+
+	return args
+}
+
 func I(ident string) IdentifierExpression {
 	return ParseIdentifier(ident)
 }
@@ -0,0 +1,93 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCatalogFunctions(t *testing.T) {
+	influxQL := InfluxQLDialectOptions()
+
+	tests := []struct {
+		exp     Expression
+		opts    *SQLDialectOptions
+		result  string
+		wantErr string
+	}{
+		{Sum("v"), nil, "SUM(v)", ""},
+		{Avg("v").As("avg"), nil, "AVG(v) AS avg", ""},
+		{Percentile("v", 50, 90), nil, "PERCENTILE(v, 50, 90)", ""},
+		{Top("v", 3), nil, "TOP(v, 3)", ""},
+		{Elapsed("ts", time.Second), nil, "ELAPSED(ts, 1s)", ""},
+		{Derivative("v", time.Minute, 0), nil, "DERIVATIVE(v, 1m, 0)", ""},
+		{Mavg("v", 5), nil, "MAVG(v, 5)", ""},
+		{StateCount("v", "GT", 10), nil, "STATECOUNT(v, 'GT', 10)", ""},
+		{Mean("v"), influxQL, "MEAN(v)", ""},
+		{Derivative("v", time.Minute), influxQL, "DERIVATIVE(v, 1m)", ""},
+		{MovingAverage("v", 3), influxQL, "MOVING_AVERAGE(v, 3)", ""},
+		{Percentile("v", 50, 90), influxQL, "", "function PERCENTILE expects 2 arguments, received 3"},
+		{Mean("v"), nil, "", "function MEAN not supported by dialect"},
+		{Derivative("v"), nil, "", "function DERIVATIVE expects 3 arguments, received 1"},
+		{Csum("v"), influxQL, "", "function CSUM not supported by dialect"},
+		// Func 和保留的大写函数不校验，与之前的行为一致。
+		{DIFFERENCE("v"), nil, "DIFFERENCE(v)", ""},
+		{DERIVATIVE("v"), nil, "DERIVATIVE(v)", ""},
+		{SUM("v"), influxQL, "SUM(v)", ""},
+		{Func("MEAN", I("v")), nil, "MEAN(v)", ""},
+		{Func("my_udf", I("v"), 1, 2, 3), nil, "my_udf(v, 1, 2, 3)", ""},
+	}
+
+	for _, test := range tests {
+		opts := test.opts
+		if opts == nil {
+			opts = DefaultDialectOptions()
+		}
+
+		sb := newSQLBuilder(false)
+		newExpressionSQLGenerator(opts).Generate(sb, test.exp)
+		got, err := sb.ToSQL()
+
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Generate(%#v) error = %v; want %q", test.exp, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Generate(%#v) error: %v", test.exp, err)
+
+			continue
+		}
+
+		if got != test.result {
+			t.Errorf("Generate(%#v) = %s; want %s", test.exp, got, test.result)
+		}
+	}
+}
+
+func TestCatalogFunctionsDisabled(t *testing.T) {
+	opts := DefaultDialectOptions()
+	opts.FunctionArities = nil
+
+	sb := newSQLBuilder(false)
+	newExpressionSQLGenerator(opts).Generate(sb, Mean("v"))
+
+	got, err := sb.ToSQL()
+	if err != nil || got != "MEAN(v)" {
+		t.Errorf("Generate(Mean) = %s, %v; want MEAN(v)", got, err)
+	}
+}
+
+func TestCatalogFunctionsClone(t *testing.T) {
+	exp := Derivative("v").Clone()
+
+	sb := newSQLBuilder(false)
+	newExpressionSQLGenerator(DefaultDialectOptions()).Generate(sb, exp)
+
+	if _, err := sb.ToSQL(); err == nil {
+		t.Error("cloned catalog function should still be checked")
+	}
+}
@@ -0,0 +1,106 @@
+package influxdb
+
+import "fmt"
+
+type FillMode int
+
+const (
+	// NONE.
+	NoneFill FillMode = iota
+	// NULL.
+	NullFill
+	// PREV, previous in InfluxQL.
+	PrevFill
+	// NEXT.
+	NextFill
+	// LINEAR.
+	LinearFill
+	// VALUE, v1[, v2...].
+	ValueFill
+	// NEAR.
+	NearestFill
+)
+
+func (fm FillMode) String() string {
+	switch fm {
+	case NoneFill:
+		return "none"
+	case NullFill:
+		return "null"
+	case PrevFill:
+		return "prev"
+	case NextFill:
+		return "next"
+	case LinearFill:
+		return "linear"
+	case ValueFill:
+		return "value"
+	case NearestFill:
+		return "nearest"
+	}
+
+	return fmt.Sprintf("%d", fm)
+}
+
+func errUnsupportedFillMode(fm FillMode) error {
+	return fmt.Errorf("fill mode '%v' not supported by dialect", fm)
+}
+
+func errFillValues(n, limit int) error {
+	if limit <= 0 {
+		return fmt.Errorf("fill expects at least 1 value, received %d", n)
+	}
+
+	return fmt.Errorf("fill expects 1 to %d values, received %d", limit, n)
+}
+
+type FillExpression interface {
+	Mode() FillMode
+	Values() []interface{}
+}
+
+type fill struct {
+	values []interface{}
+	mode   FillMode
+}
+
+func NewFillExpression(mode FillMode, values ...interface{}) FillExpression {
+	return fill{mode: mode, values: values}
+}
+
+func (f fill) Mode() FillMode {
+	return f.mode
+}
+
+func (f fill) Values() []interface{} {
+	return f.values
+}
+
+func FillNone() FillExpression {
+	return NewFillExpression(NoneFill)
+}
+
+func FillNull() FillExpression {
+	return NewFillExpression(NullFill)
+}
+
+func FillPrev() FillExpression {
+	return NewFillExpression(PrevFill)
+}
+
+func FillNext() FillExpression {
+	return NewFillExpression(NextFill)
+}
+
+func FillLinear() FillExpression {
+	return NewFillExpression(LinearFill)
+}
+
+// FillValue 使用固定值填充，TDengine 可以为每个聚合列分别指定一个值。
+func FillValue(vals ...interface{}) FillExpression {
+	return NewFillExpression(ValueFill, vals...)
+}
+
+func FillNearest() FillExpression {
+	return NewFillExpression(NearestFill)
+}
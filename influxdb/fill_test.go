@@ -0,0 +1,46 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFillSQL(t *testing.T) {
+	influxQL := InfluxQLDialectOptions()
+	window := NewIntervalWindow(time.Minute)
+
+	tests := []struct {
+		fill    FillExpression
+		opts    *SQLDialectOptions
+		sql     string
+		wantErr string
+	}{
+		{FillNull(), nil, "SELECT AVG(v) FROM cpu INTERVAL(1m) FILL(NULL)", ""},
+		{FillPrev(), nil, "SELECT AVG(v) FROM cpu INTERVAL(1m) FILL(PREV)", ""},
+		{FillLinear(), nil, "SELECT AVG(v) FROM cpu INTERVAL(1m) FILL(LINEAR)", ""},
+		{FillNearest(), nil, "SELECT AVG(v) FROM cpu INTERVAL(1m) FILL(NEAR)", ""},
+		{FillValue(0, 1.5), nil, "SELECT AVG(v) FROM cpu INTERVAL(1m) FILL(VALUE, 0, 1.5)", ""},
+		{FillValue(), nil, "", "fill expects at least 1 value, received 0"},
+		{FillNull(), influxQL, "SELECT MEAN(v) FROM cpu GROUP BY time(1m) FILL(null)", ""},
+		{FillPrev(), influxQL, "SELECT MEAN(v) FROM cpu GROUP BY time(1m) FILL(previous)", ""},
+		{FillNone(), influxQL, "SELECT MEAN(v) FROM cpu GROUP BY time(1m) FILL(none)", ""},
+		{FillValue(0), influxQL, "SELECT MEAN(v) FROM cpu GROUP BY time(1m) FILL(0)", ""},
+		{FillValue(0, 1), influxQL, "", "fill expects 1 to 1 values, received 2"},
+		{FillNext(), influxQL, "", "fill mode 'next' not supported by dialect"},
+		{FillNearest(), influxQL, "", "fill mode 'nearest' not supported by dialect"},
+	}
+
+	for _, test := range tests {
+		qb := From("cpu").Select(Avg("v"))
+		if test.opts != nil {
+			qb = From("cpu").Dialect(test.opts).Select(Mean("v"))
+		}
+
+		runSQLTests(t, []sqlTest{{
+			name:    test.fill.Mode().String(),
+			qb:      qb.Window(window).Fill(test.fill),
+			sql:     test.sql,
+			wantErr: test.wantErr,
+		}})
+	}
+}
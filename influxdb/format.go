@@ -0,0 +1,196 @@
+package influxdb
+
+import (
+	"strings"
+	"unicode"
+)
+
+// formatClauses 为 Format 换行的子句关键字，较长的关键字在前。
+var formatClauses = []string{
+	"DELETE FROM", "PARTITION BY", "GROUP BY", "ORDER BY", "UNION ALL", "EVENT_WINDOW",
+	"STATE_WINDOW", "SELECT", "FROM", "WHERE", "INTERVAL", "SLIDING", "SESSION", "FILL", "HAVING",
+	"LIMIT", "OFFSET", "SLIMIT", "SOFFSET", "TZ", "INTO",
+}
+
+// formatListWidth 为 SELECT 列表单行显示的最大长度，超过时每列一行。
+const formatListWidth = 60
+
+// Format 将 SQL 格式化为多行，便于日志和调试：每个子句一行，WHERE 和 HAVING 中
+// 最外层的 AND、OR 另起一行并缩进，过长的 SELECT 列表每列一行。字符串和括号内的内容保持不变。
+func Format(sql string) string {
+	var lines []string
+
+	for _, clause := range splitClauses(strings.TrimSpace(sql)) {
+		keyword := matchClause(clause)
+		body := strings.TrimSpace(clause[len(keyword):])
+
+		switch strings.ToUpper(keyword) {
+		case "WHERE", "HAVING":
+			lines = append(lines, keyword+" "+strings.Join(splitTopLevel(body, true), "\n  "))
+		case "SELECT":
+			cols := splitTopLevel(body, false)
+			if len(body) <= formatListWidth || len(cols) == 1 {
+				lines = append(lines, clause)
+				continue
+			}
+
+			lines = append(lines, keyword+"\n  "+strings.Join(cols, ",\n  "))
+		default:
+			lines = append(lines, clause)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// splitClauses 在括号和字符串以外的子句关键字前切分 sql。
+func splitClauses(sql string) []string {
+	var (
+		clauses []string
+		start   int
+	)
+
+	scanSQL(sql, func(i, depth int) {
+		if depth != 0 || i == 0 || !isWordStart(sql, i) {
+			return
+		}
+
+		if keyword := matchClause(sql[i:]); keyword != "" {
+			// DELETE FROM 作为一个子句。
+			if strings.EqualFold(keyword, "FROM") && strings.HasSuffix(strings.ToUpper(strings.TrimSpace(sql[start:i])), "DELETE") {
+				return
+			}
+
+			clauses = append(clauses, strings.TrimSpace(sql[start:i]))
+			start = i
+		}
+	})
+
+	return append(clauses, strings.TrimSpace(sql[start:]))
+}
+
+// splitTopLevel 在括号和字符串以外切分 body：logical 为 true 时在 AND、OR 前切分，
+// 否则按逗号切分。整体被一对括号包围时在括号内切分。
+func splitTopLevel(body string, logical bool) []string {
+	level := 0
+	if logical && wrapped(body) {
+		level = 1
+	}
+
+	var (
+		parts []string
+		start int
+	)
+
+	scanSQL(body, func(i, depth int) {
+		if depth != level {
+			return
+		}
+
+		switch {
+		case !logical && body[i] == ',':
+			parts = append(parts, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		case logical && isWordStart(body, i) && (hasKeyword(body[i:], "AND") || hasKeyword(body[i:], "OR")):
+			parts = append(parts, strings.TrimSpace(body[start:i]))
+			start = i
+		}
+	})
+
+	return append(parts, strings.TrimSpace(body[start:]))
+}
+
+// wrapped 判断 s 是否整体被一对括号包围。
+func wrapped(s string) bool {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return false
+	}
+
+	closed := -1
+
+	scanSQL(s, func(i, depth int) {
+		if closed < 0 && s[i] == ')' && depth == 0 {
+			closed = i
+		}
+	})
+
+	return closed == len(s)-1
+}
+
+// scanSQL 依次以字符串以外每个字符的下标和所在的括号深度调用 fn，右括号的深度为闭合后的深度。
+func scanSQL(sql string, fn func(i, depth int)) {
+	var (
+		depth int
+		quote byte
+	)
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		if quote != 0 {
+			switch {
+			case c == '\\':
+				i++
+			case c == quote:
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+
+			continue
+		case '(':
+			fn(i, depth)
+			depth++
+
+			continue
+		case ')':
+			depth--
+		}
+
+		fn(i, depth)
+	}
+}
+
+func isWordStart(s string, i int) bool {
+	return i == 0 || !isWordChar(rune(s[i-1]))
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hasKeyword 判断 s 是否以完整的单词 keyword 开头，忽略大小写。
+func hasKeyword(s, keyword string) bool {
+	if len(s) < len(keyword) || !strings.EqualFold(s[:len(keyword)], keyword) {
+		return false
+	}
+
+	return len(s) == len(keyword) || !isWordChar(rune(s[len(keyword)]))
+}
+
+// parenClauses 为后面紧跟括号的子句，避免将同名的列当作子句。
+var parenClauses = map[string]struct{}{
+	"INTERVAL": {}, "SLIDING": {}, "SESSION": {}, "STATE_WINDOW": {}, "FILL": {}, "TZ": {},
+}
+
+// matchClause 返回 s 开头的子句关键字，没有时返回空字符串。
+func matchClause(s string) string {
+	for _, kw := range formatClauses {
+		if !hasKeyword(s, kw) {
+			continue
+		}
+
+		if _, ok := parenClauses[kw]; ok && !strings.HasPrefix(strings.TrimLeft(s[len(kw):], " "), "(") {
+			continue
+		}
+
+		return s[:len(kw)]
+	}
+
+	return ""
+}
@@ -3,6 +3,8 @@ package influxdb
 type SqlFunctionExpression struct {
 	name string
 	args []interface{}
+	// catalog 为 true 时表示由 Sum、Percentile 等目录函数创建，生成 SQL 时校验参数个数。
+	catalog bool
 }
 
 func NewSQLFunctionExpression(name string, args ...interface{}) SQLFunctionExpression {
@@ -14,8 +16,9 @@ func NewSQLFunctionExpression(name string, args ...interface{}) SQLFunctionExpre
 
 func (sfe SqlFunctionExpression) Clone() Expression {
 	return SqlFunctionExpression{
-		name: sfe.name,
-		args: sfe.args,
+		name:    sfe.name,
+		args:    sfe.args,
+		catalog: sfe.catalog,
 	}
 }
 
@@ -46,3 +49,27 @@ func (sfe SqlFunctionExpression) Sub(val interface{}) ComputerExpression {
 func (sfe SqlFunctionExpression) Mul(val interface{}) ComputerExpression {
 	return NewComputerExpression(Multi, sfe, val)
 }
+
+func (sfe SqlFunctionExpression) Eq(val interface{}) BooleanExpression {
+	return eq(sfe, val)
+}
+
+func (sfe SqlFunctionExpression) Neq(val interface{}) BooleanExpression {
+	return neq(sfe, val)
+}
+
+func (sfe SqlFunctionExpression) Gt(val interface{}) BooleanExpression {
+	return gt(sfe, val)
+}
+
+func (sfe SqlFunctionExpression) Gte(val interface{}) BooleanExpression {
+	return gte(sfe, val)
+}
+
+func (sfe SqlFunctionExpression) Lt(val interface{}) BooleanExpression {
+	return lt(sfe, val)
+}
+
+func (sfe SqlFunctionExpression) Lte(val interface{}) BooleanExpression {
+	return lte(sfe, val)
+}
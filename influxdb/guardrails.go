@@ -0,0 +1,308 @@
+package influxdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TimeRangeExceededRule 为查询的时间范围超过 Guardrails.MaxTimeRange。
+	TimeRangeExceededRule = "time-range-exceeded"
+	// UnknownTimeBoundRule 为设置了 Guardrails.MaxTimeRange 时无法计算查询的时间边界，如与其他列比较。
+	UnknownTimeBoundRule = "unknown-time-bound"
+)
+
+// Guardrails 限制 QueryBuilder 的 Query、QueryTaos、Pages、Split 和 UnionAll 执行的查询，避免在超级表上扫描全部数据。
+// 字符串和手写的 SQL 不受限制。
+type Guardrails struct {
+	// TimeColumns 为识别时间条件的列名，为空时使用 ts、_ts 和 time。
+	TimeColumns []string
+	// RequireTimeBound 为 true 时拒绝 WHERE 中没有时间列条件的查询。
+	RequireTimeBound bool
+	// MaxTimeRange 大于 0 时拒绝时间范围超过它的查询，没有上界时以当前时间为上界，没有下界时拒绝。
+	// 边界可以是 time.Time、RFC3339 或 YYYY-MM-DD HH:MM:SS[.fff] 字符串、精度为 EpochPrecision 的整数时间戳、
+	// NOW 以及 NOW±duration，无法计算的边界同样被拒绝。
+	MaxTimeRange time.Duration
+	// MaxRawLimit 大于 0 时为不聚合、没有窗口的原始查询添加 LIMIT，LIMIT 更大时减小为 MaxRawLimit。
+	// Pages 的每页行数同样不超过 MaxRawLimit。
+	MaxRawLimit int
+}
+
+// GuardrailError 为违反 Guardrails 的查询返回的错误，Rule 为 MissingTimeBoundRule、TimeRangeExceededRule
+// 或 UnknownTimeBoundRule。
+type GuardrailError struct {
+	Rule    string
+	Message string
+}
+
+func (e *GuardrailError) Error() string {
+	return fmt.Sprintf("guardrail %s: %s", e.Rule, e.Message)
+}
+
+// check 检查 qb，需要时为原始查询添加 LIMIT。
+func (g *Guardrails) check(qb *QueryBuilder) error {
+	sc := qb.clauses
+	timeCols := g.TimeColumns
+	if len(timeCols) == 0 {
+		timeCols = defaultTimeColumns
+	}
+
+	b := whereTimeBounds(sc.Where(), timeCols, qb.dialectOptions)
+
+	if g.RequireTimeBound && b.start == nil && b.end == nil {
+		return &GuardrailError{Rule: MissingTimeBoundRule, Message: "query has no time predicate in WHERE"}
+	}
+
+	if g.MaxTimeRange > 0 {
+		if err := g.checkTimeRange(b, qb.dialectOptions); err != nil {
+			return err
+		}
+	}
+
+	if g.MaxRawLimit > 0 && sc.Window() == nil && !hasAggregate(sc) {
+		if limit, ok := sc.Limit().(int); !ok || limit > g.MaxRawLimit {
+			sc.SetLimit(g.MaxRawLimit)
+		}
+	}
+
+	return nil
+}
+
+func (g *Guardrails) checkTimeRange(b timeBounds, do *SQLDialectOptions) error {
+	if b.start == nil {
+		return &GuardrailError{
+			Rule:    TimeRangeExceededRule,
+			Message: fmt.Sprintf("query has no lower time bound, the maximum range is %v", g.MaxTimeRange),
+		}
+	}
+
+	start, ok := boundTime(b.start, do)
+	if !ok {
+		return &GuardrailError{
+			Rule:    UnknownTimeBoundRule,
+			Message: fmt.Sprintf("can not determine the time range from lower bound %v", b.start),
+		}
+	}
+
+	end := time.Now()
+	if b.end != nil {
+		if end, ok = boundTime(b.end, do); !ok {
+			return &GuardrailError{
+				Rule:    UnknownTimeBoundRule,
+				Message: fmt.Sprintf("can not determine the time range from upper bound %v", b.end),
+			}
+		}
+	}
+
+	if r := end.Sub(start); r > g.MaxTimeRange {
+		return &GuardrailError{
+			Rule:    TimeRangeExceededRule,
+			Message: fmt.Sprintf("time range %v exceeds the maximum %v", r, g.MaxTimeRange),
+		}
+	}
+
+	return nil
+}
+
+// boundTimeLayouts 为 RFC3339 以外可以解析的时间字符串格式，没有时区时使用 TimeLocation 或本地时区。
+var boundTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// boundTime 计算时间条件的值，do 为 nil 时使用 DefaultDialectOptions()，OR 分支无法比较的边界返回 false。
+func boundTime(v interface{}, do *SQLDialectOptions) (time.Time, bool) {
+	if do == nil {
+		do = DefaultDialectOptions()
+	}
+
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		return parseBoundTime(t, do)
+	case int:
+		return epochTime(int64(t), do), true
+	case int32:
+		return epochTime(int64(t), do), true
+	case int64:
+		return epochTime(t, do), true
+	case LiteralExpression:
+		return nowOffset(t.Literal(), do)
+	case SQLFunctionExpression:
+		if strings.EqualFold(t.Name(), "NOW") && len(t.Args()) == 0 {
+			return time.Now(), true
+		}
+	case ComputerExpression:
+		return computedBoundTime(t, do)
+	}
+
+	return time.Time{}, false
+}
+
+func parseBoundTime(s string, do *SQLDialectOptions) (time.Time, bool) {
+	if tm, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return tm, true
+	}
+
+	loc := do.TimeLocation
+	if loc == nil {
+		loc = time.Local
+	}
+
+	for _, layout := range boundTimeLayouts {
+		if tm, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return tm, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func epochTime(n int64, do *SQLDialectOptions) time.Time {
+	precision := do.EpochPrecision
+	if precision <= 0 {
+		precision = time.Millisecond
+	}
+
+	return time.Unix(0, 0).Add(time.Duration(n) * precision)
+}
+
+// nowOffset 计算 NOW、NOW()、NOW-1h、now() + 30m 等相对当前时间的边界。
+func nowOffset(s string, do *SQLDialectOptions) (time.Time, bool) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), ""))
+
+	rest, ok := strings.CutPrefix(s, "now")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	rest = strings.TrimPrefix(rest, "()")
+	if rest == "" {
+		return time.Now(), true
+	}
+
+	d, ok := parseBoundDuration(rest[1:], do)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	switch rest[0] {
+	case '+':
+		return time.Now().Add(d), true
+	case '-':
+		return time.Now().Add(-d), true
+	}
+
+	return time.Time{}, false
+}
+
+// parseBoundDuration 解析使用 DurationUnits 后缀的时间长度，如 1h30m。
+func parseBoundDuration(s string, do *SQLDialectOptions) (time.Duration, bool) {
+	var total time.Duration
+
+	for s != "" {
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, false
+		}
+
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		s = s[i:]
+
+		j := strings.IndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' })
+		if j < 0 {
+			j = len(s)
+		}
+
+		unit, ok := durationUnit(s[:j], do)
+		if !ok {
+			return 0, false
+		}
+
+		total += time.Duration(n) * unit
+		s = s[j:]
+	}
+
+	return total, total > 0
+}
+
+func durationUnit(suffix string, do *SQLDialectOptions) (time.Duration, bool) {
+	for _, u := range do.DurationUnits {
+		if strings.EqualFold(u.Suffix, suffix) {
+			return u.Duration, true
+		}
+	}
+
+	return 0, false
+}
+
+// computedBoundTime 计算 Func("NOW").Sub(time.Hour) 等时间加减表达式。
+func computedBoundTime(c ComputerExpression, do *SQLDialectOptions) (time.Time, bool) {
+	tm, ok := boundTime(c.LHS(), do)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	var d time.Duration
+
+	switch rhs := c.RHS().(type) {
+	case time.Duration:
+		d = rhs
+	case string:
+		if d, ok = parseBoundDuration(strings.ToLower(rhs), do); !ok {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	switch c.Op() {
+	case Plus:
+		return tm.Add(d), true
+	case Minus:
+		return tm.Add(-d), true
+	}
+
+	return time.Time{}, false
+}
+
+// guardrailer 为设置了 Guardrails 的连接，装饰器返回被包装的连接的 Guardrails。
+type guardrailer interface {
+	guardrails() *Guardrails
+}
+
+func (i *InfluxDB) guardrails() *Guardrails {
+	return i.guards
+}
+
+// guard 使用 conn 的 Guardrails 检查 qb，检查失败时回收 qb。
+func guard(conn Querier, qb *QueryBuilder) error {
+	g, ok := conn.(guardrailer)
+	if !ok || g.guardrails() == nil {
+		return nil
+	}
+
+	if err := g.guardrails().check(qb); err != nil {
+		qb.release()
+
+		return err
+	}
+
+	return nil
+}
+
+func clientGuardrails(c Client) *Guardrails {
+	if g, ok := c.(guardrailer); ok {
+		return g.guardrails()
+	}
+
+	return nil
+}
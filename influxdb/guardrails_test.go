@@ -0,0 +1,178 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+func TestGuardrailsQueryPaths(t *testing.T) {
+	end := epoch.Add(10 * time.Minute)
+	bounded := func() *influxdb.QueryBuilder {
+		return influxdb.From("cpu").Select("ts", "value").Where(influxdb.C("ts").Between(influxdb.Range(epoch, end)))
+	}
+	unbounded := func() *influxdb.QueryBuilder {
+		return influxdb.From("cpu").Select("ts", "value")
+	}
+
+	// query 返回结果的行数和错误。
+	tests := []struct {
+		name     string
+		guards   influxdb.Guardrails
+		query    func(db *influxdb.InfluxDB) (int, error)
+		want     int
+		wantRule string
+		// wantSQL 不为空时检查最后一次查询包含该片段，用于 fake server 不支持的语句。
+		wantSQL string
+	}{
+		{
+			name:   "union rejects an unbounded query",
+			guards: influxdb.Guardrails{RequireTimeBound: true},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				return 0, influxdb.UnionAll(bounded(), unbounded()).Query(context.Background(), db, &[]map[string]any{})
+			},
+			wantRule: influxdb.MissingTimeBoundRule,
+		},
+		{
+			name:   "union rejects the first query",
+			guards: influxdb.Guardrails{RequireTimeBound: true},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				return 0, influxdb.UnionAll(unbounded(), bounded()).Query(context.Background(), db, &[]map[string]any{})
+			},
+			wantRule: influxdb.MissingTimeBoundRule,
+		},
+		{
+			name:   "union limits raw queries",
+			guards: influxdb.Guardrails{MaxRawLimit: 3},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				_ = influxdb.UnionAll(bounded(), bounded()).Query(context.Background(), db, &[]map[string]any{})
+
+				return 0, nil
+			},
+			wantSQL: "LIMIT 3 UNION ALL SELECT ts, value FROM cpu WHERE (ts>='2024-01-01T00:00:00Z' AND ts<='2024-01-01T00:10:00Z') LIMIT 3",
+		},
+		{
+			name:   "pages reject an unbounded query",
+			guards: influxdb.Guardrails{RequireTimeBound: true},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				return pageRows(unbounded().Pages(context.Background(), db, 4))
+			},
+			wantRule: influxdb.MissingTimeBoundRule,
+		},
+		{
+			name:   "pages accept a bounded query",
+			guards: influxdb.Guardrails{RequireTimeBound: true, MaxTimeRange: time.Hour},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				return pageRows(bounded().Pages(context.Background(), db, 4))
+			},
+			want: 10,
+		},
+		{
+			// 每页最多 MaxRawLimit 行，仍然遍历全部数据。
+			name:   "pages are capped by the raw limit",
+			guards: influxdb.Guardrails{MaxRawLimit: 3},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				it := bounded().Pages(context.Background(), db, 100)
+
+				var (
+					page  []map[string]any
+					total int
+				)
+
+				for it.Next(&page) {
+					if len(page) > 3 {
+						return 0, errors.New("page larger than the raw limit")
+					}
+
+					total += len(page)
+				}
+
+				return total, it.Err()
+			},
+			want: 10,
+		},
+		{
+			name:   "split adds its range before checking",
+			guards: influxdb.Guardrails{RequireTimeBound: true, MaxTimeRange: time.Hour},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				var rows []map[string]any
+				err := unbounded().Split("ts", epoch, end, 2).Query(context.Background(), db, &rows)
+
+				return len(rows), err
+			},
+			want: 10,
+		},
+		{
+			name:   "split range too long",
+			guards: influxdb.Guardrails{MaxTimeRange: 5 * time.Minute},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				var rows []map[string]any
+				err := unbounded().Split("ts", epoch, end, 2).Query(context.Background(), db, &rows)
+
+				return len(rows), err
+			},
+			wantRule: influxdb.TimeRangeExceededRule,
+		},
+		{
+			name:   "split limits raw queries",
+			guards: influxdb.Guardrails{MaxRawLimit: 4},
+			query: func(db *influxdb.InfluxDB) (int, error) {
+				var rows []map[string]any
+				err := unbounded().Order(influxdb.C("ts").Asc()).Split("ts", epoch, end, 2).
+					Query(context.Background(), db, &rows)
+
+				return len(rows), err
+			},
+			want: 4,
+		},
+	}
+
+	for _, test := range tests {
+		guards := test.guards
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.Guardrails = &guards })
+		insertCPU(srv, []string{"a"}, 10)
+
+		n, err := test.query(db)
+		if test.wantRule != "" {
+			var ge *influxdb.GuardrailError
+			if !errors.As(err, &ge) || ge.Rule != test.wantRule {
+				t.Errorf("%s: error = %v; want rule %s", test.name, err, test.wantRule)
+			}
+
+			if len(srv.Queries()) != 0 {
+				t.Errorf("%s: queries = %v; want none sent", test.name, srv.Queries())
+			}
+
+			continue
+		}
+
+		if test.wantSQL != "" {
+			if q := srv.LastQuery(); !strings.Contains(q, test.wantSQL) {
+				t.Errorf("%s: query = %q; want it to contain %q", test.name, q, test.wantSQL)
+			}
+
+			continue
+		}
+
+		if err != nil || n != test.want {
+			t.Errorf("%s: %d rows, %v; want %d rows", test.name, n, err, test.want)
+		}
+	}
+}
+
+func pageRows(it *influxdb.PageIterator) (int, error) {
+	var (
+		page  []map[string]any
+		total int
+	)
+
+	for it.Next(&page) {
+		total += len(page)
+	}
+
+	return total, it.Err()
+}
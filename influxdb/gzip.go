@@ -0,0 +1,43 @@
+package influxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+func getGzipWriter(w io.Writer) *gzip.Writer {
+	zw := gzipWriterPool.Get().(*gzip.Writer)
+	zw.Reset(w)
+
+	return zw
+}
+
+func putGzipWriter(zw *gzip.Writer) {
+	zw.Reset(io.Discard)
+	gzipWriterPool.Put(zw)
+}
+
+// gzipBytes 压缩请求体。
+func gzipBytes(b []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := getGzipWriter(buf)
+	defer putGzipWriter(zw)
+
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package influxdb_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+// recordTransport 记录请求的方法和路径以及 POST 请求的 Content-Encoding 后交给 http.DefaultTransport。
+type recordTransport struct {
+	mu        sync.Mutex
+	requests  []string
+	encodings []string
+}
+
+func (rt *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.requests = append(rt.requests, req.Method+" "+req.URL.Path)
+
+	if req.Method == http.MethodPost {
+		rt.encodings = append(rt.encodings, req.Header.Get("Content-Encoding"))
+	}
+	rt.mu.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// take 返回并清空记录的请求。
+func (rt *recordTransport) take() []string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	requests := rt.requests
+	rt.requests = nil
+
+	return requests
+}
+
+func TestGzipRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		gzip     bool
+		encoding string
+	}{
+		{name: "plain", gzip: false, encoding: ""},
+		{name: "gzip", gzip: true, encoding: "gzip"},
+	}
+
+	for _, test := range tests {
+		rt := &recordTransport{}
+
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) {
+			cfg.Transport = rt
+			cfg.Gzip = test.gzip
+		})
+
+		err := db.WriteContext(context.Background(), []influxdb.Writable{cpuPoint{ts: epoch, host: "a", value: 1}})
+		if err != nil {
+			t.Fatalf("%s: write error: %v", test.name, err)
+		}
+
+		var rows []map[string]any
+		if err = db.Query2(context.Background(), "SELECT ts, value FROM cpu", &rows); err != nil || len(rows) != 1 {
+			t.Fatalf("%s: Query2 = %v, %v; want 1 row", test.name, rows, err)
+		}
+
+		// InfluxQL 查询使用 GET，没有可以压缩的请求体。
+		if err = db.Query(context.Background(), "SELECT value FROM cpu", &rows); err != nil {
+			t.Fatalf("%s: Query error: %v", test.name, err)
+		}
+
+		var methods []string
+		for _, r := range rt.take() {
+			methods = append(methods, strings.Fields(r)[0])
+		}
+
+		if want := "[POST POST GET]"; fmt.Sprint(methods) != want {
+			t.Errorf("%s: request methods = %v; want %s", test.name, methods, want)
+		}
+
+		if len(srv.Lines()) != 1 {
+			t.Errorf("%s: server received %v; want 1 line", test.name, srv.Lines())
+		}
+
+		rt.mu.Lock()
+		encodings := rt.encodings
+		rt.mu.Unlock()
+
+		// 写入和 TDengine 查询各一个 POST 请求，InfluxQL 查询的 GET 没有 Content-Encoding。
+		if want := []string{test.encoding, test.encoding}; fmt.Sprint(encodings) != fmt.Sprint(want) {
+			t.Errorf("%s: Content-Encoding = %q; want %q", test.name, encodings, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+type Operation string
+
+const (
+	QueryOperation  Operation = "query"
+	Query2Operation Operation = "query2"
+	WriteOperation  Operation = "write"
+	DeleteOperation Operation = "delete"
+	ExecOperation   Operation = "exec"
+)
+
+// OpInfo 描述一次查询、写入或删除，Before 时只有 Op、SQL 和 Start 有效。
+type OpInfo struct {
+	Start       time.Time
+	Err         error
+	Op          Operation
+	SQL         string
+	Duration    time.Duration
+	LimiterWait time.Duration
+	QueueWait   time.Duration
+	Bytes       int
+	Rows        int
+}
+
+// Hooks 在每次 Query、Query2、Write、Delete 和 Exec 前后调用，Before 返回的 context 会传给 After。
+type Hooks interface {
+	Before(ctx context.Context, info *OpInfo) context.Context
+	After(ctx context.Context, info *OpInfo)
+}
+
+// AddHooks 注册 hooks，按注册顺序调用 Before，逆序调用 After。
+func (i *InfluxDB) AddHooks(hooks ...Hooks) {
+	i.hooks = append(i.hooks, hooks...)
+}
+
+type opInfoKey struct{}
+
+func (i *InfluxDB) before(ctx context.Context, op Operation, sql string) (context.Context, *OpInfo) {
+	info := &OpInfo{Op: op, SQL: sql, Start: time.Now()}
+
+	for _, h := range i.hooks {
+		ctx = h.Before(ctx, info)
+	}
+
+	return context.WithValue(ctx, opInfoKey{}, info), info
+}
+
+func (i *InfluxDB) after(ctx context.Context, info *OpInfo, err error) {
+	info.Err = err
+	info.Duration = time.Since(info.Start)
+
+	for j := len(i.hooks) - 1; j >= 0; j-- {
+		i.hooks[j].After(ctx, info)
+	}
+}
+
+// SlogHooks 使用 log/slog 记录每次操作，出错时以 Error 级别记录，耗时超过 SlowThreshold 时以 Warn 级别记录。
+type SlogHooks struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+}
+
+func NewSlogHooks(logger *slog.Logger, slowThreshold time.Duration) *SlogHooks {
+	return &SlogHooks{Logger: logger, SlowThreshold: slowThreshold}
+}
+
+func (h *SlogHooks) Before(ctx context.Context, _ *OpInfo) context.Context {
+	return ctx
+}
+
+func (h *SlogHooks) After(ctx context.Context, info *OpInfo) {
+	logger := h.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	level := slog.LevelDebug
+
+	switch {
+	case info.Err != nil:
+		level = slog.LevelError
+	case h.SlowThreshold > 0 && info.Duration >= h.SlowThreshold:
+		level = slog.LevelWarn
+	}
+
+	logger.LogAttrs(ctx, level, "influxdb "+string(info.Op),
+		slog.String("sql", info.SQL),
+		slog.Duration("duration", info.Duration),
+		slog.Duration("limiter_wait", info.LimiterWait),
+		slog.Duration("queue_wait", info.QueueWait),
+		slog.Int("bytes", info.Bytes),
+		slog.Int("rows", info.Rows),
+		slog.Any("error", info.Err),
+	)
+}
+
+// Stats 为各类操作的累计计数，实现了 expvar.Var，可通过 expvar.Publish 暴露。
+type Stats struct {
+	Queries     atomic.Int64
+	Writes      atomic.Int64
+	Deletes     atomic.Int64
+	Execs       atomic.Int64
+	Errors      atomic.Int64
+	Bytes       atomic.Int64
+	Rows        atomic.Int64
+	Duration    atomic.Int64
+	LimiterWait atomic.Int64
+	QueueWait   atomic.Int64
+}
+
+func (s *Stats) Before(ctx context.Context, _ *OpInfo) context.Context {
+	return ctx
+}
+
+func (s *Stats) After(_ context.Context, info *OpInfo) {
+	switch info.Op {
+	case QueryOperation, Query2Operation:
+		s.Queries.Add(1)
+	case WriteOperation:
+		s.Writes.Add(1)
+	case DeleteOperation:
+		s.Deletes.Add(1)
+	case ExecOperation:
+		s.Execs.Add(1)
+	}
+
+	if info.Err != nil {
+		s.Errors.Add(1)
+	}
+
+	s.Bytes.Add(int64(info.Bytes))
+	s.Rows.Add(int64(info.Rows))
+	s.Duration.Add(int64(info.Duration))
+	s.LimiterWait.Add(int64(info.LimiterWait))
+	s.QueueWait.Add(int64(info.QueueWait))
+}
+
+// String 以 JSON 输出计数，耗时单位为纳秒。
+func (s *Stats) String() string {
+	b, _ := json.Marshal(map[string]int64{
+		"queries":      s.Queries.Load(),
+		"writes":       s.Writes.Load(),
+		"deletes":      s.Deletes.Load(),
+		"execs":        s.Execs.Load(),
+		"errors":       s.Errors.Load(),
+		"bytes":        s.Bytes.Load(),
+		"rows":         s.Rows.Load(),
+		"duration":     s.Duration.Load(),
+		"limiter_wait": s.LimiterWait.Load(),
+		"queue_wait":   s.QueueWait.Load(),
+	})
+
+	return string(b)
+}
@@ -0,0 +1,194 @@
+package influxdb_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+// recordHooks 记录 Before 和 After 的调用顺序和 After 收到的 OpInfo。
+type recordHooks struct {
+	name  string
+	calls *[]string
+	infos []influxdb.OpInfo
+}
+
+type hookNameKey struct{}
+
+func (h *recordHooks) Before(ctx context.Context, info *influxdb.OpInfo) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+
+	return context.WithValue(ctx, hookNameKey{}, h.name)
+}
+
+func (h *recordHooks) After(ctx context.Context, info *influxdb.OpInfo) {
+	*h.calls = append(*h.calls, fmt.Sprintf("after %s ctx=%v", h.name, ctx.Value(hookNameKey{})))
+	h.infos = append(h.infos, *info)
+}
+
+func TestHooks(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		run     func(db *influxdb.InfluxDB) error
+		op      influxdb.Operation
+		sql     string
+		rows    int
+		failed  bool
+		noBytes bool
+	}{
+		{
+			name: "query2",
+			run: func(db *influxdb.InfluxDB) error {
+				var rows []map[string]any
+				return db.Query2(ctx, "SELECT ts, value FROM cpu", &rows)
+			},
+			op: influxdb.Query2Operation, sql: "SELECT ts, value FROM cpu", rows: 3,
+		},
+		{
+			name: "query",
+			run: func(db *influxdb.InfluxDB) error {
+				var rows []map[string]any
+				return db.Query(ctx, "SELECT value FROM cpu", &rows)
+			},
+			op: influxdb.QueryOperation, sql: "SELECT value FROM cpu", rows: 3,
+		},
+		{
+			name: "failed query2",
+			run: func(db *influxdb.InfluxDB) error {
+				var rows []map[string]any
+				return db.Query2(ctx, "SELECT ts FROM missing_column_table WHERE", &rows)
+			},
+			op: influxdb.Query2Operation, sql: "SELECT ts FROM missing_column_table WHERE", failed: true, noBytes: true,
+		},
+		{
+			name: "write",
+			run: func(db *influxdb.InfluxDB) error {
+				return db.WriteContext(ctx, []influxdb.Writable{
+					cpuPoint{ts: epoch.Add(time.Hour), host: "a", value: 1},
+					cpuPoint{ts: epoch.Add(2 * time.Hour), host: "a", value: 2},
+				})
+			},
+			op: influxdb.WriteOperation, rows: 2,
+		},
+		{
+			name: "delete",
+			run: func(db *influxdb.InfluxDB) error {
+				_, err := db.DeleteContext(ctx, "DELETE FROM cpu WHERE host = 'a'")
+				return err
+			},
+			op: influxdb.DeleteOperation, sql: "DELETE FROM cpu WHERE host = 'a'", rows: 3, noBytes: true,
+		},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t)
+		insertCPU(srv, []string{"a"}, 3)
+
+		var calls []string
+
+		first := &recordHooks{name: "first", calls: &calls}
+		second := &recordHooks{name: "second", calls: &calls}
+		db.AddHooks(first, second)
+
+		err := test.run(db)
+		if (err != nil) != test.failed {
+			t.Errorf("%s: error = %v; want failed %v", test.name, err, test.failed)
+		}
+
+		wantCalls := "before first;before second;after second ctx=second;after first ctx=second"
+		if got := strings.Join(calls, ";"); got != wantCalls {
+			t.Errorf("%s: calls = %s; want %s", test.name, got, wantCalls)
+		}
+
+		if len(first.infos) != 1 {
+			t.Fatalf("%s: After called %d times; want 1", test.name, len(first.infos))
+		}
+
+		info := first.infos[0]
+		if info.Op != test.op || info.SQL != test.sql || info.Rows != test.rows {
+			t.Errorf("%s: info = %s %q rows %d; want %s %q rows %d",
+				test.name, info.Op, info.SQL, info.Rows, test.op, test.sql, test.rows)
+		}
+
+		if (info.Err != nil) != test.failed || (info.Bytes == 0) != test.noBytes || info.Duration <= 0 {
+			t.Errorf("%s: info err = %v, bytes = %d, duration = %v", test.name, info.Err, info.Bytes, info.Duration)
+		}
+	}
+}
+
+func TestStatsHooks(t *testing.T) {
+	db, srv := newTestDB(t)
+	insertCPU(srv, []string{"a", "b"}, 2)
+
+	stats := &influxdb.Stats{}
+	db.AddHooks(stats)
+
+	ctx := context.Background()
+
+	var rows []map[string]any
+
+	_ = db.Query2(ctx, "SELECT ts, value FROM cpu", &rows)
+	_ = db.Query(ctx, "SELECT value FROM cpu", &rows)
+	_ = db.Query2(ctx, "SELECT FROM", &rows)
+	_ = db.WriteContext(ctx, []influxdb.Writable{cpuPoint{ts: epoch, host: "c", value: 1}})
+
+	var got map[string]int64
+	if err := json.Unmarshal([]byte(stats.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{"queries": 3, "writes": 1, "deletes": 0, "execs": 0, "errors": 1, "rows": 9}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("stats[%s] = %d; want %d", k, got[k], v)
+		}
+	}
+
+	if got["bytes"] == 0 || got["duration"] == 0 {
+		t.Errorf("stats = %s; want bytes and duration recorded", stats.String())
+	}
+}
+
+func TestSlogHooks(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		slow  time.Duration
+		level string
+	}{
+		{name: "ok", query: "SELECT ts FROM cpu", level: "DEBUG"},
+		{name: "slow", query: "SELECT ts FROM cpu", slow: time.Nanosecond, level: "WARN"},
+		{name: "error", query: "SELECT FROM", slow: time.Nanosecond, level: "ERROR"},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t)
+		insertCPU(srv, []string{"a"}, 1)
+
+		var buf bytes.Buffer
+
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		db.AddHooks(influxdb.NewSlogHooks(logger, test.slow))
+
+		var rows []map[string]any
+		_ = db.Query2(context.Background(), test.query, &rows)
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("%s: log %q: %v", test.name, buf.String(), err)
+		}
+
+		if record["level"] != test.level || record["msg"] != "influxdb query2" || record["sql"] != test.query {
+			t.Errorf("%s: log = %s; want level %s", test.name, buf.String(), test.level)
+		}
+	}
+}
@@ -0,0 +1,1623 @@
+package influxdbtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// resultSet 为语句的执行结果，非查询语句只有 affected。
+type resultSet struct {
+	columns  []string
+	series   []series
+	affected int64
+	exec     bool
+}
+
+type series struct {
+	tags map[string]string
+	name string
+	rows [][]interface{}
+}
+
+type row map[string]interface{}
+
+// executor 在 store 上执行一条语句，influx 为 true 时按 InfluxQL 的规则命名列和组织序列。
+type executor struct {
+	store    *store
+	now      time.Time
+	database string
+	influx   bool
+}
+
+func (e *executor) execute(sql string) (*resultSet, error) {
+	p, err := newParser(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.peek().is("SELECT"):
+		stmt, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+
+		return e.selectRows(stmt)
+	case p.accept("SHOW"):
+		return e.show(p)
+	case p.accept("DESCRIBE") || p.accept("DESC"):
+		return e.describe(p)
+	case p.accept("DELETE", "FROM"):
+		return e.delete(p)
+	case p.accept("CREATE"):
+		return e.create(p, sql)
+	case p.accept("DROP"):
+		return e.drop(p)
+	case p.accept("ALTER"):
+		return e.alter(p)
+	}
+
+	return nil, p.errorf("unsupported statement")
+}
+
+func ack() *resultSet {
+	return &resultSet{exec: true}
+}
+
+func stringRows(name, column string, values []string) *resultSet {
+	s := series{name: name}
+	for _, v := range values {
+		s.rows = append(s.rows, []interface{}{v})
+	}
+
+	return &resultSet{columns: []string{column}, series: []series{s}}
+}
+
+func (e *executor) show(p *parser) (*resultSet, error) {
+	switch {
+	case p.accept("DATABASES"):
+		return stringRows("databases", "name", sortedKeys(e.store.databases)), nil
+	case p.accept("STABLES"):
+		return stringRows("stables", "stable_name", e.store.measurements()), nil
+	case p.accept("MEASUREMENTS"):
+		return stringRows("measurements", "name", e.store.measurements()), nil
+	case p.accept("CONTINUOUS", "QUERIES"):
+		s := series{name: e.database}
+		for _, name := range sortedKeys(e.store.cqs) {
+			s.rows = append(s.rows, []interface{}{name, e.store.cqs[name]})
+		}
+
+		return &resultSet{columns: []string{"name", "query"}, series: []series{s}}, nil
+	case p.accept("TAG", "KEYS", "FROM"):
+		m, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []string
+
+		for _, col := range e.store.columns(m) {
+			if col.tag {
+				keys = append(keys, col.name)
+			}
+		}
+
+		return stringRows(m, "tagKey", keys), nil
+	case p.accept("FIELD", "KEYS", "FROM"):
+		m, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		s := series{name: m}
+
+		for _, col := range e.store.columns(m)[min(1, len(e.store.columns(m))):] {
+			if !col.tag {
+				s.rows = append(s.rows, []interface{}{col.name, influxType(e.sample(m, col.name))})
+			}
+		}
+
+		return &resultSet{columns: []string{"fieldKey", "fieldType"}, series: []series{s}}, nil
+	case p.accept("TAG", "VALUES", "FROM"):
+		return e.showTagValues(p)
+	}
+
+	return nil, p.errorf("unsupported SHOW statement")
+}
+
+// sample 返回 measurement 中第一个非空的 field 值，用于推断类型。
+func (e *executor) sample(measurement, field string) interface{} {
+	for _, p := range e.store.points[measurement] {
+		if v, ok := p.Fields[field]; ok {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (e *executor) showTagValues(p *parser) (*resultSet, error) {
+	m, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.accept("WITH", "KEY") || p.expectOp("=") != nil {
+		return nil, p.errorf("expected WITH KEY =")
+	}
+
+	key, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	var where expr
+
+	if p.accept("WHERE") {
+		if where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := e.scan(m, where)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]struct{}{}
+
+	for _, r := range rows {
+		if v, ok := r[key].(string); ok {
+			values[v] = struct{}{}
+		}
+	}
+
+	s := series{name: m}
+	for _, v := range sortedKeys(values) {
+		s.rows = append(s.rows, []interface{}{key, v})
+	}
+
+	return &resultSet{columns: []string{"key", "value"}, series: []series{s}}, nil
+}
+
+func (e *executor) describe(p *parser) (*resultSet, error) {
+	m, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := e.store.columns(m)
+	if len(cols) == 0 {
+		return nil, errors.New("Table does not exist")
+	}
+
+	s := series{name: m}
+
+	for _, col := range cols {
+		note := ""
+		if col.tag {
+			note = "TAG"
+		}
+
+		s.rows = append(s.rows, []interface{}{col.name, col.typ, int64(8), note})
+	}
+
+	return &resultSet{columns: []string{"field", "type", "length", "note"}, series: []series{s}}, nil
+}
+
+func (e *executor) delete(p *parser) (*resultSet, error) {
+	m, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	var where expr
+
+	if p.accept("WHERE") {
+		if where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	kept := e.store.points[m][:0]
+	res := ack()
+
+	for _, pt := range e.store.points[m] {
+		ok, err := e.match(where, e.pointRow(pt))
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			res.affected++
+		} else {
+			kept = append(kept, pt)
+		}
+	}
+
+	e.store.points[m] = kept
+
+	return res, nil
+}
+
+func (e *executor) create(p *parser, sql string) (*resultSet, error) {
+	switch {
+	case p.accept("DATABASE"):
+		p.accept("IF", "NOT", "EXISTS")
+
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		e.store.databases[name] = struct{}{}
+
+		return ack(), nil
+	case p.accept("STABLE"):
+		p.accept("IF", "NOT", "EXISTS")
+
+		return e.createSTable(p)
+	case p.accept("TABLE"):
+		return ack(), nil
+	case p.accept("STREAM"):
+		p.accept("IF", "NOT", "EXISTS")
+
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		e.store.streams[name] = sql
+
+		return ack(), nil
+	case p.accept("CONTINUOUS", "QUERY"):
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		e.store.cqs[name] = sql
+
+		return ack(), nil
+	case p.accept("RETENTION", "POLICY"):
+		return ack(), nil
+	}
+
+	return nil, p.errorf("unsupported CREATE statement")
+}
+
+func (e *executor) createSTable(p *parser) (*resultSet, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := p.columnDefs(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept("TAGS") {
+		tags, err := p.columnDefs(true)
+		if err != nil {
+			return nil, err
+		}
+
+		cols = append(cols, tags...)
+	}
+
+	e.store.schemas[name] = cols
+
+	return ack(), nil
+}
+
+// columnDefs 解析 (name TYPE[(n)] [UNSIGNED], ...)。
+func (p *parser) columnDefs(tag bool) ([]column, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+
+	var cols []column
+
+	for {
+		col, err := p.columnDef(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		cols = append(cols, col)
+
+		if p.acceptOp(")") {
+			return cols, nil
+		}
+
+		if err = p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) columnDef(tag bool) (column, error) {
+	name, err := p.ident()
+	if err != nil {
+		return column{}, err
+	}
+
+	typ, err := p.ident()
+	if err != nil {
+		return column{}, err
+	}
+
+	typ = strings.ToUpper(typ)
+
+	if p.acceptOp("(") {
+		n := p.next()
+		if err = p.expectOp(")"); err != nil {
+			return column{}, err
+		}
+
+		typ += "(" + n.text + ")"
+	}
+
+	if p.accept("UNSIGNED") {
+		typ += " UNSIGNED"
+	}
+
+	return column{name: name, typ: typ, tag: tag}, nil
+}
+
+func (e *executor) drop(p *parser) (*resultSet, error) {
+	switch {
+	case p.accept("DATABASE"):
+		p.accept("IF", "EXISTS")
+
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		delete(e.store.databases, name)
+	case p.accept("STABLE") || p.accept("TABLE") || p.accept("MEASUREMENT"):
+		p.accept("IF", "EXISTS")
+
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		e.store.drop(name)
+	case p.accept("STREAM"):
+		p.accept("IF", "EXISTS")
+
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		delete(e.store.streams, name)
+	case p.accept("CONTINUOUS", "QUERY"):
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		delete(e.store.cqs, name)
+	case p.accept("RETENTION", "POLICY"):
+	default:
+		return nil, p.errorf("unsupported DROP statement")
+	}
+
+	return ack(), nil
+}
+
+func (e *executor) alter(p *parser) (*resultSet, error) {
+	if p.accept("RETENTION", "POLICY") {
+		return ack(), nil
+	}
+
+	if !p.accept("STABLE") {
+		return nil, p.errorf("unsupported ALTER statement")
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := append([]column(nil), e.store.columns(name)...)
+
+	switch {
+	case p.accept("ADD", "COLUMN") || p.accept("ADD", "TAG"):
+		col, err := p.columnDef(p.tokens[p.pos-1].is("TAG"))
+		if err != nil {
+			return nil, err
+		}
+
+		cols = append(cols, col)
+	case p.accept("DROP", "COLUMN") || p.accept("DROP", "TAG"):
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range cols {
+			if cols[j].name == col {
+				cols = append(cols[:j], cols[j+1:]...)
+				break
+			}
+		}
+	default:
+		return nil, p.errorf("unsupported ALTER STABLE statement")
+	}
+
+	e.store.schemas[name] = cols
+
+	return ack(), nil
+}
+
+// pointRow 将数据点转换为行，时间列同时以 time、ts 和 _ts 访问。
+func (e *executor) pointRow(p Point) row {
+	r := make(row, len(p.Tags)+len(p.Fields)+3)
+
+	for k, v := range p.Tags {
+		r[k] = v
+	}
+
+	for k, v := range p.Fields {
+		r[k] = v
+	}
+
+	r["time"], r["ts"], r["_ts"] = p.Time, p.Time, p.Time
+	r["tbname"] = p.Measurement
+
+	return r
+}
+
+// scan 返回 measurement 中满足 where 的行，按时间升序排列。
+func (e *executor) scan(measurement string, where expr) ([]row, error) {
+	points := append([]Point(nil), e.store.points[measurement]...)
+	sort.SliceStable(points, func(a, b int) bool { return points[a].Time.Before(points[b].Time) })
+
+	if measurement == "information_schema.ins_streams" {
+		points = points[:0]
+
+		for _, name := range sortedKeys(e.store.streams) {
+			points = append(points, Point{Fields: map[string]interface{}{
+				"stream_name": name, "sql": e.store.streams[name], "status": "ready",
+			}})
+		}
+	}
+
+	rows := make([]row, 0, len(points))
+
+	for _, pt := range points {
+		r := e.pointRow(pt)
+
+		ok, err := e.match(where, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			rows = append(rows, r)
+		}
+	}
+
+	return rows, nil
+}
+
+func (e *executor) match(where expr, r row) (bool, error) {
+	if where == nil {
+		return true, nil
+	}
+
+	v, err := e.eval(where, r, nil)
+	if err != nil {
+		return false, err
+	}
+
+	return truthy(v), nil
+}
+
+func (e *executor) selectRows(stmt *selectStmt) (*resultSet, error) {
+	if err := e.checkLimitOrder(stmt); err != nil {
+		return nil, err
+	}
+
+	rows, err := e.scan(stmt.from, stmt.where)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := e.expandFields(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &resultSet{}
+	for _, f := range fields {
+		res.columns = append(res.columns, e.columnName(f))
+	}
+
+	aggregate := stmt.window > 0
+
+	for _, f := range fields {
+		aggregate = aggregate || hasAggregate(f.expr)
+	}
+
+	timeColumn := e.influx && (len(fields) == 0 || !isTimeRef(fields[0].expr))
+	if timeColumn {
+		res.columns = append([]string{"time"}, res.columns...)
+	}
+
+	order, hidden, err := e.orderColumns(stmt, res.columns)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range e.groups(stmt, rows) {
+		if e.influx && len(g.rows) == 0 {
+			continue
+		}
+
+		s := series{name: stmt.from, tags: g.tags}
+
+		if aggregate {
+			s.rows, err = e.aggregateRows(stmt, fields, g, timeColumn, hidden)
+		} else {
+			s.rows, err = e.rawRows(fields, g.rows, timeColumn, hidden)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(s.rows) > 0 || !e.influx {
+			res.series = append(res.series, s)
+		}
+	}
+
+	// TDengine 只有 PARTITION BY 的每个分区是一个序列，LIMIT 作用于每个分区；否则所有分组合并为一个结果再排序分页。
+	if !e.influx && len(stmt.partition) == 0 {
+		res.series = merge(stmt.from, res.series)
+	}
+
+	if stmt.distinct {
+		for j := range res.series {
+			res.series[j].rows = distinct(res.series[j].rows)
+		}
+	}
+
+	e.paginate(stmt, res, order)
+
+	if !e.influx {
+		res.series = merge(stmt.from, res.series)
+	}
+
+	for j := range res.series {
+		for k, r := range res.series[j].rows {
+			res.series[j].rows[k] = r[:len(res.columns)]
+		}
+	}
+
+	return res, nil
+}
+
+// checkLimitOrder 检查 LIMIT/OFFSET 与 SLIMIT/SOFFSET 的顺序：TDengine 的 SLIMIT 在 LIMIT 之前，InfluxQL 相反。
+func (e *executor) checkLimitOrder(stmt *selectStmt) error {
+	hasLimit := stmt.limit >= 0 || stmt.skip > 0
+	hasSLimit := stmt.slimit >= 0 || stmt.soffset > 0
+
+	switch {
+	case !hasLimit || !hasSLimit:
+		return nil
+	case e.influx && !stmt.limitFirst:
+		return errors.New("SLIMIT and SOFFSET must follow LIMIT and OFFSET")
+	case !e.influx && stmt.limitFirst:
+		return errors.New("syntax error near SLIMIT: SLIMIT and SOFFSET must precede LIMIT and OFFSET")
+	}
+
+	return nil
+}
+
+type sortColumn struct {
+	index int
+	desc  bool
+}
+
+// orderColumns 返回 ORDER BY 的列在结果行中的下标，不在结果中的存储列（如 ts、标签）作为隐藏列追加在行尾，
+// 排序后去掉。未知的列返回错误。
+func (e *executor) orderColumns(stmt *selectStmt, columns []string) ([]sortColumn, []string, error) {
+	var (
+		order  []sortColumn
+		hidden []string
+	)
+
+	for _, item := range stmt.order {
+		if col := columnIndex(columns, item.name); col >= 0 {
+			order = append(order, sortColumn{index: col, desc: item.desc})
+
+			continue
+		}
+
+		if !e.storedColumn(stmt.from, item.name) {
+			return nil, nil, fmt.Errorf("unknown column %s in ORDER BY", item.name)
+		}
+
+		order = append(order, sortColumn{index: len(columns) + len(hidden), desc: item.desc})
+		hidden = append(hidden, item.name)
+	}
+
+	return order, hidden, nil
+}
+
+func (e *executor) storedColumn(measurement, name string) bool {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+
+	switch strings.ToLower(name) {
+	case "time", "ts", "_ts", "tbname", "_wstart", "_wend":
+		return true
+	}
+
+	for _, col := range e.store.columns(measurement) {
+		if strings.EqualFold(col.name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func merge(name string, ss []series) []series {
+	if len(ss) <= 1 {
+		return ss
+	}
+
+	merged := series{name: name}
+	for _, s := range ss {
+		merged.rows = append(merged.rows, s.rows...)
+	}
+
+	return []series{merged}
+}
+
+// expandFields 将 * 展开为所有列，Influx 的 * 不包括时间列。
+func (e *executor) expandFields(stmt *selectStmt) ([]selectField, error) {
+	var fields []selectField
+
+	for _, f := range stmt.fields {
+		if _, ok := f.expr.(star); !ok {
+			fields = append(fields, f)
+			continue
+		}
+
+		cols := e.store.columns(stmt.from)
+
+		if stmt.from == "information_schema.ins_streams" {
+			cols = []column{{name: "stream_name"}, {name: "sql"}, {name: "status"}}
+		}
+
+		for j, col := range cols {
+			if j == 0 && col.typ == "TIMESTAMP" && e.influx {
+				continue
+			}
+
+			fields = append(fields, selectField{expr: colRef{name: col.name}, text: col.name})
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.New("no columns selected")
+	}
+
+	return fields, nil
+}
+
+// columnName 返回结果列名：别名、列名，Influx 的函数为小写函数名，TDengine 为原始表达式。
+func (e *executor) columnName(f selectField) string {
+	switch {
+	case f.alias != "":
+		return f.alias
+	case e.influx:
+		if c, ok := f.expr.(call); ok {
+			return strings.ToLower(c.name)
+		}
+	}
+
+	if c, ok := f.expr.(colRef); ok {
+		return c.name
+	}
+
+	return f.text
+}
+
+func isTimeRef(x expr) bool {
+	c, ok := x.(colRef)
+
+	return ok && (strings.EqualFold(c.name, "time") || c.name == "_wstart")
+}
+
+type group struct {
+	tags map[string]string
+	rows []row
+}
+
+// groups 按 GROUP BY 标签（TDengine 还包括 PARTITION BY 列）分组，组按标签值排序。
+func (e *executor) groups(stmt *selectStmt, rows []row) []group {
+	keys := append(append([]string(nil), stmt.partition...), stmt.groupBy...)
+
+	if len(keys) == 1 && keys[0] == "*" {
+		keys = keys[:0]
+
+		for _, col := range e.store.columns(stmt.from) {
+			if col.tag {
+				keys = append(keys, col.name)
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return []group{{rows: rows}}
+	}
+
+	byKey := map[string]*group{}
+
+	for _, r := range rows {
+		tags := make(map[string]string, len(keys))
+		parts := make([]string, 0, len(keys))
+
+		for _, k := range keys {
+			v := fmt.Sprint(lookup(r, k))
+			if lookup(r, k) == nil {
+				v = ""
+			}
+
+			tags[k] = v
+			parts = append(parts, k+"="+v)
+		}
+
+		key := strings.Join(parts, ",")
+
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{tags: tags}
+			byKey[key] = g
+		}
+
+		g.rows = append(g.rows, r)
+	}
+
+	groups := make([]group, 0, len(byKey))
+	for _, k := range sortedKeys(byKey) {
+		groups = append(groups, *byKey[k])
+	}
+
+	return groups
+}
+
+// rawRows 计算每行的结果，hidden 为排序用的存储列，追加在结果之后。
+func (e *executor) rawRows(fields []selectField, rows []row, timeColumn bool, hidden []string) ([][]interface{}, error) {
+	out := make([][]interface{}, 0, len(rows))
+
+	for _, r := range rows {
+		values := make([]interface{}, 0, len(fields)+len(hidden)+1)
+		if timeColumn {
+			values = append(values, r["time"])
+		}
+
+		for _, f := range fields {
+			v, err := e.eval(f.expr, r, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, v)
+		}
+
+		for _, name := range hidden {
+			values = append(values, lookup(r, name))
+		}
+
+		out = append(out, values)
+	}
+
+	return out, nil
+}
+
+type bucket struct {
+	start time.Time
+	rows  []row
+}
+
+// aggregateRows 按窗口计算聚合，窗口按 Unix 纪元对齐；没有窗口时整组为一个桶，时间为纪元。
+func (e *executor) aggregateRows(
+	stmt *selectStmt, fields []selectField, g group, timeColumn bool, hidden []string,
+) ([][]interface{}, error) {
+	buckets := e.buckets(stmt, g.rows)
+
+	var out [][]interface{}
+
+	for _, b := range buckets {
+		env := row{}
+		if len(b.rows) > 0 {
+			for k, v := range b.rows[0] {
+				env[k] = v
+			}
+		}
+
+		for k, v := range g.tags {
+			env[k] = v
+		}
+
+		env["time"], env["ts"], env["_ts"], env["_wstart"] = b.start, b.start, b.start, b.start
+		env["_wend"] = b.start.Add(stmt.window)
+		env["_wduration"] = stmt.window
+
+		values := make([]interface{}, 0, len(fields)+1)
+		if timeColumn {
+			values = append(values, b.start)
+		}
+
+		filled := len(b.rows) == 0 && stmt.window > 0
+
+		for _, f := range fields {
+			v, err := e.eval(f.expr, env, b.rows)
+			if err != nil {
+				return nil, err
+			}
+
+			if filled && hasAggregate(f.expr) {
+				v = nil
+				if stmt.fill == "value" {
+					v = stmt.fillValue
+				}
+			}
+
+			env[e.columnName(f)] = v
+			values = append(values, v)
+		}
+
+		if stmt.having != nil {
+			v, err := e.eval(stmt.having, env, b.rows)
+			if err != nil {
+				return nil, err
+			}
+
+			if !truthy(v) {
+				continue
+			}
+		}
+
+		for _, name := range hidden {
+			values = append(values, lookup(env, name))
+		}
+
+		out = append(out, values)
+	}
+
+	return e.fillColumns(stmt, fields, out, timeColumn), nil
+}
+
+// fillColumns 处理 FILL(PREV)、FILL(previous)、FILL(NEXT) 和 FILL(LINEAR)，linear 按相邻窗口线性插值。
+func (e *executor) fillColumns(stmt *selectStmt, fields []selectField, out [][]interface{}, timeColumn bool) [][]interface{} {
+	offset := 0
+	if timeColumn {
+		offset = 1
+	}
+
+	for k, f := range fields {
+		if !hasAggregate(f.expr) {
+			continue
+		}
+
+		col := k + offset
+
+		switch stmt.fill {
+		case "prev", "previous":
+			for j := 1; j < len(out); j++ {
+				if out[j][col] == nil {
+					out[j][col] = out[j-1][col]
+				}
+			}
+		case "next":
+			for j := len(out) - 2; j >= 0; j-- {
+				if out[j][col] == nil {
+					out[j][col] = out[j+1][col]
+				}
+			}
+		case "linear":
+			interpolate(out, col)
+		}
+	}
+
+	return out
+}
+
+func interpolate(out [][]interface{}, col int) {
+	last := -1
+
+	for j := range out {
+		if out[j][col] == nil {
+			continue
+		}
+
+		if last >= 0 && j-last > 1 {
+			a, _ := toFloat(out[last][col])
+			b, _ := toFloat(out[j][col])
+
+			for k := last + 1; k < j; k++ {
+				out[k][col] = a + (b-a)*float64(k-last)/float64(j-last)
+			}
+		}
+
+		last = j
+	}
+}
+
+// buckets 将行按窗口分桶，FILL 不为 none 时补齐首尾之间的空窗口。
+func (e *executor) buckets(stmt *selectStmt, rows []row) []bucket {
+	if stmt.window <= 0 {
+		return []bucket{{start: time.Unix(0, 0).UTC(), rows: rows}}
+	}
+
+	var buckets []bucket
+
+	w, off := int64(stmt.window), int64(stmt.offset)
+
+	for _, r := range rows {
+		t := r["time"].(time.Time).UnixNano() - off
+
+		start := t / w * w
+		if t < 0 && t%w != 0 {
+			start -= w
+		}
+
+		ts := time.Unix(0, start+off).UTC()
+
+		if n := len(buckets); n > 0 && buckets[n-1].start.Equal(ts) {
+			buckets[n-1].rows = append(buckets[n-1].rows, r)
+			continue
+		}
+
+		if n := len(buckets); n > 0 && stmt.fill != "" && stmt.fill != "none" {
+			for gap := buckets[n-1].start.Add(stmt.window); gap.Before(ts); gap = gap.Add(stmt.window) {
+				buckets = append(buckets, bucket{start: gap, rows: []row{}})
+			}
+		}
+
+		buckets = append(buckets, bucket{start: ts, rows: []row{r}})
+	}
+
+	return buckets
+}
+
+// paginate 在每个序列内按 order 排序并处理 LIMIT/OFFSET，再处理 SLIMIT/SOFFSET。
+func (e *executor) paginate(stmt *selectStmt, res *resultSet, order []sortColumn) {
+	for j := range res.series {
+		s := &res.series[j]
+
+		for k := len(order) - 1; k >= 0; k-- {
+			col := order[k]
+
+			sort.SliceStable(s.rows, func(a, b int) bool {
+				c := compareNulls(s.rows[a][col.index], s.rows[b][col.index])
+				if col.desc {
+					return c > 0
+				}
+
+				return c < 0
+			})
+		}
+
+		s.rows = window(s.rows, stmt.skip, stmt.limit)
+	}
+
+	res.series = window(res.series, stmt.soffset, stmt.slimit)
+}
+
+// compareNulls 比较排序的值，NULL 小于其他值，因此升序时在前、降序时在后。
+func compareNulls(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	c, _ := compare(a, b)
+
+	return c
+}
+
+func columnIndex(columns []string, name string) int {
+	for j, c := range columns {
+		if strings.EqualFold(c, name) {
+			return j
+		}
+	}
+
+	switch strings.ToLower(name) {
+	case "time", "ts", "_ts", "_wstart":
+		for j, c := range columns {
+			if c == "time" || c == "ts" || c == "_wstart" {
+				return j
+			}
+		}
+	}
+
+	return -1
+}
+
+func window[T any](s []T, offset, limit int) []T {
+	if offset > len(s) {
+		offset = len(s)
+	}
+
+	s = s[offset:]
+
+	if limit >= 0 && limit < len(s) {
+		s = s[:limit]
+	}
+
+	return s
+}
+
+func distinct(rows [][]interface{}) [][]interface{} {
+	seen := map[string]struct{}{}
+	out := rows[:0]
+
+	for _, r := range rows {
+		key := fmt.Sprint(r...)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		out = append(out, r)
+	}
+
+	return out
+}
+
+var aggregates = map[string]struct{}{
+	"COUNT": {}, "SUM": {}, "AVG": {}, "MEAN": {}, "MIN": {}, "MAX": {}, "FIRST": {}, "LAST": {},
+	"SPREAD": {}, "STDDEV": {}, "MEDIAN": {}, "PERCENTILE": {},
+}
+
+func hasAggregate(x expr) bool {
+	switch x := x.(type) {
+	case call:
+		if _, ok := aggregates[x.name]; ok {
+			return true
+		}
+
+		for _, arg := range x.args {
+			if hasAggregate(arg) {
+				return true
+			}
+		}
+	case binary:
+		return hasAggregate(x.left) || hasAggregate(x.right)
+	case unary:
+		return hasAggregate(x.operand)
+	}
+
+	return false
+}
+
+// lookup 按名称读取列，找不到时忽略大小写和表名前缀。
+func lookup(r row, name string) interface{} {
+	if v, ok := r[name]; ok {
+		return v
+	}
+
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return lookup(r, name[i+1:])
+	}
+
+	for k, v := range r {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// eval 计算表达式，bucket 不为 nil 时聚合函数在 bucket 的所有行上计算。
+func (e *executor) eval(x expr, r row, bucket []row) (interface{}, error) {
+	switch x := x.(type) {
+	case literal:
+		return x.val, nil
+	case colRef:
+		return lookup(r, x.name), nil
+	case star:
+		return nil, errors.New("* is only allowed in the select list and COUNT(*)")
+	case unary:
+		v, err := e.eval(x.operand, r, bucket)
+		if err != nil || v == nil {
+			return nil, err
+		}
+
+		if x.op == "NOT" {
+			return !truthy(v), nil
+		}
+
+		return arithmetic("-", int64(0), v)
+	case isNull:
+		v, err := e.eval(x.operand, r, bucket)
+
+		return (v == nil) != x.not, err
+	case inList:
+		v, err := e.eval(x.operand, r, bucket)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range x.list {
+			iv, err := e.eval(item, r, bucket)
+			if err != nil {
+				return nil, err
+			}
+
+			if c, ok := e.compareValues(v, iv); ok && c == 0 {
+				return !x.not, nil
+			}
+		}
+
+		return x.not, nil
+	case binary:
+		return e.evalBinary(x, r, bucket)
+	case call:
+		return e.evalCall(x, r, bucket)
+	}
+
+	return nil, fmt.Errorf("unsupported expression %T", x)
+}
+
+func (e *executor) evalBinary(x binary, r row, bucket []row) (interface{}, error) {
+	left, err := e.eval(x.left, r, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x.op {
+	case "AND":
+		if !truthy(left) {
+			return false, nil
+		}
+	case "OR":
+		if truthy(left) {
+			return true, nil
+		}
+	}
+
+	right, err := e.eval(x.right, r, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x.op {
+	case "AND", "OR":
+		return truthy(right), nil
+	case "+", "-", "*", "/", "%":
+		if left == nil || right == nil {
+			return nil, nil
+		}
+
+		return arithmetic(x.op, left, right)
+	case "~", "=~", "!~", "~*", "!~*":
+		s, ok1 := left.(string)
+		pattern, ok2 := right.(string)
+
+		if !ok1 || !ok2 {
+			return false, nil
+		}
+
+		if strings.HasSuffix(x.op, "*") {
+			pattern = "(?i)" + pattern
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return re.MatchString(s) != strings.HasPrefix(x.op, "!"), nil
+	}
+
+	c, ok := e.compareValues(left, right)
+	if !ok {
+		return x.op == "!=" || x.op == "<>", nil
+	}
+
+	switch x.op {
+	case "=":
+		return c == 0, nil
+	case "!=", "<>":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %s", x.op)
+}
+
+// compareValues 比较两个值，时间可以与时间字符串和整数比较，整数 Influx 为纳秒、TDengine 为毫秒。
+func (e *executor) compareValues(a, b interface{}) (int, bool) {
+	if t, ok := a.(time.Time); ok {
+		if bt, ok := e.toTime(b); ok {
+			return t.Compare(bt), true
+		}
+	}
+
+	if t, ok := b.(time.Time); ok {
+		if at, ok := e.toTime(a); ok {
+			return at.Compare(t), true
+		}
+	}
+
+	return compare(a, b)
+}
+
+func (e *executor) toTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	case int64:
+		if e.influx {
+			return time.Unix(0, v).UTC(), true
+		}
+
+		return time.UnixMilli(v).UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+func compare(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			switch {
+			case fa < fb:
+				return -1, true
+			case fa > fb:
+				return 1, true
+			}
+
+			return 0, true
+		}
+	}
+
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			if a == b {
+				return 0, true
+			}
+
+			if !a {
+				return -1, true
+			}
+
+			return 1, true
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), true
+		}
+	}
+
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case time.Duration:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case nil:
+		return false
+	}
+
+	f, ok := toFloat(v)
+
+	return ok && f != 0
+}
+
+func arithmetic(op string, a, b interface{}) (interface{}, error) {
+	if t, ok := a.(time.Time); ok {
+		d, ok := b.(time.Duration)
+		if !ok {
+			return nil, fmt.Errorf("invalid time arithmetic %v %s %v", a, op, b)
+		}
+
+		if op == "-" {
+			return t.Add(-d), nil
+		}
+
+		return t.Add(d), nil
+	}
+
+	ia, aInt := a.(int64)
+	ib, bInt := b.(int64)
+
+	if aInt && bInt && op != "/" {
+		switch op {
+		case "+":
+			return ia + ib, nil
+		case "-":
+			return ia - ib, nil
+		case "*":
+			return ia * ib, nil
+		case "%":
+			if ib == 0 {
+				return nil, nil
+			}
+
+			return ia % ib, nil
+		}
+	}
+
+	fa, ok1 := toFloat(a)
+	fb, ok2 := toFloat(b)
+
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("invalid arithmetic %v %s %v", a, op, b)
+	}
+
+	switch op {
+	case "+":
+		return fa + fb, nil
+	case "-":
+		return fa - fb, nil
+	case "*":
+		return fa * fb, nil
+	case "/":
+		if fb == 0 {
+			return nil, nil
+		}
+
+		return fa / fb, nil
+	}
+
+	return math.Mod(fa, fb), nil
+}
+
+func (e *executor) evalCall(x call, r row, bucket []row) (interface{}, error) {
+	if _, ok := aggregates[x.name]; ok {
+		if bucket == nil {
+			return nil, fmt.Errorf("aggregate function %s is not allowed here", x.name)
+		}
+
+		return e.aggregate(x, bucket)
+	}
+
+	args := make([]interface{}, len(x.args))
+
+	for j, arg := range x.args {
+		v, err := e.eval(arg, r, bucket)
+		if err != nil {
+			return nil, err
+		}
+
+		args[j] = v
+	}
+
+	switch x.name {
+	case "NOW":
+		return e.now, nil
+	case "ABS", "CEIL", "FLOOR", "ROUND":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s requires 1 argument", x.name)
+		}
+
+		f, ok := toFloat(args[0])
+		if !ok {
+			return nil, nil
+		}
+
+		switch x.name {
+		case "ABS":
+			f = math.Abs(f)
+		case "CEIL":
+			f = math.Ceil(f)
+		case "FLOOR":
+			f = math.Floor(f)
+		default:
+			f = math.Round(f)
+		}
+
+		if _, ok := args[0].(int64); ok {
+			return int64(f), nil
+		}
+
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("unsupported function %s", x.name)
+}
+
+// aggregate 在 rows 上计算聚合函数，空值不参与计算。
+func (e *executor) aggregate(x call, rows []row) (interface{}, error) {
+	if len(x.args) == 0 {
+		return nil, fmt.Errorf("%s requires an argument", x.name)
+	}
+
+	if _, ok := x.args[0].(star); ok && x.name == "COUNT" {
+		return int64(len(rows)), nil
+	}
+
+	var values []interface{}
+
+	for _, r := range rows {
+		v, err := e.eval(x.args[0], r, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+
+	if x.name == "COUNT" {
+		return int64(len(values)), nil
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	switch x.name {
+	case "FIRST":
+		return values[0], nil
+	case "LAST":
+		return values[len(values)-1], nil
+	case "MIN", "MAX":
+		best := values[0]
+
+		for _, v := range values[1:] {
+			c, _ := compare(v, best)
+			if x.name == "MIN" && c < 0 || x.name == "MAX" && c > 0 {
+				best = v
+			}
+		}
+
+		return best, nil
+	}
+
+	nums := make([]float64, 0, len(values))
+	allInt := true
+
+	for _, v := range values {
+		f, ok := toFloat(v)
+		if !ok {
+			return nil, fmt.Errorf("%s requires numeric values", x.name)
+		}
+
+		_, isInt := v.(int64)
+		allInt = allInt && isInt
+		nums = append(nums, f)
+	}
+
+	switch x.name {
+	case "SUM":
+		var sum float64
+		for _, f := range nums {
+			sum += f
+		}
+
+		if allInt {
+			return int64(sum), nil
+		}
+
+		return sum, nil
+	case "AVG", "MEAN":
+		return mean(nums), nil
+	case "SPREAD":
+		sort.Float64s(nums)
+
+		return nums[len(nums)-1] - nums[0], nil
+	case "STDDEV":
+		return e.stddev(nums), nil
+	case "MEDIAN":
+		sort.Float64s(nums)
+
+		if len(nums)%2 == 1 {
+			return nums[len(nums)/2], nil
+		}
+
+		return (nums[len(nums)/2-1] + nums[len(nums)/2]) / 2, nil
+	case "PERCENTILE":
+		if len(x.args) != 2 {
+			return nil, errors.New("PERCENTILE requires 2 arguments")
+		}
+
+		p, err := e.eval(x.args[1], nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		pf, _ := toFloat(p)
+		sort.Float64s(nums)
+
+		// 最近秩法。
+		rank := int(math.Ceil(pf/100*float64(len(nums)))) - 1
+
+		return nums[max(0, min(rank, len(nums)-1))], nil
+	}
+
+	return nil, fmt.Errorf("unsupported aggregate %s", x.name)
+}
+
+func mean(nums []float64) float64 {
+	var sum float64
+	for _, f := range nums {
+		sum += f
+	}
+
+	return sum / float64(len(nums))
+}
+
+// stddev 在 InfluxQL 下为样本标准差，在 TDengine 下为总体标准差。
+func (e *executor) stddev(nums []float64) interface{} {
+	n := float64(len(nums))
+	if e.influx {
+		n--
+	}
+
+	if n <= 0 {
+		return nil
+	}
+
+	m := mean(nums)
+
+	var sum float64
+	for _, f := range nums {
+		sum += (f - m) * (f - m)
+	}
+
+	return math.Sqrt(sum / n)
+}
@@ -0,0 +1,159 @@
+package influxdbtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testStore() *store {
+	s := newStore()
+
+	for j, host := range []string{"a", "b", "c"} {
+		for k := 0; k < 3; k++ {
+			s.insert(Point{
+				Measurement: "cpu",
+				Time:        epoch.Add(time.Duration(k)*time.Minute + time.Duration(j)*time.Second),
+				Tags:        map[string]string{"host": host},
+				Fields:      map[string]interface{}{"v": float64(10*j + k)},
+			})
+		}
+	}
+
+	return s
+}
+
+// flatten 将结果转换为字符串，每个序列一行，值以逗号分隔，行之间以分号分隔。
+func flatten(res *resultSet) string {
+	var out []string
+
+	for _, s := range res.series {
+		var rows []string
+
+		for _, r := range s.rows {
+			vals := make([]string, len(r))
+			for j, v := range r {
+				if t, ok := v.(time.Time); ok {
+					v = t.Sub(epoch)
+				}
+
+				vals[j] = fmt.Sprint(v)
+			}
+
+			rows = append(rows, strings.Join(vals, ","))
+		}
+
+		out = append(out, strings.Join(rows, ";"))
+	}
+
+	return strings.Join(out, " | ")
+}
+
+func TestExecuteSelect(t *testing.T) {
+	tests := []struct {
+		sql     string
+		influx  bool
+		columns []string
+		result  string
+		wantErr string
+	}{
+		{
+			sql:     "SELECT host, v FROM cpu ORDER BY ts DESC LIMIT 1",
+			columns: []string{"host", "v"},
+			result:  "c,22",
+		},
+		{
+			sql:     "SELECT v FROM cpu WHERE host = 'a' ORDER BY ts DESC",
+			columns: []string{"v"},
+			result:  "2;1;0",
+		},
+		{
+			sql:     "SELECT host, v FROM cpu ORDER BY host DESC, v LIMIT 2 OFFSET 1",
+			columns: []string{"host", "v"},
+			result:  "c,21;c,22",
+		},
+		{
+			sql:     "SELECT v FROM cpu ORDER BY nosuch",
+			wantErr: "unknown column nosuch in ORDER BY",
+		},
+		{
+			sql:     "SELECT host, COUNT(*) AS n FROM cpu PARTITION BY host SLIMIT 2 SOFFSET 1",
+			columns: []string{"host", "n"},
+			result:  "b,3;c,3",
+		},
+		{
+			sql:     "SELECT host, v FROM cpu PARTITION BY host ORDER BY ts DESC SLIMIT 2 LIMIT 1",
+			columns: []string{"host", "v"},
+			result:  "a,2;b,12",
+		},
+		{
+			sql:     "SELECT v FROM cpu PARTITION BY host LIMIT 1 SLIMIT 2",
+			wantErr: "SLIMIT and SOFFSET must precede LIMIT and OFFSET",
+		},
+		{
+			sql:     "SELECT host, MAX(v) AS m FROM cpu GROUP BY host ORDER BY m DESC LIMIT 1",
+			columns: []string{"host", "m"},
+			result:  "c,22",
+		},
+		{
+			sql:     "SELECT _wstart, AVG(v) AS avg FROM cpu WHERE host = 'a' INTERVAL(2m) ORDER BY _wstart DESC",
+			columns: []string{"_wstart", "avg"},
+			result:  "2m0s,2;0s,0.5",
+		},
+		{
+			sql:     "SELECT v FROM cpu WHERE host = 'b' ORDER BY time DESC LIMIT 2",
+			influx:  true,
+			columns: []string{"time", "v"},
+			result:  "2m1s,12;1m1s,11",
+		},
+		{
+			sql:     "SELECT v FROM cpu GROUP BY host LIMIT 1 SLIMIT 2",
+			influx:  true,
+			columns: []string{"time", "v"},
+			result:  "0s,0 | 1s,10",
+		},
+		{
+			sql:     "SELECT v FROM cpu GROUP BY host SLIMIT 2 LIMIT 1",
+			influx:  true,
+			wantErr: "SLIMIT and SOFFSET must follow LIMIT and OFFSET",
+		},
+		{
+			sql:     "SELECT count(v) FROM cpu GROUP BY time(2m), host SLIMIT 1",
+			influx:  true,
+			columns: []string{"time", "count"},
+			result:  "0s,2;2m0s,1",
+		},
+	}
+
+	for _, test := range tests {
+		e := &executor{store: testStore(), now: epoch, database: DefaultDatabase, influx: test.influx}
+
+		res, err := e.execute(test.sql)
+
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: error = %v; want %q", test.sql, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.sql, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(res.columns, test.columns) {
+			t.Errorf("%s: columns = %v; want %v", test.sql, res.columns, test.columns)
+		}
+
+		if got := flatten(res); got != test.result {
+			t.Errorf("%s: rows = %s; want %s", test.sql, got, test.result)
+		}
+	}
+}
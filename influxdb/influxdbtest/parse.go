@@ -0,0 +1,906 @@
+package influxdbtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int8
+
+const (
+	eofToken tokenKind = iota
+	identToken
+	stringToken
+	numberToken
+	durationToken
+	opToken
+)
+
+type token struct {
+	text string
+	kind tokenKind
+}
+
+func (t token) is(keyword string) bool {
+	return t.kind == identToken && strings.EqualFold(t.text, keyword)
+}
+
+func (t token) isOp(op string) bool {
+	return t.kind == opToken && t.text == op
+}
+
+func lex(sql string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';':
+			i++
+		case c == '\'':
+			s, n, err := lexQuoted(sql[i:], '\'')
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: stringToken, text: s})
+			i += n
+		case c == '"' || c == '`':
+			s, n, err := lexQuoted(sql[i:], c)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: identToken, text: s})
+			i += n
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i
+			for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.' ||
+				(sql[j] == 'e' || sql[j] == 'E') && j+1 < len(sql) && (sql[j+1] == '-' || sql[j+1] == '+' || unicode.IsDigit(rune(sql[j+1])))) {
+				if sql[j] == 'e' || sql[j] == 'E' {
+					j++
+				}
+				j++
+			}
+
+			k := j
+			for k < len(sql) && unicode.IsLetter(rune(sql[k])) {
+				k++
+			}
+
+			if k > j {
+				tokens = append(tokens, token{kind: durationToken, text: sql[i:k]})
+			} else {
+				tokens = append(tokens, token{kind: numberToken, text: sql[i:j]})
+			}
+
+			i = k
+		case c == '_' || c == ':' || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(sql) && (sql[j] == '_' || sql[j] == '.' || unicode.IsLetter(rune(sql[j])) || unicode.IsDigit(rune(sql[j]))) {
+				j++
+			}
+
+			tokens = append(tokens, token{kind: identToken, text: sql[i:j]})
+			i = j
+		default:
+			op := string(c)
+
+			for _, two := range []string{"!=", "<>", "<=", ">=", "!~", "~*", "=~"} {
+				if strings.HasPrefix(sql[i:], two) {
+					op = two
+					break
+				}
+			}
+
+			if strings.HasPrefix(sql[i:], "!~*") {
+				op = "!~*"
+			}
+
+			if !strings.Contains("=!<>~*+-/%(),.", op[:1]) {
+				return nil, fmt.Errorf("syntax error near %q", sql[i:])
+			}
+
+			tokens = append(tokens, token{kind: opToken, text: op})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: eofToken}), nil
+}
+
+// lexQuoted 读取引号包围的字符串，两个连续的引号或反斜杠表示转义。
+func lexQuoted(s string, quote byte) (string, int, error) {
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case s[i] == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		case s[i] == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated string %s", s)
+}
+
+type expr interface{}
+
+type (
+	colRef  struct{ name string }
+	literal struct{ val interface{} }
+	star    struct{}
+	binary  struct {
+		left, right expr
+		op          string
+	}
+	unary struct {
+		operand expr
+		op      string
+	}
+	inList struct {
+		operand expr
+		list    []expr
+		not     bool
+	}
+	isNull struct {
+		operand expr
+		not     bool
+	}
+	call struct {
+		name string
+		text string
+		args []expr
+	}
+)
+
+type selectField struct {
+	expr  expr
+	alias string
+	text  string
+}
+
+type orderItem struct {
+	name string
+	desc bool
+}
+
+type selectStmt struct {
+	where     expr
+	having    expr
+	fillValue interface{}
+	from      string
+	fill      string
+	fields    []selectField
+	partition []string
+	groupBy   []string
+	order     []orderItem
+	window    time.Duration
+	offset    time.Duration
+	limit     int
+	skip      int
+	slimit    int
+	soffset   int
+	distinct  bool
+	// limitFirst 为 true 时 LIMIT/OFFSET 出现在 SLIMIT/SOFFSET 之前。
+	limitFirst bool
+}
+
+type parser struct {
+	sql    string
+	tokens []token
+	pos    int
+}
+
+func newParser(sql string) (*parser, error) {
+	tokens, err := lex(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parser{sql: sql, tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != eofToken {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) accept(keywords ...string) bool {
+	start := p.pos
+
+	for _, kw := range keywords {
+		if !p.peek().is(kw) {
+			p.pos = start
+			return false
+		}
+
+		p.pos++
+	}
+
+	return true
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.peek().isOp(op) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.errorf("expected %q", op)
+	}
+
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error near %q: %s", p.peek().text, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind != identToken {
+		return "", p.errorf("expected identifier, got %q", t.text)
+	}
+
+	return t.text, nil
+}
+
+func (p *parser) integer() (int, error) {
+	t := p.next()
+	if t.kind != numberToken {
+		return 0, p.errorf("expected number, got %q", t.text)
+	}
+
+	return strconv.Atoi(t.text)
+}
+
+func (p *parser) parseSelect() (*selectStmt, error) {
+	stmt := &selectStmt{limit: -1, slimit: -1}
+
+	if !p.accept("SELECT") {
+		return nil, p.errorf("expected SELECT")
+	}
+
+	stmt.distinct = p.accept("DISTINCT")
+
+	for {
+		start := p.pos
+
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		f := selectField{expr: e, text: p.text(start, p.pos)}
+
+		if p.accept("AS") {
+			if f.alias, err = p.ident(); err != nil {
+				return nil, err
+			}
+		}
+
+		stmt.fields = append(stmt.fields, f)
+
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+
+	if p.accept("INTO") {
+		if _, err := p.ident(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.accept("FROM") {
+		return nil, p.errorf("expected FROM")
+	}
+
+	from, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.from = from
+
+	for p.peek().kind != eofToken {
+		if err = p.parseSelectClause(stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+func (p *parser) parseSelectClause(stmt *selectStmt) error {
+	var err error
+
+	switch {
+	case p.accept("WHERE"):
+		stmt.where, err = p.parseExpr()
+	case p.accept("HAVING"):
+		stmt.having, err = p.parseExpr()
+	case p.accept("PARTITION", "BY"):
+		stmt.partition, err = p.identList()
+	case p.accept("GROUP", "BY"):
+		err = p.parseGroupBy(stmt)
+	case p.accept("INTERVAL"):
+		stmt.window, stmt.offset, err = p.parseWindowArgs()
+	case p.accept("SLIDING"):
+		_, _, err = p.parseWindowArgs()
+	case p.accept("FILL"):
+		err = p.parseFill(stmt)
+	case p.accept("ORDER", "BY"):
+		err = p.parseOrder(stmt)
+	case p.accept("LIMIT"):
+		stmt.limitFirst = stmt.limitFirst || stmt.slimit < 0 && stmt.soffset == 0
+		stmt.limit, err = p.integer()
+		if err == nil && p.acceptOp(",") {
+			stmt.skip = stmt.limit
+			stmt.limit, err = p.integer()
+		}
+	case p.accept("OFFSET"):
+		stmt.limitFirst = stmt.limitFirst || stmt.slimit < 0 && stmt.soffset == 0
+		stmt.skip, err = p.integer()
+	case p.accept("SLIMIT"):
+		stmt.slimit, err = p.integer()
+	case p.accept("SOFFSET"):
+		stmt.soffset, err = p.integer()
+	case p.accept("TZ"):
+		err = p.skipParens()
+	default:
+		err = p.errorf("unsupported clause")
+	}
+
+	return err
+}
+
+func (p *parser) identList() ([]string, error) {
+	var names []string
+
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+
+		if !p.acceptOp(",") {
+			return names, nil
+		}
+	}
+}
+
+func (p *parser) parseGroupBy(stmt *selectStmt) error {
+	for {
+		if p.peek().is("time") && p.tokens[p.pos+1].isOp("(") {
+			p.next()
+
+			var err error
+			if stmt.window, stmt.offset, err = p.parseWindowArgs(); err != nil {
+				return err
+			}
+		} else {
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+
+			stmt.groupBy = append(stmt.groupBy, name)
+		}
+
+		if !p.acceptOp(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseWindowArgs() (time.Duration, time.Duration, error) {
+	if err := p.expectOp("("); err != nil {
+		return 0, 0, err
+	}
+
+	window, err := p.duration()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var offset time.Duration
+
+	if p.acceptOp(",") {
+		neg := p.acceptOp("-")
+		if offset, err = p.duration(); err != nil {
+			return 0, 0, err
+		}
+
+		if neg {
+			offset = -offset
+		}
+	}
+
+	return window, offset, p.expectOp(")")
+}
+
+func (p *parser) duration() (time.Duration, error) {
+	t := p.next()
+	if t.kind != durationToken {
+		return 0, p.errorf("expected duration, got %q", t.text)
+	}
+
+	return parseDuration(t.text)
+}
+
+func (p *parser) parseFill(stmt *selectStmt) error {
+	if err := p.expectOp("("); err != nil {
+		return err
+	}
+
+	t := p.next()
+
+	switch {
+	case t.kind == numberToken || t.isOp("-"):
+		e, err := p.valueAfter(t)
+		if err != nil {
+			return err
+		}
+
+		stmt.fill, stmt.fillValue = "value", e
+	case t.is("VALUE") || t.is("VALUE_F"):
+		if err := p.expectOp(","); err != nil {
+			return err
+		}
+
+		e, err := p.valueAfter(p.next())
+		if err != nil {
+			return err
+		}
+
+		stmt.fill, stmt.fillValue = "value", e
+
+		for p.acceptOp(",") {
+			p.next()
+		}
+	case t.kind == identToken:
+		stmt.fill = strings.ToLower(t.text)
+	default:
+		return p.errorf("invalid fill")
+	}
+
+	return p.expectOp(")")
+}
+
+// valueAfter 解析以 t 开头的数字，t 可以是负号。
+func (p *parser) valueAfter(t token) (interface{}, error) {
+	neg := t.isOp("-")
+	if neg {
+		t = p.next()
+	}
+
+	f, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", t.text)
+	}
+
+	if neg {
+		f = -f
+	}
+
+	return f, nil
+}
+
+func (p *parser) parseOrder(stmt *selectStmt) error {
+	for {
+		start := p.pos
+
+		e, err := p.parseExpr()
+		if err != nil {
+			return err
+		}
+
+		item := orderItem{name: p.text(start, p.pos)}
+		if c, ok := e.(colRef); ok {
+			item.name = c.name
+		}
+
+		if p.accept("DESC") {
+			item.desc = true
+		} else {
+			p.accept("ASC")
+		}
+
+		stmt.order = append(stmt.order, item)
+
+		if !p.acceptOp(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) skipParens() error {
+	if err := p.expectOp("("); err != nil {
+		return err
+	}
+
+	for depth := 1; depth > 0; {
+		t := p.next()
+
+		switch {
+		case t.kind == eofToken:
+			return p.errorf("unbalanced parentheses")
+		case t.isOp("("):
+			depth++
+		case t.isOp(")"):
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// text 返回 tokens[start:end] 对应的原始文本，用于没有别名时的列名。
+func (p *parser) text(start, end int) string {
+	var b strings.Builder
+
+	for i := start; i < end; i++ {
+		t := p.tokens[i]
+		if i > start && !t.isOp(")") && !t.isOp(",") && !t.isOp("(") && !p.tokens[i-1].isOp("(") {
+			b.WriteByte(' ')
+		}
+
+		if t.kind == stringToken {
+			b.WriteString("'" + t.text + "'")
+		} else {
+			b.WriteString(t.text)
+		}
+	}
+
+	return b.String()
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{op: "OR", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{op: "AND", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.accept("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return unary{op: "NOT", operand: e}, nil
+	}
+
+	return p.parseComparison()
+}
+
+var comparisonOps = map[string]struct{}{
+	"=": {}, "!=": {}, "<>": {}, "<": {}, "<=": {}, ">": {}, ">=": {}, "~": {}, "!~": {}, "~*": {}, "!~*": {}, "=~": {},
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+
+	switch {
+	case t.kind == opToken:
+		if _, ok := comparisonOps[t.text]; !ok {
+			return left, nil
+		}
+
+		p.next()
+
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+
+		return binary{op: t.text, left: left, right: right}, nil
+	case t.is("IN") || t.is("NOT") && p.tokens[p.pos+1].is("IN"):
+		not := p.accept("NOT")
+		p.next()
+
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		return inList{operand: left, list: list, not: not}, nil
+	case t.is("IS"):
+		p.next()
+		not := p.accept("NOT")
+
+		if !p.accept("NULL") {
+			return nil, p.errorf("expected NULL")
+		}
+
+		return isNull{operand: left, not: not}, nil
+	case t.is("BETWEEN"):
+		p.next()
+
+		lo, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.accept("AND") {
+			return nil, p.errorf("expected AND")
+		}
+
+		hi, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+
+		return binary{
+			op:    "AND",
+			left:  binary{op: ">=", left: left, right: lo},
+			right: binary{op: "<=", left: left, right: hi},
+		}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseList() ([]expr, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+
+	var list []expr
+
+	if p.acceptOp(")") {
+		return list, nil
+	}
+
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, e)
+
+		if p.acceptOp(")") {
+			return list, nil
+		}
+
+		if err = p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().isOp("+") || p.peek().isOp("-") {
+		op := p.next().text
+
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().isOp("*") || p.peek().isOp("/") || p.peek().isOp("%") {
+		op := p.next().text
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.acceptOp("-") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return unary{op: "-", operand: e}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	start := p.pos
+	t := p.next()
+
+	switch t.kind {
+	case numberToken:
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return literal{val: n}, nil
+		}
+
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", t.text)
+		}
+
+		return literal{val: f}, nil
+	case durationToken:
+		d, err := parseDuration(t.text)
+		if err != nil {
+			return nil, err
+		}
+
+		return literal{val: d}, nil
+	case stringToken:
+		return literal{val: t.text}, nil
+	case opToken:
+		switch t.text {
+		case "*":
+			return star{}, nil
+		case "(":
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+
+			return e, p.expectOp(")")
+		}
+	case identToken:
+		switch {
+		case t.is("NULL"):
+			return literal{}, nil
+		case t.is("TRUE"):
+			return literal{val: true}, nil
+		case t.is("FALSE"):
+			return literal{val: false}, nil
+		}
+
+		if !p.peek().isOp("(") {
+			return colRef{name: t.text}, nil
+		}
+
+		p.next()
+
+		c := call{name: strings.ToUpper(t.text)}
+
+		if !p.acceptOp(")") {
+			for {
+				arg, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+
+				c.args = append(c.args, arg)
+
+				if p.acceptOp(")") {
+					break
+				}
+
+				if err = p.expectOp(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		c.text = p.text(start, p.pos)
+
+		return c, nil
+	}
+
+	return nil, p.errorf("unexpected %q", t.text)
+}
+
+var durationUnits = map[string]time.Duration{
+	"b":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"us": time.Microsecond,
+	"a":  time.Millisecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	unit, ok := durationUnits[strings.ToLower(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("unsupported duration unit %q", s)
+	}
+
+	return time.Duration(n * float64(unit)), nil
+}
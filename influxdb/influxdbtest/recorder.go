@@ -0,0 +1,305 @@
+package influxdbtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Mode int8
+
+const (
+	// Replay 从录制文件返回响应，没有匹配的记录时请求失败，不访问网络。
+	Replay Mode = iota
+	// Record 将请求转发到真实服务并记录请求和响应，Stop 时写入录制文件。
+	Record
+)
+
+// Interaction 为录制文件中的一次请求和响应。
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest 为规范化后的请求，Body 为 SQL 或行协议。
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	Body   string `json:"body"`
+}
+
+type RecordedResponse struct {
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+	Status int         `json:"status"`
+}
+
+// Recorder 为录制/回放的 http.RoundTripper，设置为 Config.Transport 后 Query、Query2、Write 等请求都经过它：
+//
+//	rec, err := influxdbtest.NewRecorder("testdata/cpu.json", mode, nil)
+//	defer rec.Stop()
+//
+//	cfg.Transport = rec
+//
+// 请求按方法、接口和规范化的 SQL 匹配，SQL 中字符串以外的连续空白视为一个空格，括号和逗号两侧的空白被忽略，
+// 因此 SQLDialectOptions 的空白变化不影响回放。相同的请求按录制顺序依次返回，用完后重复返回最后一次的响应。
+type Recorder struct {
+	transport    http.RoundTripper
+	path         string
+	interactions []Interaction
+	used         []bool
+	mode         Mode
+	mu           sync.Mutex
+}
+
+// NewRecorder 创建 Recorder，transport 为 Record 模式下转发请求使用的 RoundTripper，为 nil 时使用 http.DefaultTransport。
+// Replay 模式下 path 必须存在。
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	r := &Recorder{path: path, mode: mode, transport: transport}
+
+	if mode == Record {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("influxdbtest: invalid cassette %s: %w", path, err)
+	}
+
+	r.used = make([]bool, len(r.interactions))
+
+	return r, nil
+}
+
+// Interactions 返回已录制或已加载的请求和响应。
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Stop 在 Record 模式下将录制的请求写入文件，Replay 模式下不做任何事。
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode != Record {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, body, err := normalizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == Record {
+		return r.record(req, recorded, body)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+
+	for j, it := range r.interactions {
+		if it.Request != recorded {
+			continue
+		}
+
+		match = j
+
+		if !r.used[j] {
+			break
+		}
+	}
+
+	if match < 0 {
+		return nil, fmt.Errorf("influxdbtest: no recorded interaction for %s %s %q", recorded.Method, recorded.Route, recorded.Body)
+	}
+
+	r.used[match] = true
+
+	return response(req, r.interactions[match].Response), nil
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest, body []byte) (*http.Response, error) {
+	// 请求体已被读取，转发时使用副本。
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	rr := RecordedResponse{Status: resp.StatusCode, Body: string(data)}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		rr.Header = http.Header{"Content-Type": {ct}}
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: recorded, Response: rr})
+	r.mu.Unlock()
+
+	return response(req, rr), nil
+}
+
+func response(req *http.Request, rr RecordedResponse) *http.Response {
+	header := rr.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(rr.Status) + " " + http.StatusText(rr.Status),
+		StatusCode:    rr.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(rr.Body)),
+		ContentLength: int64(len(rr.Body)),
+		Request:       req,
+	}
+}
+
+// normalizeRequest 返回用于匹配的请求和原始请求体，请求体按需解压。
+//
+// POST /rest/sql 的请求体、GET /rest/sql 路径中的查询和 /query 的 q 参数视为 SQL 并规范化，
+// 写入请求的行协议只去掉首尾空白。
+func normalizeRequest(req *http.Request) (RecordedRequest, []byte, error) {
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return RecordedRequest{}, nil, err
+		}
+
+		req.Body.Close()
+	}
+
+	payload := body
+
+	if req.Header.Get("Content-Encoding") == "gzip" && len(body) > 0 {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return RecordedRequest{}, nil, err
+		}
+
+		if payload, err = io.ReadAll(zr); err != nil {
+			return RecordedRequest{}, nil, err
+		}
+	}
+
+	rr := RecordedRequest{Method: req.Method, Route: req.URL.Path, Body: strings.TrimSpace(string(payload))}
+
+	switch p := req.URL.Path; {
+	case strings.HasPrefix(p, "/rest/sql/") && req.Method == http.MethodGet:
+		// InfluxDB.Query 将转义后的查询直接拼接在数据库名之后。
+		q, err := url.QueryUnescape(strings.TrimPrefix(req.URL.EscapedPath(), "/rest/sql/"))
+		if err != nil {
+			return RecordedRequest{}, nil, err
+		}
+
+		rr.Route, rr.Body = "/rest/sql", NormalizeSQL(q)
+	case strings.HasPrefix(p, "/rest/sql"):
+		rr.Route, rr.Body = "/rest/sql", NormalizeSQL(rr.Body)
+		if tz := req.URL.Query().Get("tz"); tz != "" {
+			rr.Route += "?tz=" + tz
+		}
+	case p == "/query":
+		rr.Body = NormalizeSQL(req.URL.Query().Get("q"))
+	}
+
+	return rr, body, nil
+}
+
+// NormalizeSQL 将字符串以外的连续空白替换为一个空格，去掉括号和逗号两侧及首尾的空白和末尾的分号。
+func NormalizeSQL(sql string) string {
+	var (
+		b     strings.Builder
+		quote byte
+		last  byte
+		space bool
+	)
+
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		if quote != 0 {
+			b.WriteByte(c)
+
+			switch {
+			case c == '\\' && i+1 < len(sql):
+				i++
+				b.WriteByte(sql[i])
+			case c == quote:
+				quote = 0
+			}
+
+			last = c
+
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			space = true
+			continue
+		case '(', ')', ',':
+			space = false
+		case '\'', '"', '`':
+			quote = c
+		}
+
+		if space && last != 0 && last != '(' && last != ',' {
+			b.WriteByte(' ')
+		}
+
+		space, last = false, c
+		b.WriteByte(c)
+	}
+
+	return strings.TrimSpace(b.String())
+}
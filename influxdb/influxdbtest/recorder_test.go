@@ -0,0 +1,247 @@
+package influxdbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{sql: "SELECT  *\n\tFROM cpu ;", want: "SELECT * FROM cpu"},
+		{sql: "SELECT SUM( value ) , host FROM cpu", want: "SELECT SUM(value),host FROM cpu"},
+		{sql: "SELECT SUM(value),host FROM cpu", want: "SELECT SUM(value),host FROM cpu"},
+		{sql: "SELECT * FROM cpu WHERE host = 'a  b'", want: "SELECT * FROM cpu WHERE host = 'a  b'"},
+		{sql: "SELECT * FROM cpu WHERE host = 'it\\'s  x'", want: "SELECT * FROM cpu WHERE host = 'it\\'s  x'"},
+		{sql: "SELECT \"a  b\" ,  `c  d` FROM cpu", want: "SELECT \"a  b\",`c  d` FROM cpu"},
+		{sql: "  ", want: ""},
+	}
+
+	for _, test := range tests {
+		if got := NormalizeSQL(test.sql); got != test.want {
+			t.Errorf("NormalizeSQL(%q) = %q; want %q", test.sql, got, test.want)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "testdata", "cpu.json")
+
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+
+	rec, err := NewRecorder(path, Record, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := srv.Config()
+	cfg.Transport = rec
+	cfg.Gzip = true
+
+	db, closeDB, err := influxdb.NewInfluxDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(closeDB)
+
+	if err = db.WriteContext(ctx, []influxdb.Writable{
+		cpu{ts: epoch, host: "a", value: "1"},
+		cpu{ts: epoch.Add(time.Minute), host: "b", value: "5"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []struct {
+		name  string
+		query func(db *influxdb.InfluxDB, sql string) (string, error)
+		sql   string
+		// replay 为回放时使用的 SQL，只有空白不同。
+		replay string
+		want   string
+		// fail 不为空时录制前让服务返回该错误，回放时应得到同样的错误。
+		fail string
+	}{
+		{
+			name:   "query2",
+			query:  query2,
+			sql:    "SELECT host, SUM(value) AS s FROM cpu GROUP BY host ORDER BY s DESC",
+			replay: "SELECT host,SUM( value )  AS s\nFROM cpu GROUP BY host ORDER BY s DESC;",
+			want:   "b=5;a=1",
+		},
+		{
+			name:   "influxql",
+			query:  query,
+			sql:    "SELECT sum(value) AS s FROM cpu GROUP BY host",
+			replay: "SELECT  sum(value) AS s  FROM cpu GROUP BY host",
+			want:   "a=1;b=5",
+		},
+		{
+			name:   "error",
+			query:  query2,
+			sql:    "SELECT host, SUM(value) AS s FROM cpu WHERE host = 'c'",
+			replay: "SELECT host, SUM(value) AS s FROM cpu WHERE host = 'c'",
+			fail:   "boom",
+		},
+	}
+
+	for _, q := range queries {
+		if q.fail != "" {
+			srv.FailNext(q.fail)
+		}
+
+		if got, err := q.query(db, q.sql); !sameResult(got, err, q.want, q.fail) {
+			t.Errorf("record %s: %s, %v; want %s", q.name, got, err, q.want)
+		}
+	}
+
+	if err = rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(path); err != nil {
+		t.Fatalf("cassette not written: %v", err)
+	}
+
+	if n := len(rec.Interactions()); n != 1+len(queries) {
+		t.Errorf("recorded %d interactions; want %d", n, 1+len(queries))
+	}
+
+	// 回放时服务已关闭，请求只能由录制文件返回。
+	srv.Close()
+
+	if rec, err = NewRecorder(path, Replay, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Transport = rec
+
+	db, closeReplay, err := influxdb.NewInfluxDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(closeReplay)
+
+	if err = db.WriteContext(ctx, []influxdb.Writable{
+		cpu{ts: epoch, host: "a", value: "1"},
+		cpu{ts: epoch.Add(time.Minute), host: "b", value: "5"},
+	}); err != nil {
+		t.Errorf("replay write: %v", err)
+	}
+
+	for _, q := range queries {
+		if got, err := q.query(db, q.replay); !sameResult(got, err, q.want, q.fail) {
+			t.Errorf("replay %s: %s, %v; want %s", q.name, got, err, q.want)
+		}
+	}
+
+	_, err = query2(db, "SELECT * FROM cpu")
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("unrecorded query error = %v; want no recorded interaction", err)
+	}
+}
+
+func TestRecorderReplayOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cpu.json")
+	sql := "SELECT COUNT(*) AS n FROM cpu"
+
+	cassette := fmt.Sprintf(`[
+  {"request": {"method": "POST", "route": "/rest/sql", "body": %[1]q},
+   "response": {"status": 200, "body": "{\"code\":0,\"column_meta\":[[\"n\",\"BIGINT\",8]],\"data\":[[1]],\"rows\":1}"}},
+  {"request": {"method": "POST", "route": "/rest/sql", "body": %[1]q},
+   "response": {"status": 200, "body": "{\"code\":0,\"column_meta\":[[\"n\",\"BIGINT\",8]],\"data\":[[2]],\"rows\":1}"}}
+]`, sql)
+
+	if err := os.WriteFile(path, []byte(cassette), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := NewRecorder(path, Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := influxdb.Config{Host: "http://127.0.0.1", Port: 1, Database: DefaultDatabase, Transport: rec}
+
+	db, closeDB, err := influxdb.NewInfluxDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(closeDB)
+
+	// 相同的请求按录制顺序返回，用完后重复最后一次的响应。
+	var got []string
+
+	for j := 0; j < 3; j++ {
+		var rows []map[string]any
+		if err = db.Query2(influxdb.WithoutCache(context.Background()), sql, &rows); err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, fmt.Sprint(rows[0]["n"]))
+	}
+
+	if strings.Join(got, ",") != "1,2,2" {
+		t.Errorf("replayed counts = %v; want 1,2,2", got)
+	}
+
+	if _, err = NewRecorder(filepath.Join(t.TempDir(), "missing.json"), Replay, nil); err == nil {
+		t.Error("NewRecorder with a missing cassette: want error")
+	}
+
+	if err = os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewRecorder(path, Replay, nil); err == nil || !strings.Contains(err.Error(), "invalid cassette") {
+		t.Errorf("NewRecorder with a broken cassette error = %v; want invalid cassette", err)
+	}
+}
+
+func query2(db *influxdb.InfluxDB, sql string) (string, error) {
+	var rows []map[string]any
+	if err := db.Query2(context.Background(), sql, &rows); err != nil {
+		return "", err
+	}
+
+	return hostSums(rows), nil
+}
+
+func query(db *influxdb.InfluxDB, sql string) (string, error) {
+	var rows []map[string]any
+	if err := db.Query(context.Background(), sql, &rows); err != nil {
+		return "", err
+	}
+
+	return hostSums(rows), nil
+}
+
+func sameResult(got string, err error, want, wantErr string) bool {
+	if wantErr != "" {
+		return err != nil && strings.Contains(err.Error(), wantErr)
+	}
+
+	return err == nil && got == want
+}
+
+func hostSums(rows []map[string]any) string {
+	got := make([]string, 0, len(rows))
+	for _, r := range rows {
+		got = append(got, fmt.Sprintf("%v=%v", r["host"], r["s"]))
+	}
+
+	return strings.Join(got, ";")
+}
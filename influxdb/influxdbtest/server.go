@@ -0,0 +1,439 @@
+// Package influxdbtest 提供基于 httptest 的内存 TDengine/InfluxDB 模拟服务，用于测试 influxdb 包的调用方。
+//
+// Server 实现行协议写入接口 /influxdb/v1/write、TDengine 的 /rest/sql 接口和 InfluxQL 查询接口，
+// 支持构建器生成的 SELECT、WHERE、GROUP BY time()/INTERVAL 窗口、常用聚合函数、FILL、schema 查询、
+// DELETE 和 DDL，并记录收到的行协议和语句供测试断言：
+//
+//	srv := influxdbtest.NewServer()
+//	defer srv.Close()
+//
+//	db, closeFn, _ := influxdb.NewInfluxDB(srv.Config())
+//	defer closeFn()
+//
+//	_ = db.Write(points)
+//	lines := srv.Lines()
+package influxdbtest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+// DefaultDatabase 为 NewServer 使用的数据库名。
+const DefaultDatabase = "test"
+
+// Server 为内存模拟服务，所有方法可并发调用。
+type Server struct {
+	*httptest.Server
+	store    *store
+	now      func() time.Time
+	failNext *string
+	database string
+	lines    []string
+	queries  []string
+	mu       sync.Mutex
+}
+
+// NewServer 启动模拟服务，使用完毕后需要调用 Close。
+func NewServer() *Server {
+	s := &Server{store: newStore(), database: DefaultDatabase, now: time.Now}
+	s.store.databases[s.database] = struct{}{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/influxdb/v1/write", s.handleWrite)
+	mux.HandleFunc("/write", s.handleWrite)
+	mux.HandleFunc("/query", s.handleInfluxQuery)
+	mux.HandleFunc("/rest/sql/", s.handleSQL)
+	mux.HandleFunc("/-/ping", s.handlePing)
+	mux.HandleFunc("/ping", s.handlePing)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Config 返回连接到该服务的配置，可在返回值上继续修改其他选项。
+func (s *Server) Config() influxdb.Config {
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(s.URL, "http://"))
+	p, _ := strconv.Atoi(port)
+
+	return influxdb.Config{Host: "http://" + host, Port: p, Database: s.database, WriteDatabase: s.database}
+}
+
+// SetNow 设置 now() 和不带时间戳的行协议使用的当前时间。
+func (s *Server) SetNow(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = func() time.Time { return now }
+}
+
+// Lines 返回收到的所有行协议，每个元素为一行。
+func (s *Server) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.lines...)
+}
+
+// Queries 返回收到的所有语句，包括查询、DELETE 和 DDL。
+func (s *Server) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.queries...)
+}
+
+// LastQuery 返回最后收到的语句，没有时返回空字符串。
+func (s *Server) LastQuery() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queries) == 0 {
+		return ""
+	}
+
+	return s.queries[len(s.queries)-1]
+}
+
+// Insert 直接向存储写入数据点，不记录为收到的行协议。
+func (s *Server) Insert(points ...Point) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range points {
+		if p.Tags == nil {
+			p.Tags = map[string]string{}
+		}
+
+		s.store.insert(p)
+	}
+}
+
+// Points 返回 measurement 中的所有数据点，按写入顺序排列。
+func (s *Server) Points(measurement string) []Point {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Point(nil), s.store.points[measurement]...)
+}
+
+// FailNext 使下一个请求（写入、查询或执行）失败并返回 msg。
+func (s *Server) FailNext(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failNext = &msg
+}
+
+// Reset 清空数据、表结构和记录的请求。
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = newStore()
+	s.store.databases[s.database] = struct{}{}
+	s.lines, s.queries, s.failNext = nil, nil, nil
+}
+
+// takeFailure 返回并清除 FailNext 设置的错误，调用方需持有锁。
+func (s *Server) takeFailure() (string, bool) {
+	if s.failNext == nil {
+		return "", false
+	}
+
+	msg := *s.failNext
+	s.failNext = nil
+
+	return msg, true
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleWrite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	precision, ok := precisions[r.URL.Query().Get("precision")]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid precision"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if db := r.URL.Query().Get("db"); db != "" {
+		if _, ok := s.store.databases[db]; !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "database not found: " + db})
+			return
+		}
+	}
+
+	if msg, ok := s.takeFailure(); ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": msg})
+		return
+	}
+
+	var points []Point
+
+	sc := bufio.NewScanner(strings.NewReader(string(body)))
+	sc.Buffer(nil, len(body)+1)
+
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p, err := parseLine(line, precision, s.now())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		s.lines = append(s.lines, line)
+		points = append(points, p)
+	}
+
+	for _, p := range points {
+		s.store.insert(p)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSQL 处理 POST /rest/sql/{db}（TDengine）和 GET /rest/sql/{db}{query}（InfluxDB.Query 使用的 InfluxQL 查询）。
+func (s *Server) handleSQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		rest := strings.TrimPrefix(r.URL.EscapedPath(), "/rest/sql/")
+		rest = strings.TrimPrefix(rest, url.PathEscape(s.database))
+
+		query, err := url.QueryUnescape(rest)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		s.influxQuery(w, query)
+
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, influxdb.TDResponse{Code: 1, Desc: err.Error()})
+		return
+	}
+
+	loc := time.UTC
+
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			writeJSON(w, http.StatusBadRequest, influxdb.TDResponse{Code: 1, Desc: err.Error()})
+			return
+		}
+	}
+
+	query := strings.TrimSpace(string(body))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries = append(s.queries, query)
+
+	if msg, ok := s.takeFailure(); ok {
+		writeJSON(w, http.StatusOK, influxdb.TDResponse{Code: 1, Desc: msg})
+		return
+	}
+
+	e := &executor{store: s.store, now: s.now(), database: s.database}
+
+	res, err := e.execute(query)
+	if err != nil {
+		writeJSON(w, http.StatusOK, influxdb.TDResponse{Code: 1, Desc: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tdResponse(res, loc))
+}
+
+// handleInfluxQuery 处理 InfluxDB 1.x 的 /query?q= 接口。
+func (s *Server) handleInfluxQuery(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" && r.Method == http.MethodPost {
+		_ = r.ParseForm()
+		q = r.PostForm.Get("q")
+	}
+
+	s.influxQuery(w, q)
+}
+
+func (s *Server) influxQuery(w http.ResponseWriter, query string) {
+	query = strings.TrimSpace(query)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries = append(s.queries, query)
+
+	if msg, ok := s.takeFailure(); ok {
+		writeJSON(w, http.StatusOK, influxResult{Results: []influxStatement{{Error: msg}}})
+		return
+	}
+
+	e := &executor{store: s.store, now: s.now(), database: s.database, influx: true}
+
+	res, err := e.execute(query)
+	if err != nil {
+		writeJSON(w, http.StatusOK, influxResult{Results: []influxStatement{{Error: err.Error()}}})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, influxResponse(res))
+}
+
+type influxStatement struct {
+	Error       string            `json:"error,omitempty"`
+	Series      []influxdb.Series `json:"series,omitempty"`
+	StatementID int               `json:"statement_id"`
+}
+
+type influxResult struct {
+	Results []influxStatement `json:"results"`
+}
+
+func influxResponse(res *resultSet) influxResult {
+	stmt := influxStatement{}
+
+	for _, s := range res.series {
+		if len(s.rows) == 0 {
+			continue
+		}
+
+		out := influxdb.Series{Name: s.name, Tags: s.tags, Columns: res.columns}
+
+		for _, r := range s.rows {
+			values := make([]interface{}, len(r))
+			for j, v := range r {
+				values[j] = jsonValue(v, time.UTC, time.Nanosecond)
+			}
+
+			out.Values = append(out.Values, values)
+		}
+
+		stmt.Series = append(stmt.Series, out)
+	}
+
+	return influxResult{Results: []influxStatement{stmt}}
+}
+
+func tdResponse(res *resultSet, loc *time.Location) influxdb.TDResponse {
+	if res.exec {
+		return influxdb.TDResponse{
+			ColumnMeta: [][3]interface{}{{"affected_rows", "INT", 4}},
+			Data:       [][]interface{}{{res.affected}},
+			Rows:       1,
+		}
+	}
+
+	out := influxdb.TDResponse{Data: [][]interface{}{}}
+
+	for _, s := range res.series {
+		for _, r := range s.rows {
+			values := make([]interface{}, len(r))
+			for j, v := range r {
+				values[j] = jsonValue(v, loc, time.Millisecond)
+			}
+
+			out.Data = append(out.Data, values)
+		}
+	}
+
+	for j, name := range res.columns {
+		meta := [3]interface{}{name, "VARCHAR", 64}
+
+		if v := firstValue(res, j); v != nil && tdType(v) != "VARCHAR" {
+			meta[1], meta[2] = tdType(v), 8
+		}
+
+		out.ColumnMeta = append(out.ColumnMeta, meta)
+	}
+
+	out.Rows = len(out.Data)
+
+	return out
+}
+
+// firstValue 返回第 col 列第一个非空的值。
+func firstValue(res *resultSet, col int) interface{} {
+	for _, s := range res.series {
+		for _, r := range s.rows {
+			if r[col] != nil {
+				return r[col]
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonValue 将时间格式化为 loc 时区的 RFC3339，时长转换为 unit 的整数倍。
+func jsonValue(v interface{}, loc *time.Location, unit time.Duration) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return v.In(loc).Format(time.RFC3339Nano)
+	case time.Duration:
+		return int64(v / unit)
+	}
+
+	return v
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	body := io.Reader(r.Body)
+
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		body = zr
+	}
+
+	return io.ReadAll(body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Fprintln(w, err)
+	}
+}
@@ -0,0 +1,123 @@
+package influxdbtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+type cpu struct {
+	ts    time.Time
+	host  string
+	value string
+}
+
+func (c cpu) Measurement() string { return "cpu" }
+func (c cpu) Tags() []byte        { return []byte("host=" + c.host) }
+func (c cpu) Fields() []byte      { return []byte("value=" + c.value) }
+func (c cpu) Timestamp() int64    { return c.ts.Unix() }
+
+func newTestDB(t *testing.T) (*influxdb.InfluxDB, *Server) {
+	t.Helper()
+
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+
+	db, closeDB, err := influxdb.NewInfluxDB(srv.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(closeDB)
+
+	return db, srv
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	db, srv := newTestDB(t)
+
+	err := db.WriteContext(ctx, []influxdb.Writable{
+		cpu{ts: epoch, host: "a", value: "1"},
+		cpu{ts: epoch.Add(time.Minute), host: "a", value: "2"},
+		cpu{ts: epoch.Add(time.Minute), host: "b", value: "5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(srv.Points("cpu")); got != 3 {
+		t.Fatalf("Points = %d; want 3", got)
+	}
+
+	tests := []struct {
+		query  func() ([]map[string]any, error)
+		name   string
+		result string
+	}{
+		{
+			name: "query2",
+			query: func() ([]map[string]any, error) {
+				var rows []map[string]any
+				err := db.Query2(ctx, "SELECT host, SUM(value) AS s FROM cpu GROUP BY host ORDER BY s DESC", &rows)
+
+				return rows, err
+			},
+			result: "b=5;a=3",
+		},
+		{
+			name: "influxql",
+			query: func() ([]map[string]any, error) {
+				var rows []map[string]any
+				err := db.Query(ctx, "SELECT sum(value) AS s FROM cpu GROUP BY host", &rows)
+
+				return rows, err
+			},
+			result: "a=3;b=5",
+		},
+	}
+
+	for _, test := range tests {
+		rows, err := test.query()
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		var got []string
+
+		for _, r := range rows {
+			got = append(got, fmt.Sprintf("%v=%v", r["host"], r["s"]))
+		}
+
+		if strings.Join(got, ";") != test.result {
+			t.Errorf("%s: rows = %v; want %s", test.name, rows, test.result)
+		}
+	}
+
+	n, err := db.Exec(ctx, "DELETE FROM cpu WHERE host = 'a'")
+	if err != nil || n != 2 {
+		t.Errorf("Exec(DELETE) = %d, %v; want 2, nil", n, err)
+	}
+
+	srv.FailNext("boom")
+
+	var rows []map[string]any
+	if err = db.Query2(ctx, "SELECT * FROM cpu", &rows); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Query2 after FailNext error = %v; want boom", err)
+	}
+
+	if err = db.Query2(ctx, "SELECT * FROM cpu WHERE host = 'a'", &rows); !errors.Is(err, influxdb.ErrNoData) {
+		t.Errorf("Query2 of deleted rows error = %v; want ErrNoData", err)
+	}
+
+	if q := srv.LastQuery(); q != "SELECT * FROM cpu WHERE host = 'a'" {
+		t.Errorf("LastQuery = %q", q)
+	}
+}
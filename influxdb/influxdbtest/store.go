@@ -0,0 +1,296 @@
+package influxdbtest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Point 为存储在内存中的一个数据点。
+type Point struct {
+	Time        time.Time
+	Tags        map[string]string
+	Fields      map[string]interface{}
+	Measurement string
+}
+
+// column 为通过 CREATE STABLE 或 ALTER STABLE 声明的列。
+type column struct {
+	name string
+	typ  string
+	tag  bool
+}
+
+type store struct {
+	points    map[string][]Point
+	schemas   map[string][]column
+	databases map[string]struct{}
+	streams   map[string]string
+	cqs       map[string]string
+}
+
+func newStore() *store {
+	return &store{
+		points:    map[string][]Point{},
+		schemas:   map[string][]column{},
+		databases: map[string]struct{}{},
+		streams:   map[string]string{},
+		cqs:       map[string]string{},
+	}
+}
+
+func (s *store) insert(p Point) {
+	s.points[p.Measurement] = append(s.points[p.Measurement], p)
+}
+
+// measurements 返回有数据或声明了结构的 measurement，按名称排序。
+func (s *store) measurements() []string {
+	names := map[string]struct{}{}
+	for name := range s.points {
+		names[name] = struct{}{}
+	}
+
+	for name := range s.schemas {
+		names[name] = struct{}{}
+	}
+
+	return sortedKeys(names)
+}
+
+// columns 返回 measurement 的列，优先使用声明的结构，否则从数据推断，时间列在最前面。
+func (s *store) columns(measurement string) []column {
+	if cols, ok := s.schemas[measurement]; ok {
+		return cols
+	}
+
+	fields := map[string]string{}
+	tags := map[string]struct{}{}
+
+	for _, p := range s.points[measurement] {
+		for k, v := range p.Fields {
+			if _, ok := fields[k]; !ok {
+				fields[k] = tdType(v)
+			}
+		}
+
+		for k := range p.Tags {
+			tags[k] = struct{}{}
+		}
+	}
+
+	if len(fields) == 0 && len(tags) == 0 {
+		return nil
+	}
+
+	cols := []column{{name: "ts", typ: "TIMESTAMP"}}
+	for _, k := range sortedKeys(fields) {
+		cols = append(cols, column{name: k, typ: fields[k]})
+	}
+
+	for _, k := range sortedKeys(tags) {
+		cols = append(cols, column{name: k, typ: "VARCHAR", tag: true})
+	}
+
+	return cols
+}
+
+func (s *store) drop(measurement string) {
+	delete(s.points, measurement)
+	delete(s.schemas, measurement)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func tdType(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "DOUBLE"
+	case int64, time.Duration:
+		return "BIGINT"
+	case bool:
+		return "BOOL"
+	case time.Time:
+		return "TIMESTAMP"
+	}
+
+	return "VARCHAR"
+}
+
+func influxType(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "float"
+	case int64:
+		return "integer"
+	case bool:
+		return "boolean"
+	}
+
+	return "string"
+}
+
+var precisions = map[string]time.Duration{
+	"":   time.Nanosecond,
+	"n":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// parseLine 解析一行行协议：measurement[,tag=value...] field=value[,field=value...] [timestamp]。
+func parseLine(line string, precision time.Duration, now time.Time) (Point, error) {
+	parts := splitUnescaped(line, ' ')
+	if len(parts) < 2 || len(parts) > 3 {
+		return Point{}, fmt.Errorf("invalid line protocol: %q", line)
+	}
+
+	p := Point{Tags: map[string]string{}, Fields: map[string]interface{}{}, Time: now}
+
+	keys := splitUnescaped(parts[0], ',')
+	p.Measurement = unescape(keys[0])
+
+	if p.Measurement == "" {
+		return Point{}, fmt.Errorf("missing measurement: %q", line)
+	}
+
+	for _, kv := range keys[1:] {
+		if kv == "" {
+			continue
+		}
+
+		k, v, ok := cutUnescaped(kv, '=')
+		if !ok {
+			return Point{}, fmt.Errorf("invalid tag %q", kv)
+		}
+
+		p.Tags[unescape(k)] = unescape(v)
+	}
+
+	for _, kv := range splitUnescaped(parts[1], ',') {
+		k, v, ok := cutUnescaped(kv, '=')
+		if !ok {
+			return Point{}, fmt.Errorf("invalid field %q", kv)
+		}
+
+		val, err := parseFieldValue(v)
+		if err != nil {
+			return Point{}, err
+		}
+
+		p.Fields[unescape(k)] = val
+	}
+
+	if len(p.Fields) == 0 {
+		return Point{}, fmt.Errorf("missing fields: %q", line)
+	}
+
+	if len(parts) == 3 {
+		ts, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid timestamp %q", parts[2])
+		}
+
+		p.Time = time.Unix(0, ts*int64(precision)).UTC()
+	}
+
+	return p, nil
+}
+
+func parseFieldValue(v string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		if len(v) < 2 || !strings.HasSuffix(v, `"`) {
+			return nil, fmt.Errorf("invalid string field %q", v)
+		}
+
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(v[1 : len(v)-1]), nil
+	case strings.HasSuffix(v, "i"):
+		return strconv.ParseInt(v[:len(v)-1], 10, 64)
+	case strings.HasSuffix(v, "u"):
+		n, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+		return int64(n), err
+	}
+
+	switch strings.ToLower(v) {
+	case "t", "true":
+		return true, nil
+	case "f", "false":
+		return false, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, errors.New("invalid field value " + v)
+	}
+
+	return f, nil
+}
+
+// splitUnescaped 按 sep 切分，忽略反斜杠转义和双引号内的 sep。
+func splitUnescaped(s string, sep byte) []string {
+	var (
+		parts   []string
+		start   int
+		escaped bool
+		quoted  bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func cutUnescaped(s string, sep byte) (string, string, bool) {
+	parts := splitUnescaped(s, sep)
+	if len(parts) < 2 {
+		return s, "", false
+	}
+
+	return parts[0], s[len(parts[0])+1:], true
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
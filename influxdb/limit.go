@@ -0,0 +1,164 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// defaultQueryRateLimit 为未配置时查询的限流，写入和删除默认不限流。
+var defaultQueryRateLimit = RateLimit{Rate: 150, Burst: 1}
+
+// RateLimit 为限流配置，Rate 为每秒产生的令牌数，Burst 为令牌桶容量。
+type RateLimit struct {
+	Rate Limit
+	// Burst 同时是单次操作最多消耗的令牌数，WithCost、SpanCost 和 WritePointsPerToken 计算的令牌数
+	// 超过 Burst 时按 Burst 计算。加权限流时 Burst 应不小于最大的权重，默认查询限流的 Burst 为 1，
+	// 此时所有查询的权重都为 1。
+	Burst int
+}
+
+func (rl *RateLimit) limiter() *Limiter {
+	if rl == nil {
+		return nil
+	}
+
+	return NewLimiter(rl.Rate, rl.Burst)
+}
+
+// Limiters 为查询和写入（包括删除）使用的限流器，同一个 Limiters 可以通过 Config.Limiters
+// 在多个 InfluxDB 之间共享。它实现了 expvar.Var，输出等待次数和累计等待时间。
+type Limiters struct {
+	Query *Limiter
+	Write *Limiter
+
+	queryWaits atomic.Int64
+	queryWait  atomic.Int64
+	writeWaits atomic.Int64
+	writeWait  atomic.Int64
+}
+
+// NewLimiters 创建限流器，query 或 write 为 nil 时对应操作不限流。
+func NewLimiters(query, write *RateLimit) *Limiters {
+	return &Limiters{Query: query.limiter(), Write: write.limiter()}
+}
+
+// QueryWait 返回查询累计的等待时间。
+func (l *Limiters) QueryWait() time.Duration {
+	return time.Duration(l.queryWait.Load())
+}
+
+// WriteWait 返回写入和删除累计的等待时间。
+func (l *Limiters) WriteWait() time.Duration {
+	return time.Duration(l.writeWait.Load())
+}
+
+// String 以 JSON 输出等待次数和等待时间，耗时单位为纳秒。
+func (l *Limiters) String() string {
+	b, _ := json.Marshal(map[string]int64{
+		"query_waits": l.queryWaits.Load(),
+		"query_wait":  l.queryWait.Load(),
+		"write_waits": l.writeWaits.Load(),
+		"write_wait":  l.writeWait.Load(),
+	})
+
+	return string(b)
+}
+
+func newLimiters(cfg Config) *Limiters {
+	if cfg.Limiters != nil {
+		return cfg.Limiters
+	}
+
+	query := cfg.QueryRateLimit
+	if query == nil {
+		query = &defaultQueryRateLimit
+	}
+
+	return NewLimiters(query, cfg.WriteRateLimit)
+}
+
+type costKey struct{}
+
+// WithCost 指定本次操作消耗的令牌数，超过限流器的 Burst 时按 Burst 计算，见 RateLimit.Burst。
+func WithCost(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, costKey{}, n)
+}
+
+// SpanCost 按查询的时间跨度计算令牌数，每 perToken 消耗一个令牌，至少为 1。
+func SpanCost(start, end time.Time, perToken time.Duration) int {
+	if perToken <= 0 {
+		return 1
+	}
+
+	return ceilCost(float64(end.Sub(start)) / float64(perToken))
+}
+
+func ceilCost(f float64) int {
+	if f <= 1 {
+		return 1
+	}
+
+	if f >= math.MaxInt32 {
+		return math.MaxInt32
+	}
+
+	return int(math.Ceil(f))
+}
+
+// writeCost 按数据点数计算写入消耗的令牌数，未配置 WritePointsPerToken 时为 1。
+func (i *InfluxDB) writeCost(points int) int {
+	if i.writePointsPerToken <= 0 {
+		return 1
+	}
+
+	return ceilCost(float64(points) / float64(i.writePointsPerToken))
+}
+
+// waitN 等待 lim 中的 n 个令牌，ctx 中通过 WithCost 指定的令牌数优先，n 超过 Burst 时按 Burst 计算。
+func waitN(ctx context.Context, lim *Limiter, n int, waits, total *atomic.Int64) error {
+	if lim == nil {
+		return nil
+	}
+
+	if cost, ok := ctx.Value(costKey{}).(int); ok && cost > 0 {
+		n = cost
+	}
+
+	if burst := lim.Burst(); lim.Limit() != Inf && n > burst {
+		n = burst
+	}
+
+	start := time.Now()
+	delay, err := lim.waitN(ctx, n)
+	d := time.Since(start)
+
+	// 只统计需要等待令牌的调用，立即拿到令牌的不算作等待。
+	if delay > 0 {
+		waits.Add(1)
+	}
+
+	total.Add(int64(d))
+
+	if info, ok := ctx.Value(opInfoKey{}).(*OpInfo); ok {
+		info.LimiterWait += d
+	}
+
+	return err
+}
+
+// wait 等待查询限流器并记录等待时间。
+func (i *InfluxDB) wait(ctx context.Context) error {
+	l := i.limiters
+
+	return waitN(ctx, l.Query, 1, &l.queryWaits, &l.queryWait)
+}
+
+// waitWrite 等待写入限流器并记录等待时间，删除也使用写入限流器。
+func (i *InfluxDB) waitWrite(ctx context.Context, n int) error {
+	l := i.limiters
+
+	return waitN(ctx, l.Write, n, &l.writeWaits, &l.writeWait)
+}
@@ -0,0 +1,172 @@
+package influxdb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCosts(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"span one token", SpanCost(time.Time{}, time.Time{}.Add(time.Hour), time.Hour), 1},
+		{"span rounds up", SpanCost(time.Time{}, time.Time{}.Add(25*time.Hour+30*time.Minute), time.Hour), 26},
+		{"span empty", SpanCost(time.Time{}, time.Time{}, time.Hour), 1},
+		{"span without unit", SpanCost(time.Time{}, time.Time{}.Add(time.Hour), 0), 1},
+		{"write without points per token", (&InfluxDB{}).writeCost(5000), 1},
+		{"write rounds up", (&InfluxDB{writePointsPerToken: 1000}).writeCost(5001), 6},
+		{"write small batch", (&InfluxDB{writePointsPerToken: 1000}).writeCost(1), 1},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: cost = %d; want %d", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestWaitN(t *testing.T) {
+	tests := []struct {
+		name  string
+		burst int
+		cost  int
+		n     int
+		// left 为等待后剩余的令牌数。
+		left int
+	}{
+		{name: "weighted", burst: 10, n: 3, left: 7},
+		{name: "cost from context", burst: 10, cost: 4, n: 1, left: 6},
+		{name: "clamped to burst", burst: 2, n: 10, left: 0},
+		{name: "default burst ignores weight", burst: 1, n: 5, left: 0},
+	}
+
+	for _, test := range tests {
+		// 令牌几乎不再产生，剩余令牌数只取决于消耗。
+		lim := NewLimiter(0.001, test.burst)
+
+		ctx := context.Background()
+		if test.cost > 0 {
+			ctx = WithCost(ctx, test.cost)
+		}
+
+		info := &OpInfo{}
+		ctx = context.WithValue(ctx, opInfoKey{}, info)
+
+		var waits, total atomic.Int64
+
+		if err := waitN(ctx, lim, test.n, &waits, &total); err != nil {
+			t.Errorf("%s: waitN error: %v", test.name, err)
+
+			continue
+		}
+
+		now := time.Now()
+		if test.left > 0 && !lim.AllowN(now, test.left) || lim.AllowN(now, 1) {
+			t.Errorf("%s: unexpected tokens left; want %d", test.name, test.left)
+		}
+
+		// 令牌充足时不需要等待，不计入等待次数。
+		if waits.Load() != 0 || total.Load() != int64(info.LimiterWait) {
+			t.Errorf("%s: waits = %d, total = %d, info = %v", test.name, waits.Load(), total.Load(), info.LimiterWait)
+		}
+	}
+}
+
+func TestWaitNCountsDelays(t *testing.T) {
+	lim := NewLimiter(100, 1)
+
+	var waits, total atomic.Int64
+
+	for i, want := range []int64{0, 1, 2} {
+		if err := waitN(context.Background(), lim, 1, &waits, &total); err != nil {
+			t.Fatalf("waitN #%d error: %v", i, err)
+		}
+
+		if waits.Load() != want {
+			t.Errorf("after call #%d waits = %d; want %d", i, waits.Load(), want)
+		}
+	}
+
+	if total.Load() <= 0 {
+		t.Errorf("total = %d; want the delayed calls recorded", total.Load())
+	}
+}
+
+func TestWaitNUnlimited(t *testing.T) {
+	var waits, total atomic.Int64
+
+	if err := waitN(context.Background(), nil, 100, &waits, &total); err != nil || waits.Load() != 0 {
+		t.Errorf("waitN(nil) = %v, waits %d; want no wait", err, waits.Load())
+	}
+
+	if err := waitN(context.Background(), NewLimiter(Inf, 0), 100, &waits, &total); err != nil || waits.Load() != 0 {
+		t.Errorf("waitN(Inf) = %v, waits %d; want no wait", err, waits.Load())
+	}
+}
+
+func TestWaitNCancelled(t *testing.T) {
+	lim := NewLimiter(0.001, 1)
+	lim.AllowN(time.Now(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var waits, total atomic.Int64
+
+	if err := waitN(ctx, lim, 1, &waits, &total); err == nil {
+		t.Error("waitN on an empty bucket succeeded; want the deadline error")
+	}
+}
+
+func TestNewLimiters(t *testing.T) {
+	shared := NewLimiters(nil, &RateLimit{Rate: 10, Burst: 5})
+
+	tests := []struct {
+		name  string
+		cfg   Config
+		query [2]float64
+		write [2]float64
+		same  *Limiters
+	}{
+		{name: "defaults", query: [2]float64{150, 1}},
+		{
+			name:  "configured",
+			cfg:   Config{QueryRateLimit: &RateLimit{Rate: 20, Burst: 4}, WriteRateLimit: &RateLimit{Rate: 5, Burst: 100}},
+			query: [2]float64{20, 4},
+			write: [2]float64{5, 100},
+		},
+		{name: "shared", cfg: Config{Limiters: shared, QueryRateLimit: &RateLimit{Rate: 1, Burst: 1}}, same: shared},
+	}
+
+	for _, test := range tests {
+		l := newLimiters(test.cfg)
+
+		if test.same != nil {
+			if l != test.same {
+				t.Errorf("%s: newLimiters did not use Config.Limiters", test.name)
+			}
+
+			continue
+		}
+
+		if got := limits(l.Query); got != test.query {
+			t.Errorf("%s: query limiter = %v; want %v", test.name, got, test.query)
+		}
+
+		if got := limits(l.Write); got != test.write {
+			t.Errorf("%s: write limiter = %v; want %v", test.name, got, test.write)
+		}
+	}
+}
+
+func limits(lim *Limiter) [2]float64 {
+	if lim == nil {
+		return [2]float64{}
+	}
+
+	return [2]float64{float64(lim.Limit()), float64(lim.Burst())}
+}
@@ -0,0 +1,339 @@
+package influxdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultTimeColumns 为未指定时间列时识别的列名，不区分大小写。
+var defaultTimeColumns = []string{"ts", "_ts", "time"}
+
+// Severity 为 Lint 问题的级别。
+type Severity int8
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// LintIssue 为 Lint 发现的一个问题。
+type LintIssue struct {
+	Rule     string
+	Message  string
+	Severity Severity
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Rule, i.Message)
+}
+
+// LintIssues 为 Lint 的检查结果。
+type LintIssues []LintIssue
+
+// Err 将 SeverityError 的问题合并为一个错误，没有时返回 nil。
+func (issues LintIssues) Err() error {
+	var errs []error
+
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			errs = append(errs, errors.New(i.String()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// LintRule 为一条检查规则，Check 返回问题描述，没有问题时返回空字符串。
+type LintRule struct {
+	Check    func(sc SelectClauses) string
+	Name     string
+	Severity Severity
+}
+
+const (
+	MissingTimeBoundRule        = "missing-time-bound"
+	UnboundedRawQueryRule       = "unbounded-raw-query"
+	GroupByWithoutAggregateRule = "group-by-without-aggregate"
+	FillWithoutWindowRule       = "fill-without-window"
+)
+
+// DefaultLintRules 返回默认规则，可以修改 Severity、删除或追加规则后传给 Lint。timeCols 为时间列名，
+// 为空时识别 ts、_ts 和 time：
+//   - missing-time-bound（warning）：WHERE 没有时间列的上界或下界；
+//   - unbounded-raw-query（error）：不聚合、没有窗口、没有 LIMIT，且 WHERE 没有时间范围的原始查询，在超级表上会扫描全部数据；
+//   - group-by-without-aggregate（warning）：有 GROUP BY 或窗口但没有聚合函数；
+//   - fill-without-window（error）：有 FILL 但没有窗口。
+func DefaultLintRules(timeCols ...string) []LintRule {
+	if len(timeCols) == 0 {
+		timeCols = defaultTimeColumns
+	}
+
+	return []LintRule{
+		{Name: MissingTimeBoundRule, Severity: SeverityWarning, Check: func(sc SelectClauses) string {
+			return checkTimeBound(sc, timeCols)
+		}},
+		{Name: UnboundedRawQueryRule, Severity: SeverityError, Check: func(sc SelectClauses) string {
+			return checkUnboundedRawQuery(sc, timeCols)
+		}},
+		{Name: GroupByWithoutAggregateRule, Severity: SeverityWarning, Check: checkGroupByAggregate},
+		{Name: FillWithoutWindowRule, Severity: SeverityError, Check: checkFillWindow},
+	}
+}
+
+// Lint 使用 rules 检查 qb，rules 为空时使用 DefaultLintRules()，不会回收 qb。
+func Lint(qb *QueryBuilder, rules ...LintRule) LintIssues {
+	if len(rules) == 0 {
+		rules = DefaultLintRules()
+	}
+
+	var issues LintIssues
+
+	for _, r := range rules {
+		if msg := r.Check(qb.clauses); msg != "" {
+			issues = append(issues, LintIssue{Rule: r.Name, Severity: r.Severity, Message: msg})
+		}
+	}
+
+	return issues
+}
+
+func checkTimeBound(sc SelectClauses, timeCols []string) string {
+	if b := whereTimeBounds(sc.Where(), timeCols, nil); b.start == nil && b.end == nil {
+		return "no time predicate in WHERE"
+	}
+
+	return ""
+}
+
+func checkUnboundedRawQuery(sc SelectClauses, timeCols []string) string {
+	if sc.Window() != nil || sc.Limit() != nil || hasAggregate(sc) {
+		return ""
+	}
+
+	if b := whereTimeBounds(sc.Where(), timeCols, nil); b.start != nil && b.end != nil {
+		return ""
+	}
+
+	return "raw query without time range or LIMIT"
+}
+
+func checkGroupByAggregate(sc SelectClauses) string {
+	if (sc.GroupBy() == nil || sc.GroupBy().IsEmpty()) && sc.Window() == nil {
+		return ""
+	}
+
+	if !hasAggregate(sc) {
+		return "GROUP BY or window without an aggregate function"
+	}
+
+	return ""
+}
+
+func checkFillWindow(sc SelectClauses) string {
+	if sc.Fill() != nil && sc.Window() == nil {
+		return "FILL without a window"
+	}
+
+	return ""
+}
+
+// aggregateFunctions 为 TDengine 和 InfluxQL 的聚合和选择函数。
+var aggregateFunctions = map[string]struct{}{
+	"COUNT": {}, "SUM": {}, "AVG": {}, "MEAN": {}, "MIN": {}, "MAX": {}, "SPREAD": {}, "STDDEV": {},
+	"PERCENTILE": {}, "APERCENTILE": {}, "MEDIAN": {}, "MODE": {}, "TOP": {}, "BOTTOM": {}, "FIRST": {},
+	"LAST": {}, "LAST_ROW": {}, "TWA": {}, "ELAPSED": {}, "INTEGRAL": {}, "LEASTSQUARES": {},
+	"HYPERLOGLOG": {}, "HISTOGRAM": {}, "SAMPLE": {}, "TAIL": {}, "UNIQUE": {}, "DISTINCT": {},
+}
+
+// hasAggregate 判断查询列中是否有聚合函数。
+func hasAggregate(sc SelectClauses) bool {
+	if sc.Select() == nil {
+		return false
+	}
+
+	for _, col := range sc.Select().Columns() {
+		if isAggregate(col) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isAggregate(e interface{}) bool {
+	switch t := e.(type) {
+	case SQLFunctionExpression:
+		if _, ok := aggregateFunctions[strings.ToUpper(t.Name())]; ok {
+			return true
+		}
+
+		for _, arg := range t.Args() {
+			if isAggregate(arg) {
+				return true
+			}
+		}
+	case AliasedExpression:
+		return isAggregate(t.Aliased())
+	case ComputerExpression:
+		return isAggregate(t.LHS()) || isAggregate(t.RHS())
+	}
+
+	return false
+}
+
+// timeBounds 为 WHERE 中时间列的下界和上界，未限制的一侧为 nil。
+type timeBounds struct {
+	start interface{}
+	end   interface{}
+}
+
+// whereTimeBounds 返回 where 对时间列 timeCols 的限制，AND 取最晚的下界和最早的上界，
+// OR 的每个分支都有限制时取各分支的并集，即最早的下界和最晚的上界。
+func whereTimeBounds(where ExpressionList, timeCols []string, do *SQLDialectOptions) timeBounds {
+	if where == nil {
+		return timeBounds{}
+	}
+
+	return expressionTimeBounds(where, timeCols, do)
+}
+
+func expressionTimeBounds(e Expression, timeCols []string, do *SQLDialectOptions) timeBounds {
+	var b timeBounds
+
+	switch t := e.(type) {
+	case ExpressionList:
+		if t.Type() == OrType {
+			for j, sub := range t.Expressions() {
+				sb := expressionTimeBounds(sub, timeCols, do)
+				if j == 0 {
+					b = sb
+
+					continue
+				}
+
+				b.start = unionBound(b.start, sb.start, false, do)
+				b.end = unionBound(b.end, sb.end, true, do)
+			}
+
+			return b
+		}
+
+		for _, sub := range t.Expressions() {
+			sb := expressionTimeBounds(sub, timeCols, do)
+			b.start = intersectBound(b.start, sb.start, true, do)
+			b.end = intersectBound(b.end, sb.end, false, do)
+		}
+	case BooleanExpression:
+		if !isTimeColumn(t.LHS(), timeCols) {
+			return b
+		}
+
+		switch t.Op() {
+		case GtOp, GteOp:
+			b.start = t.RHS()
+		case LtOp, LteOp:
+			b.end = t.RHS()
+		case EqOp:
+			b.start, b.end = t.RHS(), t.RHS()
+		}
+	case RangeExpression:
+		if isTimeColumn(t.LHS(), timeCols) && t.Op() == BetweenOp {
+			b.start, b.end = t.RHS().Start(), t.RHS().End()
+		}
+	}
+
+	return b
+}
+
+// orBounds 为无法比较先后的多个 OR 分支的边界，如 now() - 1h 和具体时间。
+type orBounds []interface{}
+
+func (o orBounds) String() string {
+	parts := make([]string, 0, len(o))
+	for _, v := range o {
+		parts = append(parts, fmt.Sprint(v))
+	}
+
+	return strings.Join(parts, " OR ")
+}
+
+// unionBound 合并两个 OR 分支的同侧边界，任一侧没有限制时返回 nil，later 为 true 时取较晚的值，
+// 否则取较早的值，无法比较时返回 orBounds。
+func unionBound(a, b interface{}, later bool, do *SQLDialectOptions) interface{} {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	at, aok := boundTime(a, do)
+	bt, bok := boundTime(b, do)
+
+	if !aok || !bok {
+		bounds, _ := a.(orBounds)
+		if bounds == nil {
+			bounds = orBounds{a}
+		}
+
+		return append(bounds, b)
+	}
+
+	if at.Before(bt) == later {
+		return b
+	}
+
+	return a
+}
+
+// intersectBound 合并 AND 条件的同侧边界，later 为 true 时取较晚的值，否则取较早的值，
+// 无法比较时优先保留可以比较的值，都无法比较时保留后一个。
+func intersectBound(a, b interface{}, later bool, do *SQLDialectOptions) interface{} {
+	if a == nil {
+		return b
+	}
+
+	if b == nil {
+		return a
+	}
+
+	at, aok := boundTime(a, do)
+	bt, bok := boundTime(b, do)
+
+	if aok && !bok {
+		return a
+	}
+
+	if !aok || !bok {
+		return b
+	}
+
+	if at.Before(bt) == later {
+		return b
+	}
+
+	return a
+}
+
+func isTimeColumn(e Expression, timeCols []string) bool {
+	id, ok := e.(IdentifierExpression)
+	if !ok {
+		return false
+	}
+
+	col, _ := id.GetCol().(string)
+
+	for _, name := range timeCols {
+		if strings.EqualFold(col, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,312 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+func TestLint(t *testing.T) {
+	hour := func(h int) time.Time { return epoch.Add(time.Duration(h) * time.Hour) }
+
+	tests := []struct {
+		name  string
+		qb    *influxdb.QueryBuilder
+		rules []influxdb.LintRule
+		want  []string
+	}{
+		{
+			name: "bounded raw query",
+			qb:   influxdb.From("cpu").Where(influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1)))),
+		},
+		{
+			name: "no where",
+			qb:   influxdb.From("cpu"),
+			want: []string{"missing-time-bound", "unbounded-raw-query"},
+		},
+		{
+			name: "raw query with limit",
+			qb:   influxdb.From("cpu").Limit(10),
+			want: []string{"missing-time-bound"},
+		},
+		{
+			name: "lower bound only",
+			qb:   influxdb.From("cpu").Where(influxdb.C("time").Gte(hour(0))),
+			want: []string{"unbounded-raw-query"},
+		},
+		{
+			name: "or with every branch bounded",
+			qb: influxdb.From("cpu").Where(influxdb.Or(
+				influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1))),
+				influxdb.C("ts").Between(influxdb.Range(hour(5), hour(6))),
+			)),
+		},
+		{
+			name: "or with an unbounded branch",
+			qb: influxdb.From("cpu").Where(influxdb.Or(
+				influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1))),
+				influxdb.C("host").Eq("a"),
+			)),
+			want: []string{"missing-time-bound", "unbounded-raw-query"},
+		},
+		{
+			name: "aggregate without window",
+			qb:   influxdb.From("cpu").Select(influxdb.Sum("value")),
+			want: []string{"missing-time-bound"},
+		},
+		{
+			name: "group by without aggregate",
+			qb:   influxdb.From("cpu").Select("value").GroupBy("host").Where(influxdb.C("ts").Gt(hour(0))),
+			want: []string{"unbounded-raw-query", "group-by-without-aggregate"},
+		},
+		{
+			name: "fill without window",
+			qb: influxdb.From("cpu").Select(influxdb.Sum("value")).Fill(influxdb.FillNull()).
+				Where(influxdb.C("ts").Gt(hour(0))),
+			want: []string{"fill-without-window"},
+		},
+		{
+			name:  "custom time column",
+			qb:    influxdb.From("events").Where(influxdb.C("event_time").Between(influxdb.Range(hour(0), hour(1)))),
+			rules: influxdb.DefaultLintRules("event_time"),
+		},
+		{
+			name:  "default columns are not time columns with custom ones",
+			qb:    influxdb.From("events").Where(influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1)))),
+			rules: influxdb.DefaultLintRules("event_time"),
+			want:  []string{"missing-time-bound", "unbounded-raw-query"},
+		},
+	}
+
+	for _, test := range tests {
+		var got []string
+
+		for _, issue := range influxdb.Lint(test.qb, test.rules...) {
+			got = append(got, issue.Rule)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: Lint() = %v; want %v", test.name, got, test.want)
+		}
+	}
+
+	issues := influxdb.Lint(influxdb.From("cpu"))
+	if err := issues.Err(); err == nil || !strings.Contains(err.Error(), "error: unbounded-raw-query") ||
+		strings.Contains(err.Error(), "missing-time-bound") {
+		t.Errorf("Err() = %v; want only the unbounded-raw-query error", err)
+	}
+}
+
+func TestGuardrails(t *testing.T) {
+	hour := func(h int) time.Time { return epoch.Add(time.Duration(h) * time.Hour) }
+
+	tests := []struct {
+		name      string
+		guards    influxdb.Guardrails
+		qb        *influxdb.QueryBuilder
+		wantRule  string
+		wantQuery string
+	}{
+		{
+			name:      "within range",
+			guards:    influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb:        influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1)))),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE (ts>='2024-01-01T00:00:00Z' AND ts<='2024-01-01T01:00:00Z')",
+		},
+		{
+			name:   "or uses the union of branches",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.Or(
+				influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1))),
+				influxdb.C("ts").Between(influxdb.Range(hour(10), hour(11))),
+			)),
+			wantRule: influxdb.TimeRangeExceededRule,
+		},
+		{
+			name:   "or with later branch first",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.Or(
+				influxdb.C("ts").Between(influxdb.Range(hour(10), hour(11))),
+				influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1))),
+			)),
+			wantRule: influxdb.TimeRangeExceededRule,
+		},
+		{
+			name:   "or with overlapping branches",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.Or(
+				influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1))),
+				influxdb.C("ts").Between(influxdb.Range(hour(1), hour(2))),
+			)),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE ((ts>='2024-01-01T00:00:00Z' AND ts<='2024-01-01T01:00:00Z') " +
+				"OR (ts>='2024-01-01T01:00:00Z' AND ts<='2024-01-01T02:00:00Z'))",
+		},
+		{
+			name:   "or with a now branch",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.Or(
+				influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1))),
+				influxdb.C("ts").Between(influxdb.Range(influxdb.Func("NOW"), influxdb.Func("NOW"))),
+			)),
+			wantRule: influxdb.TimeRangeExceededRule,
+		},
+		{
+			name:   "or with incomparable bounds",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.Or(
+				influxdb.C("ts").Between(influxdb.Range(hour(0), hour(1))),
+				influxdb.C("ts").Gte(influxdb.C("created")),
+			)),
+			wantRule: influxdb.UnknownTimeBoundRule,
+		},
+		{
+			name:     "bound from another column",
+			guards:   influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb:       influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.C("ts").Gte(influxdb.C("created"))),
+			wantRule: influxdb.UnknownTimeBoundRule,
+		},
+		{
+			name:   "tdengine time literals",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).
+				Where(influxdb.C("ts").Between(influxdb.Range("2024-01-01 00:00:00", "2024-01-01 01:00:00.500"))),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE (ts>='2024-01-01 00:00:00' AND ts<='2024-01-01 01:00:00.500')",
+		},
+		{
+			name:   "tdengine time literals too far apart",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).
+				Where(influxdb.C("ts").Between(influxdb.Range("2024-01-01 00:00:00", "2024-01-01 05:00:00"))),
+			wantRule: influxdb.TimeRangeExceededRule,
+		},
+		{
+			name:   "epoch milliseconds",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).
+				Where(influxdb.C("ts").Between(influxdb.Range(hour(0).UnixMilli(), hour(1).UnixMilli()))),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE (ts>=1704067200000 AND ts<=1704070800000)",
+		},
+		{
+			name:   "epoch milliseconds too far apart",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).
+				Where(influxdb.C("ts").Gte(hour(0).UnixMilli()), influxdb.C("ts").Lt(hour(5).UnixMilli())),
+			wantRule: influxdb.TimeRangeExceededRule,
+		},
+		{
+			name:      "now offset",
+			guards:    influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb:        influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.C("ts").Gte(influxdb.Now("-1h"))),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE (ts >= NOW-1h)",
+		},
+		{
+			name:     "now offset too far back",
+			guards:   influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb:       influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.C("ts").Gte(influxdb.Now("-1d"))),
+			wantRule: influxdb.TimeRangeExceededRule,
+		},
+		{
+			name:   "now range",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).
+				Where(influxdb.C("ts").Between(influxdb.Range(influxdb.Func("NOW").Sub(90*time.Minute), influxdb.Now()))),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE (ts>=NOW()-90m AND ts<=NOW)",
+		},
+		{
+			name:   "and keeps the latest lower bound",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(
+				influxdb.C("ts").Gte(hour(5)), influxdb.C("ts").Gte(hour(0)), influxdb.C("ts").Lte(hour(6)),
+			),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE " +
+				"((ts >= '2024-01-01T05:00:00Z') AND (ts >= '2024-01-01T00:00:00Z') AND (ts <= '2024-01-01T06:00:00Z'))",
+		},
+		{
+			name:   "and with the redundant lower bound first",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(
+				influxdb.C("ts").Gte(hour(0)), influxdb.C("ts").Gte(hour(5)), influxdb.C("ts").Lte(hour(6)),
+			),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE " +
+				"((ts >= '2024-01-01T00:00:00Z') AND (ts >= '2024-01-01T05:00:00Z') AND (ts <= '2024-01-01T06:00:00Z'))",
+		},
+		{
+			name:   "and keeps the earliest upper bound",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(
+				influxdb.C("ts").Gte(hour(0)), influxdb.C("ts").Lte(hour(1)), influxdb.C("ts").Lte(hour(10)),
+			),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE " +
+				"((ts >= '2024-01-01T00:00:00Z') AND (ts <= '2024-01-01T01:00:00Z') AND (ts <= '2024-01-01T10:00:00Z'))",
+		},
+		{
+			name:   "and with the redundant upper bound first",
+			guards: influxdb.Guardrails{MaxTimeRange: 2 * time.Hour},
+			qb: influxdb.From("cpu").Select(influxdb.Count("value")).Where(
+				influxdb.C("ts").Lte(hour(10)), influxdb.C("ts").Lte(hour(1)), influxdb.C("ts").Gte(hour(0)),
+			),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE " +
+				"((ts <= '2024-01-01T10:00:00Z') AND (ts <= '2024-01-01T01:00:00Z') AND (ts >= '2024-01-01T00:00:00Z'))",
+		},
+		{
+			name:     "require time bound",
+			guards:   influxdb.Guardrails{RequireTimeBound: true},
+			qb:       influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.C("host").Eq("a")),
+			wantRule: influxdb.MissingTimeBoundRule,
+		},
+		{
+			name:      "custom time column",
+			guards:    influxdb.Guardrails{RequireTimeBound: true, TimeColumns: []string{"event_time"}},
+			qb:        influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.C("event_time").Gt(hour(0))),
+			wantQuery: "SELECT COUNT(value) FROM cpu WHERE (event_time > '2024-01-01T00:00:00Z')",
+		},
+		{
+			name:     "default columns ignored with custom ones",
+			guards:   influxdb.Guardrails{RequireTimeBound: true, TimeColumns: []string{"event_time"}},
+			qb:       influxdb.From("cpu").Select(influxdb.Count("value")).Where(influxdb.C("ts").Gt(hour(0))),
+			wantRule: influxdb.MissingTimeBoundRule,
+		},
+		{
+			name:      "raw limit",
+			guards:    influxdb.Guardrails{MaxRawLimit: 5},
+			qb:        influxdb.From("cpu").Select("value").Limit(100),
+			wantQuery: "SELECT value FROM cpu LIMIT 5",
+		},
+	}
+
+	for _, test := range tests {
+		guards := test.guards
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.Guardrails = &guards })
+
+		var rows []map[string]any
+
+		err := test.qb.QueryTaos(context.Background(), db, &rows)
+		if test.wantRule != "" {
+			var ge *influxdb.GuardrailError
+			if !errors.As(err, &ge) || ge.Rule != test.wantRule {
+				t.Errorf("%s: error = %v; want rule %s", test.name, err, test.wantRule)
+			}
+
+			if len(srv.Queries()) != 0 {
+				t.Errorf("%s: queries = %v; want none sent", test.name, srv.Queries())
+			}
+
+			continue
+		}
+
+		if err != nil && !errors.Is(err, influxdb.ErrNoData) {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if q := srv.LastQuery(); q != test.wantQuery {
+			t.Errorf("%s: query = %q; want %q", test.name, q, test.wantQuery)
+		}
+	}
+}
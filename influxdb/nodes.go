@@ -0,0 +1,226 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+var ErrNoEndpoints = errors.New("no endpoints configured")
+
+// Endpoint 为一个 TDengine/InfluxDB 节点，Host 包含协议，如 http://127.0.0.1。
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+type BalanceStrategy int8
+
+const (
+	// RoundRobin 轮询健康节点。
+	RoundRobin BalanceStrategy = iota
+	// LeastLatency 优先选择平均延迟最低的健康节点。
+	LeastLatency
+)
+
+const (
+	defaultHealthCheckPath = "/-/ping"
+	defaultWriteDatabase   = "hypon"
+	latencyWeight          = 5
+)
+
+type node struct {
+	readHost  string
+	writeHost string
+	baseURL   string
+	queryURL  string
+	pingURL   string
+	// latency 为请求延迟的指数加权移动平均，单位为纳秒。
+	latency atomic.Int64
+	down    atomic.Bool
+}
+
+func newNode(ep Endpoint, cfg Config) (*node, error) {
+	u, err := url.Parse(fmt.Sprintf("%s:%d/influxdb/v1", ep.Host, ep.Port))
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = path.Join(u.Path, "write")
+	params := u.Query()
+	writeDatabase := cfg.WriteDatabase
+	if writeDatabase == "" {
+		writeDatabase = defaultWriteDatabase
+	}
+
+	params.Set("db", writeDatabase)
+	params.Set("rp", "")
+	params.Set("precision", "s")
+	params.Set("consistency", "")
+	u.RawQuery = params.Encode()
+
+	healthCheckPath := cfg.HealthCheckPath
+	if healthCheckPath == "" {
+		healthCheckPath = defaultHealthCheckPath
+	}
+
+	return &node{
+		readHost:  fmt.Sprintf("%s:%d/rest/sql/%s", ep.Host, ep.Port, cfg.Database),
+		writeHost: u.String(),
+		baseURL:   fmt.Sprintf("%s:%d/rest/sql/%s", ep.Host, ep.Port, cfg.Database),
+		queryURL:  fmt.Sprintf("%s:%d/query?db=%s", ep.Host, ep.Port, url.QueryEscape(cfg.Database)),
+		pingURL:   fmt.Sprintf("%s:%d%s", ep.Host, ep.Port, healthCheckPath),
+	}, nil
+}
+
+func (n *node) observe(d time.Duration) {
+	for {
+		old := n.latency.Load()
+
+		lat := int64(d)
+		if old > 0 {
+			lat = old + (int64(d)-old)/latencyWeight
+		}
+
+		if n.latency.CompareAndSwap(old, lat) {
+			return
+		}
+	}
+}
+
+type nodePool struct {
+	nodes    []*node
+	next     atomic.Uint64
+	strategy BalanceStrategy
+}
+
+func newNodePool(endpoints []Endpoint, cfg Config) (*nodePool, error) {
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	p := &nodePool{strategy: cfg.Balance}
+
+	for _, ep := range endpoints {
+		n, err := newNode(ep, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		p.nodes = append(p.nodes, n)
+	}
+
+	return p, nil
+}
+
+// candidates 返回本次请求依次尝试的节点，健康节点按负载均衡策略排在前面，不健康节点作为最后的备选。
+func (p *nodePool) candidates() []*node {
+	healthy := make([]*node, 0, len(p.nodes))
+	down := make([]*node, 0)
+
+	start := int(p.next.Add(1)-1) % len(p.nodes)
+
+	for j := 0; j < len(p.nodes); j++ {
+		n := p.nodes[(start+j)%len(p.nodes)]
+		if n.down.Load() {
+			down = append(down, n)
+		} else {
+			healthy = append(healthy, n)
+		}
+	}
+
+	if p.strategy == LeastLatency {
+		sort.SliceStable(healthy, func(a, b int) bool {
+			return healthy[a].latency.Load() < healthy[b].latency.Load()
+		})
+	}
+
+	return append(healthy, down...)
+}
+
+// do 依次在候选节点上执行请求，连接失败时将节点标记为不健康并切换到下一个节点。
+func (p *nodePool) do(
+	ctx context.Context, client *http.Client, build func(n *node) (*http.Request, error),
+) (*http.Response, error) {
+	var lastErr error
+
+	for _, n := range p.candidates() {
+		req, err := build(n)
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+
+		resp, err := client.Do(req)
+		if err == nil {
+			n.down.Store(false)
+			n.observe(time.Since(start))
+
+			return resp, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		n.down.Store(true)
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// healthCheck 定期探测所有节点直到 stop 关闭，状态码小于 500 即视为健康。
+func (p *nodePool) healthCheck(client *http.Client, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, n := range p.nodes {
+				p.ping(client, n, interval)
+			}
+		}
+	}
+}
+
+func (p *nodePool) ping(client *http.Client, n *node, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.pingURL, nil)
+	if err != nil {
+		n.down.Store(true)
+
+		return
+	}
+
+	start := time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		n.down.Store(true)
+
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		n.down.Store(true)
+
+		return
+	}
+
+	n.down.Store(false)
+	n.observe(time.Since(start))
+}
@@ -0,0 +1,148 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewNode(t *testing.T) {
+	tests := []struct {
+		name  string
+		ep    Endpoint
+		cfg   Config
+		write string
+		read  string
+		ping  string
+	}{
+		{
+			name:  "default write database",
+			ep:    Endpoint{Host: "http://10.0.0.1", Port: 6041},
+			cfg:   Config{Database: "metrics"},
+			write: "http://10.0.0.1:6041/influxdb/v1/write?consistency=&db=hypon&precision=s&rp=",
+			read:  "http://10.0.0.1:6041/rest/sql/metrics",
+			ping:  "http://10.0.0.1:6041/-/ping",
+		},
+		{
+			name:  "escaped database and health check path",
+			ep:    Endpoint{Host: "https://td", Port: 443},
+			cfg:   Config{Database: "a b", WriteDatabase: "a b", HealthCheckPath: "/health"},
+			write: "https://td:443/influxdb/v1/write?consistency=&db=a+b&precision=s&rp=",
+			read:  "https://td:443/rest/sql/a b",
+			ping:  "https://td:443/health",
+		},
+	}
+
+	for _, test := range tests {
+		n, err := newNode(test.ep, test.cfg)
+		if err != nil {
+			t.Errorf("%s: newNode error: %v", test.name, err)
+
+			continue
+		}
+
+		if n.writeHost != test.write || n.baseURL != test.read || n.pingURL != test.ping {
+			t.Errorf("%s: newNode = %s, %s, %s; want %s, %s, %s",
+				test.name, n.writeHost, n.baseURL, n.pingURL, test.write, test.read, test.ping)
+		}
+	}
+}
+
+func TestNodePoolCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy BalanceStrategy
+		latency  []time.Duration
+		down     []bool
+		want     []string
+	}{
+		{
+			name: "round robin",
+			down: []bool{false, false, false},
+			want: []string{"a b c", "b c a", "c a b", "a b c"},
+		},
+		{
+			name: "down nodes last",
+			down: []bool{false, true, false},
+			want: []string{"a c b", "c a b", "c a b"},
+		},
+		{
+			name:     "least latency",
+			strategy: LeastLatency,
+			latency:  []time.Duration{3, 1, 2},
+			down:     []bool{false, false, true},
+			want:     []string{"b a c", "b a c"},
+		},
+	}
+
+	for _, test := range tests {
+		p := &nodePool{strategy: test.strategy}
+		names := map[*node]string{}
+
+		for j, down := range test.down {
+			n := &node{}
+			n.down.Store(down)
+
+			if test.latency != nil {
+				n.latency.Store(int64(test.latency[j]))
+			}
+
+			names[n] = string(rune('a' + j))
+			p.nodes = append(p.nodes, n)
+		}
+
+		for round, want := range test.want {
+			var got []string
+			for _, n := range p.candidates() {
+				got = append(got, names[n])
+			}
+
+			if strings.Join(got, " ") != want {
+				t.Errorf("%s: round %d candidates = %v; want %s", test.name, round, got, want)
+			}
+		}
+	}
+}
+
+func TestNodePoolFailover(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer ok.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	bad, good := &node{baseURL: closed.URL}, &node{baseURL: ok.URL}
+	p := &nodePool{nodes: []*node{bad, good}}
+
+	resp, err := p.do(context.Background(), http.DefaultClient, func(n *node) (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, n.baseURL, nil)
+	})
+	if err != nil {
+		t.Fatalf("do error: %v", err)
+	}
+
+	resp.Body.Close()
+
+	if !bad.down.Load() || good.down.Load() || good.latency.Load() == 0 {
+		t.Errorf("after failover bad.down = %v, good.down = %v, good.latency = %d",
+			bad.down.Load(), good.down.Load(), good.latency.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	bad.down.Store(false)
+
+	_, err = p.do(ctx, http.DefaultClient, func(n *node) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL, nil)
+	})
+	if err == nil {
+		t.Error("do with a cancelled context succeeded; want error")
+	}
+}
@@ -0,0 +1,217 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const defaultCursorColumn = "ts"
+
+// ErrDuplicateCursor 表示多行的游标列相同，如超级表中不同子表的时间戳相同，此时无法确定分页位置，
+// 需要用 Cursor 指定 tbname 或标签作为排序的第二关键字。
+var ErrDuplicateCursor = errors.New("rows share the same cursor values, add tbname or tags to Cursor")
+
+// PageIterator 以键集（最后一条记录的时间戳及标签）为游标按时间升序分页遍历查询结果，
+// 相比 OFFSET 分页不会随页数增加而变慢。
+//
+//	it := From("meters").Where(...).Pages(ctx, conn, 1000)
+//	for it.Next(&page) {
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PageIterator struct {
+	ctx      context.Context
+	err      error
+	conn     Querier
+	qb       *QueryBuilder
+	cursor   map[string]any
+	timeCol  string
+	tagCols  []string
+	pageSize int
+	done     bool
+}
+
+// Pages 返回 qb 的分页迭代器，每页最多 pageSize 行。qb 的排序和 LIMIT 会被游标列的升序分页替换，
+// 遍历结束后 qb 被回收。
+func (qb *QueryBuilder) Pages(ctx context.Context, conn Querier, pageSize int) *PageIterator {
+	it := &PageIterator{
+		ctx:      ctx,
+		conn:     conn,
+		qb:       qb,
+		timeCol:  defaultCursorColumn,
+		pageSize: pageSize,
+	}
+
+	if pageSize <= 0 {
+		it.finish(fmt.Errorf("invalid page size %d", pageSize))
+	}
+
+	return it
+}
+
+// Cursor 设置游标列，默认为 ts，以 (时间戳, 标签...) 元组作为游标，游标列需要在查询结果中。
+// 元组必须唯一：普通表和子表的时间戳是主键，超级表需要指定 tbname 或能区分子表的标签，
+// 否则遇到游标相同的行时返回 ErrDuplicateCursor。
+func (it *PageIterator) Cursor(timeCol string, tags ...string) *PageIterator {
+	it.timeCol = timeCol
+	it.tagCols = tags
+
+	return it
+}
+
+// Next 查询下一页并解析到 dst，没有更多数据或出错时返回 false。
+func (it *PageIterator) Next(dst interface{}) bool {
+	if it.done {
+		return false
+	}
+
+	q := it.pageQuery()
+	if err := guard(it.conn, q); err != nil {
+		it.finish(err)
+
+		return false
+	}
+
+	// MaxRawLimit 可能减小了 LIMIT，此时缩小每页行数，仍多查询一行。
+	if limit, ok := q.clauses.Limit().(int); ok && limit <= it.pageSize {
+		it.pageSize = max(limit-1, 1)
+		q.Limit(it.pageSize + 1)
+	}
+
+	sql, tz, err := q.ToSQL()
+	if err != nil {
+		it.finish(err)
+
+		return false
+	}
+
+	rows, err := fetchRows(it.ctx, it.conn, sql, tz)
+	if errors.Is(err, ErrNoData) {
+		it.finish(nil)
+
+		return false
+	}
+
+	if err == nil {
+		err = it.checkCursor(rows)
+	}
+
+	if err != nil {
+		it.finish(err)
+
+		return false
+	}
+
+	if len(rows) == 0 {
+		it.finish(nil)
+
+		return false
+	}
+
+	// 多查询的一行用于判断是否还有下一页，以及下一页的第一行是否与本页最后一行的游标相同。
+	if len(rows) > it.pageSize {
+		rows = rows[:it.pageSize]
+	} else {
+		it.finish(nil)
+	}
+
+	it.cursor = rows[len(rows)-1]
+
+	if err = decodeRows(zeroNulls(rows), dst); err != nil {
+		it.finish(err)
+
+		return false
+	}
+
+	return true
+}
+
+// Err 返回遍历过程中的错误。
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+func (it *PageIterator) finish(err error) {
+	if it.done {
+		return
+	}
+
+	it.done = true
+	it.err = err
+	it.qb.release()
+}
+
+func (it *PageIterator) pageQuery() *QueryBuilder {
+	q := it.qb.Clone()
+
+	order := make([]OrderedExpression, 0, len(it.tagCols)+1)
+	order = append(order, C(it.timeCol).Asc())
+
+	for _, tag := range it.tagCols {
+		order = append(order, C(tag).Asc())
+	}
+
+	q.Order(order...).Limit(it.pageSize + 1)
+
+	if it.cursor == nil {
+		return q
+	}
+
+	last := it.cursor[it.timeCol]
+
+	if len(it.tagCols) == 0 {
+		return q.Where(C(it.timeCol).Gt(last))
+	}
+
+	// (ts, t1, t2) > (v0, v1, v2) 展开为 ts > v0 OR (ts = v0 AND t1 > v1) OR (ts = v0 AND t1 = v1 AND t2 > v2)
+	ors := make([]Expression, 0, len(it.tagCols)+1)
+	ors = append(ors, C(it.timeCol).Gt(last))
+
+	for i, tag := range it.tagCols {
+		ands := make([]Expression, 0, i+2)
+		ands = append(ands, C(it.timeCol).Eq(last))
+
+		for _, prev := range it.tagCols[:i] {
+			ands = append(ands, C(prev).Eq(it.cursor[prev]))
+		}
+
+		ands = append(ands, C(tag).Gt(it.cursor[tag]))
+		ors = append(ors, And(ands...))
+	}
+
+	return q.Where(Or(ors...))
+}
+
+// checkCursor 检查结果中有游标列，且相邻两行的游标不同。
+func (it *PageIterator) checkCursor(rows []map[string]any) error {
+	cols := append([]string{it.timeCol}, it.tagCols...)
+
+	if len(rows) > 0 {
+		for _, col := range cols {
+			if _, ok := rows[0][col]; !ok {
+				return fmt.Errorf("cursor column %s not found in the result", col)
+			}
+		}
+	}
+
+	for j := 1; j < len(rows); j++ {
+		same := true
+		for _, col := range cols {
+			if !reflect.DeepEqual(rows[j-1][col], rows[j][col]) {
+				same = false
+
+				break
+			}
+		}
+
+		if same {
+			return ErrDuplicateCursor
+		}
+	}
+
+	return nil
+}
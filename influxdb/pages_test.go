@@ -0,0 +1,98 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+func TestPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		hosts    []string
+		points   int
+		pageSize int
+		cursor   []string
+		selects  []interface{}
+		pages    []int
+		wantErr  error
+		errText  string
+	}{
+		{name: "child table", hosts: []string{"a"}, points: 5, pageSize: 2, pages: []int{2, 2, 1}},
+		{name: "exact pages", hosts: []string{"a"}, points: 4, pageSize: 2, pages: []int{2, 2}},
+		{name: "single page", hosts: []string{"a"}, points: 3, pageSize: 10, pages: []int{3}},
+		{
+			name: "super table with tie-breaker", hosts: []string{"a", "b", "c"}, points: 3, pageSize: 2,
+			cursor: []string{"ts", "host"}, pages: []int{2, 2, 2, 2, 1},
+		},
+		{
+			name: "super table without tie-breaker", hosts: []string{"a", "b"}, points: 3, pageSize: 2,
+			wantErr: influxdb.ErrDuplicateCursor,
+		},
+		{
+			// 第一页的最后一行与下一行时间戳相同，只能通过多查询的一行发现。
+			name: "duplicate across pages", hosts: []string{"a", "b"}, points: 3, pageSize: 1,
+			wantErr: influxdb.ErrDuplicateCursor,
+		},
+		{
+			name: "cursor column not selected", hosts: []string{"a"}, points: 3, pageSize: 2,
+			selects: []interface{}{"value"}, errText: "cursor column ts not found",
+		},
+		{name: "invalid page size", hosts: []string{"a"}, points: 3, pageSize: 0, errText: "invalid page size 0"},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t)
+		insertCPU(srv, test.hosts, test.points)
+
+		selects := test.selects
+		if selects == nil {
+			selects = []interface{}{"ts", "host", "value"}
+		}
+
+		it := influxdb.From("cpu").Select(selects...).Pages(context.Background(), db, test.pageSize)
+		if test.cursor != nil {
+			it.Cursor(test.cursor[0], test.cursor[1:]...)
+		}
+
+		var (
+			pages []int
+			seen  = map[string]bool{}
+			page  []map[string]any
+			dup   bool
+		)
+
+		for it.Next(&page) {
+			pages = append(pages, len(page))
+
+			for _, r := range page {
+				key := fmt.Sprint(r["ts"], r["host"])
+				dup = dup || seen[key]
+				seen[key] = true
+			}
+		}
+
+		err := it.Err()
+
+		switch {
+		case test.wantErr != nil:
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s: Err() = %v; want %v", test.name, err, test.wantErr)
+			}
+		case test.errText != "":
+			if err == nil || !strings.Contains(err.Error(), test.errText) {
+				t.Errorf("%s: Err() = %v; want %q", test.name, err, test.errText)
+			}
+		case err != nil:
+			t.Errorf("%s: Err() = %v", test.name, err)
+		case fmt.Sprint(pages) != fmt.Sprint(test.pages):
+			t.Errorf("%s: pages = %v; want %v", test.name, pages, test.pages)
+		case dup || len(seen) != len(test.hosts)*test.points:
+			t.Errorf("%s: returned %d distinct rows, duplicated %v; want %d", test.name, len(seen), dup, len(test.hosts)*test.points)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT  *\n\tFROM cpu", "SELECT * FROM cpu"},
+		{"  SELECT * FROM cpu  ", "SELECT * FROM cpu"},
+		{"SELECT * FROM cpu WHERE host = 'a  b'", "SELECT * FROM cpu WHERE host = 'a  b'"},
+		{`SELECT "a  b" FROM  cpu`, `SELECT "a  b" FROM cpu`},
+		{"SELECT `a  b` FROM  cpu", "SELECT `a  b` FROM cpu"},
+		{`SELECT "it's  x",  v FROM cpu`, `SELECT "it's  x", v FROM cpu`},
+		{"SELECT * FROM cpu WHERE host = 'say \"hi  there'", "SELECT * FROM cpu WHERE host = 'say \"hi  there'"},
+	}
+
+	for _, test := range tests {
+		if got := normalizeSQL(test.sql); got != test.want {
+			t.Errorf("normalizeSQL(%q) = %q; want %q", test.sql, got, test.want)
+		}
+	}
+}
+
+func TestQueryCacheLeaderCanceled(t *testing.T) {
+	tests := []struct {
+		name       string
+		leaderErr  error
+		followerOK bool
+		wantErr    error
+	}{
+		{name: "leader canceled", leaderErr: context.Canceled, followerOK: true},
+		{name: "leader timed out", leaderErr: context.DeadlineExceeded, followerOK: true},
+		// 与 context 无关的错误由所有合并的调用者共享。
+		{name: "leader failed", leaderErr: errors.New("boom"), wantErr: errors.New("boom")},
+	}
+
+	for _, test := range tests {
+		c := newQueryCache(time.Minute, 10)
+		key := cacheKey{sql: "SELECT * FROM cpu"}
+
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		leaderDone := make(chan error, 1)
+
+		leaderCtx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			_, err := c.do(leaderCtx, key, func() ([]byte, error) {
+				close(entered)
+				<-release
+
+				return nil, test.leaderErr
+			})
+			leaderDone <- err
+		}()
+
+		<-entered
+
+		followerDone := make(chan error, 1)
+
+		var body []byte
+
+		go func() {
+			var err error
+			body, err = c.do(context.Background(), key, func() ([]byte, error) {
+				return []byte("rows"), nil
+			})
+			followerDone <- err
+		}()
+
+		// 等待 follower 合并到 leader 的调用。
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		close(release)
+
+		if err := <-leaderDone; !errors.Is(err, test.leaderErr) {
+			t.Errorf("%s: leader error = %v; want %v", test.name, err, test.leaderErr)
+		}
+
+		err := <-followerDone
+		if test.followerOK {
+			if err != nil || string(body) != "rows" {
+				t.Errorf("%s: follower = %q, %v; want rows", test.name, body, err)
+			}
+
+			continue
+		}
+
+		if err == nil || err.Error() != test.wantErr.Error() {
+			t.Errorf("%s: follower error = %v; want %v", test.name, err, test.wantErr)
+		}
+	}
+}
@@ -229,18 +229,26 @@ func (lim *Limiter) Wait(ctx context.Context) (err error) {
 // canceled, or the expected wait time exceeds the Context's Deadline.
 // The burst limit is ignored if the rate limit is Inf.
 func (lim *Limiter) WaitN(ctx context.Context, n int) (err error) {
+	_, err = lim.waitN(ctx, n)
+
+	return err
+}
+
+// waitN is like WaitN but also returns the delay of the reservation, which is
+// zero when the tokens were available immediately.
+func (lim *Limiter) waitN(ctx context.Context, n int) (delay time.Duration, err error) {
 	lim.mu.Lock()
 	burst := lim.burst
 	limit := lim.limit
 	lim.mu.Unlock()
 
 	if n > burst && limit != Inf {
-		return fmt.Errorf("rate: Wait(n=%d) exceeds limiter's burst %d", n, burst)
+		return 0, fmt.Errorf("rate: Wait(n=%d) exceeds limiter's burst %d", n, burst)
 	}
 	// Check if ctx is already cancelled
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return 0, ctx.Err()
 	default:
 	}
 	// Determine wait limit
@@ -253,12 +261,12 @@ func (lim *Limiter) WaitN(ctx context.Context, n int) (err error) {
 	// Reserve
 	r := lim.reserveN(now, n, waitLimit)
 	if !r.ok {
-		return fmt.Errorf("rate: Wait(n=%d) would exceed context deadline", n)
+		return 0, fmt.Errorf("rate: Wait(n=%d) would exceed context deadline", n)
 	}
 	// Wait if necessary
-	delay := r.DelayFrom(now)
+	delay = r.DelayFrom(now)
 	if delay == 0 {
-		return nil
+		return 0, nil
 	}
 
 	t := time.NewTimer(delay)
@@ -267,13 +275,13 @@ func (lim *Limiter) WaitN(ctx context.Context, n int) (err error) {
 	select {
 	case <-t.C:
 		// We can proceed.
-		return nil
+		return delay, nil
 	case <-ctx.Done():
 		// Context was canceled before we could proceed.  Cancel the
 		// reservation, which may permit other events to proceed sooner.
 		r.Cancel()
 
-		return ctx.Err()
+		return delay, ctx.Err()
 	}
 }
 
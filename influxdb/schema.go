@@ -0,0 +1,256 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Backend 为连接的数据库类型，决定 schema 查询使用的语句。
+type Backend int8
+
+const (
+	TDengineBackend Backend = iota
+	InfluxQLBackend
+)
+
+// FieldKey 为普通列（InfluxDB 的 field），Type 为数据库返回的类型名，如 FLOAT、float、integer。
+type FieldKey struct {
+	Name string
+	Type string
+}
+
+// Databases 返回所有数据库名。
+func (i *InfluxDB) Databases(ctx context.Context) ([]string, error) {
+	return i.showStrings(ctx, "SHOW DATABASES", "name")
+}
+
+// Measurements 返回当前数据库的所有 measurement（TDengine 为超级表）。
+func (i *InfluxDB) Measurements(ctx context.Context) ([]string, error) {
+	if i.backend == InfluxQLBackend {
+		return i.showStrings(ctx, "SHOW MEASUREMENTS", "name")
+	}
+
+	return i.showStrings(ctx, "SHOW STABLES", "stable_name")
+}
+
+// TagKeys 返回 measurement 的标签名。
+func (i *InfluxDB) TagKeys(ctx context.Context, measurement string) ([]string, error) {
+	if i.backend == InfluxQLBackend {
+		sql, err := i.schemaSQL("SHOW TAG KEYS FROM ", measurement, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return i.showStrings(ctx, sql, "tagKey")
+	}
+
+	cols, err := i.describe(ctx, measurement)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+
+	for _, col := range cols {
+		if col.tag {
+			keys = append(keys, col.Name)
+		}
+	}
+
+	return keys, nil
+}
+
+// TagValues 返回 measurement 中标签 key 的所有取值，filter 不为 nil 时只返回满足条件的序列的取值。
+func (i *InfluxDB) TagValues(ctx context.Context, measurement, key string, filter Expression) ([]string, error) {
+	if i.backend == InfluxQLBackend {
+		sql, err := i.schemaSQL("SHOW TAG VALUES FROM ", measurement, filter, " WITH KEY = ", key)
+		if err != nil {
+			return nil, err
+		}
+
+		return i.showStrings(ctx, sql, "value")
+	}
+
+	sql, err := i.schemaSQL("SELECT DISTINCT ", measurement, filter, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.showStrings(ctx, sql, key)
+}
+
+// FieldKeys 返回 measurement 的普通列及其类型，TDengine 包括时间戳列。
+func (i *InfluxDB) FieldKeys(ctx context.Context, measurement string) ([]FieldKey, error) {
+	if i.backend == InfluxQLBackend {
+		sql, err := i.schemaSQL("SHOW FIELD KEYS FROM ", measurement, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var rows []map[string]any
+		if err = i.Query(ctx, sql, &rows); err != nil {
+			if errors.Is(err, ErrNoSeries) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		keys := make([]FieldKey, 0, len(rows))
+		for _, row := range rows {
+			keys = append(keys, FieldKey{Name: fmt.Sprint(row["fieldKey"]), Type: fmt.Sprint(row["fieldType"])})
+		}
+
+		return keys, nil
+	}
+
+	cols, err := i.describe(ctx, measurement)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []FieldKey
+
+	for _, col := range cols {
+		if !col.tag {
+			keys = append(keys, col.FieldKey)
+		}
+	}
+
+	return keys, nil
+}
+
+type describedColumn struct {
+	FieldKey
+	tag bool
+}
+
+// describe 通过 DESCRIBE 读取 TDengine 表结构，note 为 TAG 的列为标签。
+func (i *InfluxDB) describe(ctx context.Context, measurement string) ([]describedColumn, error) {
+	sql, err := i.schemaSQL("DESCRIBE ", measurement, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]any
+
+	err = i.Query2(ctx, sql, &rows)
+	if errors.Is(err, ErrNoData) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]describedColumn, 0, len(rows))
+	for _, row := range rows {
+		cols = append(cols, describedColumn{
+			FieldKey: FieldKey{Name: fmt.Sprint(row["field"]), Type: fmt.Sprint(row["type"])},
+			tag:      strings.EqualFold(fmt.Sprint(row["note"]), "TAG"),
+		})
+	}
+
+	return cols, nil
+}
+
+// showStrings 执行 schema 查询并返回 col 列的值，没有数据时返回空。
+func (i *InfluxDB) showStrings(ctx context.Context, sql, col string) ([]string, error) {
+	var (
+		rows []map[string]any
+		err  error
+	)
+
+	if i.backend == InfluxQLBackend {
+		err = i.Query(ctx, sql, &rows)
+	} else {
+		err = i.Query2(ctx, sql, &rows)
+	}
+
+	if errors.Is(err, ErrNoData) || errors.Is(err, ErrNoSeries) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, fmt.Sprint(row[col]))
+	}
+
+	return res, nil
+}
+
+// schemaSQL 生成 prefix + measurement [+ withKey key] [WHERE filter]，标识符和条件按当前方言输出。
+// withKey 只有一个元素时生成 prefix + key FROM measurement [WHERE filter]。
+func (i *InfluxDB) schemaSQL(prefix, measurement string, filter Expression, withKey ...string) (string, error) {
+	do := i.dialectOptions()
+	esg := newExpressionSQLGenerator(do)
+	sb := newSQLBuilder(false)
+
+	sb.WriteStrings(prefix)
+
+	if len(withKey) == 1 {
+		writeQuotedIdentifier(sb, do, withKey[0])
+		sb.Write(do.FromFragment).WriteRunes(do.SpaceRune)
+	}
+
+	esg.Generate(sb, ParseIdentifier(measurement))
+
+	if len(withKey) == 2 {
+		sb.WriteStrings(withKey[0])
+		writeQuotedIdentifier(sb, do, withKey[1])
+	}
+
+	if filter != nil {
+		sb.Write(do.WhereFragment)
+		esg.Generate(sb, filter)
+	}
+
+	return sb.ToSQL()
+}
+
+// writeQuotedIdentifier 输出单个标识符，不是普通名称（字母、数字、下划线）时用 QuoteRune 引起来，
+// 包含 QuoteRune 或换行的名称无法安全引用，返回错误。
+func writeQuotedIdentifier(sb SQLBuilder, do *SQLDialectOptions, name string) {
+	if isPlainIdentifier(name) {
+		sb.WriteStrings(name)
+
+		return
+	}
+
+	if name == "" || strings.ContainsRune(name, do.QuoteRune) || strings.ContainsAny(name, "\r\n") {
+		sb.SetError(fmt.Errorf("invalid identifier %q", name))
+
+		return
+	}
+
+	sb.WriteRunes(do.QuoteRune)
+	sb.WriteStrings(name)
+	sb.WriteRunes(do.QuoteRune)
+}
+
+func isPlainIdentifier(name string) bool {
+	for j, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case j > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+func (i *InfluxDB) dialectOptions() *SQLDialectOptions {
+	if i.backend == InfluxQLBackend {
+		return influxQLDialectOptions
+	}
+
+	return defaultDialectOptions
+}
@@ -0,0 +1,185 @@
+package influxdb_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jiurenm/mare/influxdb"
+	"github.com/jiurenm/mare/influxdb/influxdbtest"
+)
+
+func TestTagValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		backend   influxdb.Backend
+		from      string
+		key       string
+		filter    influxdb.Expression
+		want      []string
+		wantQuery string
+		wantErr   string
+	}{
+		{
+			name:      "tdengine",
+			key:       "host",
+			want:      []string{"a", "b"},
+			wantQuery: "SELECT DISTINCT host FROM cpu",
+		},
+		{
+			name:      "tdengine filter",
+			key:       "host",
+			filter:    influxdb.C("host").Eq("b"),
+			want:      []string{"b"},
+			wantQuery: "SELECT DISTINCT host FROM cpu WHERE (host = 'b')",
+		},
+		{
+			name:      "tdengine quoted key",
+			from:      "dc",
+			key:       "data center",
+			want:      []string{"east"},
+			wantQuery: "SELECT DISTINCT `data center` FROM dc",
+		},
+		{
+			name:      "tdengine injection",
+			key:       "host FROM cpu; DROP TABLE cpu; --",
+			wantQuery: "SELECT DISTINCT `host FROM cpu; DROP TABLE cpu; --` FROM cpu",
+		},
+		{
+			name:    "tdengine quote rune",
+			key:     "host` FROM cpu; --",
+			wantErr: "invalid identifier",
+		},
+		{
+			name:      "influxql",
+			backend:   influxdb.InfluxQLBackend,
+			key:       "host",
+			want:      []string{"a", "b"},
+			wantQuery: "SHOW TAG VALUES FROM cpu WITH KEY = host",
+		},
+		{
+			name:      "influxql filter",
+			backend:   influxdb.InfluxQLBackend,
+			key:       "host",
+			filter:    influxdb.C("host").Eq("a"),
+			want:      []string{"a"},
+			wantQuery: "SHOW TAG VALUES FROM cpu WITH KEY = host WHERE (host = 'a')",
+		},
+		{
+			name:      "influxql quoted key",
+			backend:   influxdb.InfluxQLBackend,
+			from:      "dc",
+			key:       "data center",
+			want:      []string{"east"},
+			wantQuery: `SHOW TAG VALUES FROM dc WITH KEY = "data center"`,
+		},
+		{
+			name:    "influxql quote rune",
+			backend: influxdb.InfluxQLBackend,
+			key:     `host"; DROP MEASUREMENT cpu`,
+			wantErr: "invalid identifier",
+		},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.Backend = test.backend })
+		insertCPU(srv, []string{"a", "b"}, 2)
+		srv.Insert(influxdbtest.Point{
+			Measurement: "dc",
+			Time:        epoch,
+			Tags:        map[string]string{"host": "a", "data center": "east"},
+			Fields:      map[string]any{"value": 1.0},
+		})
+
+		if test.from == "" {
+			test.from = "cpu"
+		}
+
+		got, err := db.TagValues(context.Background(), test.from, test.key, test.filter)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: error = %v; want %q", test.name, err, test.wantErr)
+			}
+
+			if len(srv.Queries()) != 0 {
+				t.Errorf("%s: queries = %v; want none sent", test.name, srv.Queries())
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if q := srv.LastQuery(); q != test.wantQuery {
+			t.Errorf("%s: query = %q; want %q", test.name, q, test.wantQuery)
+		}
+
+		// 注入的 key 只是一个不存在的列名，不比较取值。
+		if test.want != nil && fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: TagValues() = %v; want %v", test.name, got, test.want)
+		}
+
+		if len(srv.Points("cpu")) != 4 {
+			t.Errorf("%s: cpu has %d points; want 4", test.name, len(srv.Points("cpu")))
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend influxdb.Backend
+		call    func(db *influxdb.InfluxDB) (any, error)
+		want    string
+	}{
+		{
+			name: "tdengine measurements",
+			call: func(db *influxdb.InfluxDB) (any, error) { return db.Measurements(context.Background()) },
+			want: "[cpu]",
+		},
+		{
+			name: "tdengine tag keys",
+			call: func(db *influxdb.InfluxDB) (any, error) { return db.TagKeys(context.Background(), "cpu") },
+			want: "[host]",
+		},
+		{
+			name:    "influxql measurements",
+			backend: influxdb.InfluxQLBackend,
+			call:    func(db *influxdb.InfluxDB) (any, error) { return db.Measurements(context.Background()) },
+			want:    "[cpu]",
+		},
+		{
+			name:    "influxql tag keys",
+			backend: influxdb.InfluxQLBackend,
+			call:    func(db *influxdb.InfluxDB) (any, error) { return db.TagKeys(context.Background(), "cpu") },
+			want:    "[host]",
+		},
+		{
+			name:    "influxql field keys",
+			backend: influxdb.InfluxQLBackend,
+			call:    func(db *influxdb.InfluxDB) (any, error) { return db.FieldKeys(context.Background(), "cpu") },
+			want:    "[{value float}]",
+		},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.Backend = test.backend })
+		insertCPU(srv, []string{"a"}, 1)
+
+		got, err := test.call(db)
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if fmt.Sprint(got) != test.want {
+			t.Errorf("%s: got %v; want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -9,6 +9,9 @@ type SelectClauses interface {
 	Where() ExpressionList
 	WhereAppend(expressions ...Expression) SelectClauses
 
+	Having() ExpressionList
+	HavingAppend(expressions ...Expression) SelectClauses
+
 	Order() ColumnListExpression
 	SetOrder(oes ...OrderedExpression) SelectClauses
 
@@ -22,8 +25,11 @@ type SelectClauses interface {
 	Interval() string
 	SetInterval(interval string) SelectClauses
 
-	Fill() interface{}
-	SetFill(fill interface{}) SelectClauses
+	Window() Window
+	SetWindow(w Window) SelectClauses
+
+	Fill() FillExpression
+	SetFill(fill FillExpression) SelectClauses
 
 	Limit() interface{}
 	ClearLimit() SelectClauses
@@ -32,6 +38,13 @@ type SelectClauses interface {
 	Offset() uint
 	SetOffset(offset uint) SelectClauses
 
+	SLimit() interface{}
+	ClearSLimit() SelectClauses
+	SetSLimit(slimit interface{}) SelectClauses
+
+	SOffset() uint
+	SetSOffset(soffset uint) SelectClauses
+
 	Distinct() ColumnListExpression
 	SetDistinct(cle ColumnListExpression) SelectClauses
 
@@ -41,6 +54,9 @@ type SelectClauses interface {
 	Timezone() string
 	SetTimezone(tz string) SelectClauses
 
+	Into() string
+	SetInto(into string) SelectClauses
+
 	Clone() *selectClauses
 	Clear()
 }
@@ -50,14 +66,18 @@ type selectClauses struct {
 	distinct      ColumnListExpression
 	from          ColumnListExpression
 	where         ExpressionList
+	having        ExpressionList
 	order         ColumnListExpression
 	partitionBy   ColumnListExpression
 	groupBy       ColumnListExpression
-	fill          interface{}
+	window        Window
+	fill          FillExpression
 	limit         interface{}
-	interval      string
+	slimit        interface{}
 	timezone      string
+	into          string
 	offset        uint
+	soffset       uint
 }
 
 func newSelectClauses() SelectClauses {
@@ -109,6 +129,24 @@ func (sc *selectClauses) WhereAppend(expressions ...Expression) SelectClauses {
 	return sc
 }
 
+func (sc *selectClauses) Having() ExpressionList {
+	return sc.having
+}
+
+func (sc *selectClauses) HavingAppend(expressions ...Expression) SelectClauses {
+	if len(expressions) == 0 {
+		return sc
+	}
+
+	if sc.having == nil {
+		sc.having = NewExpressionList(AndType, expressions...)
+	} else {
+		sc.having = sc.having.Append(expressions...)
+	}
+
+	return sc
+}
+
 func (sc *selectClauses) Order() ColumnListExpression {
 	return sc.order
 }
@@ -149,21 +187,42 @@ func (sc *selectClauses) SetPartitionBy(cl ColumnListExpression) SelectClauses {
 	return sc
 }
 
+// Interval 返回 INTERVAL 窗口的字符串形式，非 INTERVAL 窗口返回空字符串。
 func (sc *selectClauses) Interval() string {
-	return sc.interval
+	if sc.window == nil || sc.window.Type() != IntervalWindowType {
+		return ""
+	}
+
+	if s, ok := sc.window.Interval().(string); ok {
+		return s
+	}
+
+	return ""
 }
 
 func (sc *selectClauses) SetInterval(interval string) SelectClauses {
-	sc.interval = interval
+	if interval == "" {
+		return sc.SetWindow(nil)
+	}
+
+	return sc.SetWindow(NewIntervalWindow(interval))
+}
+
+func (sc *selectClauses) Window() Window {
+	return sc.window
+}
+
+func (sc *selectClauses) SetWindow(w Window) SelectClauses {
+	sc.window = w
 
 	return sc
 }
 
-func (sc *selectClauses) Fill() interface{} {
+func (sc *selectClauses) Fill() FillExpression {
 	return sc.fill
 }
 
-func (sc *selectClauses) SetFill(fill interface{}) SelectClauses {
+func (sc *selectClauses) SetFill(fill FillExpression) SelectClauses {
 	sc.fill = fill
 
 	return sc
@@ -195,6 +254,32 @@ func (sc *selectClauses) SetOffset(offset uint) SelectClauses {
 	return sc
 }
 
+func (sc *selectClauses) SLimit() interface{} {
+	return sc.slimit
+}
+
+func (sc *selectClauses) ClearSLimit() SelectClauses {
+	sc.slimit = nil
+
+	return sc
+}
+
+func (sc *selectClauses) SetSLimit(slimit interface{}) SelectClauses {
+	sc.slimit = slimit
+
+	return sc
+}
+
+func (sc *selectClauses) SOffset() uint {
+	return sc.soffset
+}
+
+func (sc *selectClauses) SetSOffset(soffset uint) SelectClauses {
+	sc.soffset = soffset
+
+	return sc
+}
+
 func (sc *selectClauses) Distinct() ColumnListExpression {
 	return sc.distinct
 }
@@ -231,26 +316,45 @@ func (sc *selectClauses) Clone() *selectClauses {
 		distinct:      sc.distinct,
 		from:          sc.from,
 		where:         sc.where,
-		interval:      sc.interval,
+		having:        sc.having,
+		window:        sc.window,
 		order:         sc.order,
+		partitionBy:   sc.partitionBy,
 		groupBy:       sc.groupBy,
 		fill:          sc.fill,
 		limit:         sc.limit,
 		offset:        sc.offset,
+		slimit:        sc.slimit,
+		soffset:       sc.soffset,
 		timezone:      sc.timezone,
+		into:          sc.into,
 	}
 }
 
 func (sc *selectClauses) Clear() {
 	sc.ClearLimit()
+	sc.ClearSLimit()
 	sc.SetSelect(newColumnListExpression(Star())).SetDistinct(nil)
 	sc.from = nil
 	sc.where = nil
-	sc.interval = ""
+	sc.having = nil
+	sc.window = nil
 	sc.partitionBy = nil
 	sc.order = nil
 	sc.groupBy = nil
 	sc.fill = nil
 	sc.offset = 0
+	sc.soffset = 0
 	sc.timezone = ""
+	sc.into = ""
+}
+
+func (sc *selectClauses) Into() string {
+	return sc.into
+}
+
+func (sc *selectClauses) SetInto(into string) SelectClauses {
+	sc.into = into
+
+	return sc
 }
@@ -0,0 +1,65 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHavingSQL(t *testing.T) {
+	runSQLTests(t, []sqlTest{
+		{
+			name: "having",
+			qb: From("cpu").Select("host", Avg("v").As("avg")).GroupBy("host").
+				Having(Avg("v").Gt(10)),
+			sql: "SELECT host, AVG(v) AS avg FROM cpu GROUP BY host HAVING (AVG(v) > 10)",
+		},
+		{
+			name: "having appended",
+			qb: From("cpu").Select(Count(Star())).Window(NewIntervalWindow(time.Minute)).Fill(FillNull()).
+				Having(Count(Star()).Gt(0)).Having(Max("v").Lt(100)).Order(C("ts").Desc()).Limit(5),
+			sql: "SELECT COUNT(*) FROM cpu INTERVAL(1m) FILL(NULL) HAVING ((COUNT(*) > 0) AND (MAX(v) < 100)) ORDER BY ts DESC LIMIT 5",
+		},
+		{
+			name:    "influxql having",
+			qb:      From("cpu").Dialect(InfluxQLDialectOptions()).Select(Mean("v")).Having(Mean("v").Gt(1)),
+			wantErr: ErrHavingNotSupported.Error(),
+		},
+	})
+}
+
+func TestHavingClone(t *testing.T) {
+	qb := From("cpu").Select(Sum("v")).GroupBy("host").Having(Sum("v").Gt(1))
+	clone := qb.Clone().Having(Sum("v").Lt(9))
+
+	runSQLTests(t, []sqlTest{
+		{name: "original", qb: qb, sql: "SELECT SUM(v) FROM cpu GROUP BY host HAVING (SUM(v) > 1)"},
+		{name: "clone", qb: clone, sql: "SELECT SUM(v) FROM cpu GROUP BY host HAVING ((SUM(v) > 1) AND (SUM(v) < 9))"},
+	})
+}
+
+func TestSeriesLimitSQL(t *testing.T) {
+	runSQLTests(t, []sqlTest{
+		{
+			name: "tdengine slimit before limit",
+			qb: From("cpu").Select("ts", "v").PartitionBy("tbname").Order(C("ts").Desc()).
+				Limit(10).Offset(20).SLimit(5).SOffset(10),
+			sql: "SELECT ts, v FROM cpu PARTITION BY tbname ORDER BY ts DESC SLIMIT 5 SOFFSET 10 LIMIT 10 OFFSET 20",
+		},
+		{
+			name: "tdengine slimit only",
+			qb:   From("cpu").Select(Avg("v")).PartitionBy("tbname").SLimit(3),
+			sql:  "SELECT AVG(v) FROM cpu PARTITION BY tbname SLIMIT 3",
+		},
+		{
+			name: "influxql slimit after limit",
+			qb: From("cpu").Dialect(InfluxQLDialectOptions()).Select("v").GroupBy("host").
+				Limit(10).Offset(20).SLimit(5).SOffset(10),
+			sql: "SELECT v FROM cpu GROUP BY host LIMIT 10 OFFSET 20 SLIMIT 5 SOFFSET 10",
+		},
+		{
+			name: "non positive slimit clears",
+			qb:   From("cpu").Select("v").SLimit(5).SLimit(0),
+			sql:  "SELECT v FROM cpu",
+		},
+	})
+}
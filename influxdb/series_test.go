@@ -0,0 +1,76 @@
+package influxdb_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+func TestEachSeriesPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  *influxdb.SQLDialectOptions
+		qb       func() *influxdb.QueryBuilder
+		pageSize int
+		pages    []int
+		keyword  string
+	}{
+		{
+			name: "tdengine",
+			qb: func() *influxdb.QueryBuilder {
+				return influxdb.From("cpu").Select("host", influxdb.Count(influxdb.Star()).As("n")).PartitionBy("host")
+			},
+			pageSize: 2,
+			pages:    []int{2, 2, 1},
+			keyword:  "SLIMIT",
+		},
+		{
+			name: "influxql",
+			qb: func() *influxdb.QueryBuilder {
+				return influxdb.From("cpu").Dialect(influxdb.InfluxQLDialectOptions()).
+					Select(influxdb.Count("value").As("n")).GroupBy("host")
+			},
+			pageSize: 3,
+			pages:    []int{3, 2},
+			keyword:  "SLIMIT",
+		},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t)
+		insertCPU(srv, []string{"a", "b", "c", "d", "e"}, 3)
+
+		var pages []int
+
+		err := influxdb.EachSeriesPage(context.Background(), db, test.qb(), test.pageSize, func(page []map[string]any) error {
+			pages = append(pages, len(page))
+
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: EachSeriesPage() error: %v", test.name, err)
+
+			continue
+		}
+
+		if len(pages) != len(test.pages) {
+			t.Errorf("%s: pages = %v; want %v", test.name, pages, test.pages)
+
+			continue
+		}
+
+		for j := range pages {
+			if pages[j] != test.pages[j] {
+				t.Errorf("%s: pages = %v; want %v", test.name, pages, test.pages)
+
+				break
+			}
+		}
+
+		if q := srv.LastQuery(); !strings.Contains(q, test.keyword) {
+			t.Errorf("%s: last query %q does not contain %s", test.name, q, test.keyword)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package influxdb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+	"github.com/jiurenm/mare/influxdb/influxdbtest"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestDB 返回连接到 fake server 的 InfluxDB，modify 可以修改连接配置。
+func newTestDB(t *testing.T, modify ...func(cfg *influxdb.Config)) (*influxdb.InfluxDB, *influxdbtest.Server) {
+	t.Helper()
+
+	srv := influxdbtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cfg := srv.Config()
+	for _, m := range modify {
+		m(&cfg)
+	}
+
+	db, closeDB, err := influxdb.NewInfluxDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(closeDB)
+
+	return db, srv
+}
+
+// insertCPU 写入 hosts 个主机每分钟一个点，共 n 分钟，value 为分钟序号。
+func insertCPU(srv *influxdbtest.Server, hosts []string, n int) {
+	for _, host := range hosts {
+		for j := 0; j < n; j++ {
+			srv.Insert(influxdbtest.Point{
+				Measurement: "cpu",
+				Time:        epoch.Add(time.Duration(j) * time.Minute),
+				Tags:        map[string]string{"host": host},
+				Fields:      map[string]any{"value": float64(j)},
+			})
+		}
+	}
+}
+
+// cpuPoint 为写入 cpu 的 Writable，时间戳单位为秒。
+type cpuPoint struct {
+	ts    time.Time
+	host  string
+	value float64
+}
+
+func (c cpuPoint) Measurement() string { return "cpu" }
+func (c cpuPoint) Tags() []byte        { return []byte("host=" + c.host) }
+func (c cpuPoint) Fields() []byte      { return []byte(fmt.Sprintf("value=%v", c.value)) }
+func (c cpuPoint) Timestamp() int64    { return c.ts.Unix() }
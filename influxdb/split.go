@@ -0,0 +1,527 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mr "github.com/jiurenm/mare/syncx/mapreduce"
+)
+
+const defaultSplitWorkers = 4
+
+var (
+	// ErrSplitNotMergeable 表示查询包含无法跨时间段合并的聚合函数，如 AVG。
+	ErrSplitNotMergeable = errors.New("aggregate can not be merged across split time ranges")
+	// ErrSplitNotSupported 表示查询包含拆分后结果不正确的子句，如 OFFSET、SLIDING 或按月划分的窗口。
+	ErrSplitNotSupported = errors.New("query can not be split across time ranges")
+)
+
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SplitPlan 将一个时间范围查询拆分为多个子时间段并发执行，再按时间顺序合并结果。
+//
+// 没有聚合函数或带有 INTERVAL 窗口的查询按子时间段顺序拼接；不带窗口的 SUM、COUNT、MIN、MAX
+// 聚合按非聚合列分组后重新聚合，其他聚合函数返回 ErrSplitNotMergeable。合并后按 ORDER BY 排序再应用 LIMIT。
+//
+// 窗口必须是固定步长的 INTERVAL 窗口，OFFSET、SLIMIT、SOFFSET、SLIDING 以及重新聚合时的 HAVING
+// 拆分后结果不正确，返回 ErrSplitNotSupported。conn 为 *InfluxDB 时合并使用原始的 NULL，
+// 其他 Querier 的 Query2 已将 NULL 转换为 0。
+type SplitPlan struct {
+	qb      *QueryBuilder
+	timeCol string
+	start   time.Time
+	end     time.Time
+	parts   int
+	workers int
+}
+
+// Split 将 [start, end) 拆分为 parts 个子时间段，子时间段的边界对齐到 INTERVAL 窗口。
+func (qb *QueryBuilder) Split(timeCol string, start, end time.Time, parts int) *SplitPlan {
+	return &SplitPlan{
+		qb:      qb,
+		timeCol: timeCol,
+		start:   start,
+		end:     end,
+		parts:   parts,
+		workers: defaultSplitWorkers,
+	}
+}
+
+// Workers 设置并发执行子查询的数量上限，默认为 4。
+func (sp *SplitPlan) Workers(workers int) *SplitPlan {
+	sp.workers = workers
+
+	return sp
+}
+
+// Ranges 返回拆分后的子时间段。
+func (sp *SplitPlan) Ranges() ([]TimeRange, error) {
+	if !sp.start.Before(sp.end) {
+		return nil, fmt.Errorf("invalid split range [%v, %v)", sp.start, sp.end)
+	}
+
+	if sp.parts <= 0 {
+		return nil, fmt.Errorf("invalid split parts %d", sp.parts)
+	}
+
+	step, offset, err := sp.alignment()
+	if err != nil {
+		return nil, err
+	}
+
+	span := sp.end.Sub(sp.start) / time.Duration(sp.parts)
+	ranges := make([]TimeRange, 0, sp.parts)
+	lo := sp.start
+
+	for i := 1; i < sp.parts; i++ {
+		hi := sp.start.Add(span * time.Duration(i))
+		if step > 0 {
+			hi = hi.Add(-offset).Truncate(step).Add(offset)
+		}
+
+		if !hi.After(lo) {
+			continue
+		}
+
+		ranges = append(ranges, TimeRange{Start: lo, End: hi})
+		lo = hi
+	}
+
+	return append(ranges, TimeRange{Start: lo, End: sp.end}), nil
+}
+
+// Query 并发执行所有子查询并将合并后的结果解析到 dst，结束后 qb 被回收。
+func (sp *SplitPlan) Query(ctx context.Context, conn Querier, dst interface{}) error {
+	defer sp.qb.release()
+
+	if err := sp.check(); err != nil {
+		return err
+	}
+
+	ranges, err := sp.Ranges()
+	if err != nil {
+		return err
+	}
+
+	merge, reaggregate, err := sp.merger()
+	if err != nil {
+		return err
+	}
+
+	// 以整个时间范围检查，guard 添加的 LIMIT 由各子查询继承；检查失败时 guard 只回收副本。
+	bounded := sp.qb.Clone().Where(C(sp.timeCol).Gte(sp.start), C(sp.timeCol).Lt(sp.end))
+	if err = guard(conn, bounded); err != nil {
+		return err
+	}
+
+	sp.qb.clauses.SetLimit(bounded.clauses.Limit())
+	bounded.release()
+
+	type part struct {
+		rows  []map[string]any
+		index int
+	}
+
+	parts, err := mr.MapReduce(func(source chan<- int) {
+		for i := range ranges {
+			source <- i
+		}
+	}, func(i int, writer mr.Writer[part], cancel func(error)) {
+		q := sp.qb.Clone().Where(C(sp.timeCol).Gte(ranges[i].Start), C(sp.timeCol).Lt(ranges[i].End))
+		// 各时间段的部分聚合结果需要完整合并后才能排序和截取。
+		if reaggregate {
+			q.Limit(0)
+		}
+
+		sql, tz, err := q.ToSQL()
+		if err != nil {
+			cancel(err)
+
+			return
+		}
+
+		rows, err := fetchRows(ctx, conn, sql, tz)
+		if err != nil && !errors.Is(err, ErrNoData) {
+			cancel(err)
+
+			return
+		}
+
+		writer.Write(part{rows: rows, index: i})
+	}, func(pipe <-chan part, writer mr.Writer[[][]map[string]any], cancel func(error)) {
+		res := make([][]map[string]any, len(ranges))
+		for p := range pipe {
+			res[p.index] = p.rows
+		}
+
+		writer.Write(res)
+	}, mr.WithContext(ctx), mr.WithWorkers(sp.workers))
+	if err != nil {
+		return err
+	}
+
+	rows := merge(parts)
+	if len(rows) == 0 {
+		return ErrNoData
+	}
+
+	sp.sortRows(rows)
+
+	if limit, ok := sp.qb.clauses.Limit().(int); ok && limit < len(rows) {
+		rows = rows[:limit]
+	}
+
+	return decodeRows(zeroNulls(rows), dst)
+}
+
+// check 拒绝拆分后结果不正确的查询。
+func (sp *SplitPlan) check() error {
+	sc := sp.qb.clauses
+
+	switch {
+	case sc.Offset() > 0:
+		return fmt.Errorf("%w: OFFSET", ErrSplitNotSupported)
+	case sc.SLimit() != nil || sc.SOffset() > 0:
+		return fmt.Errorf("%w: SLIMIT and SOFFSET", ErrSplitNotSupported)
+	case sc.Window() != nil && sc.Window().Sliding() != nil:
+		return fmt.Errorf("%w: SLIDING", ErrSplitNotSupported)
+	}
+
+	return nil
+}
+
+// sortRows 按 qb 的 ORDER BY 对合并后的行排序，没有 ORDER BY 时保持时间段的顺序。
+func (sp *SplitPlan) sortRows(rows []map[string]any) {
+	order := sp.qb.clauses.Order()
+	if order == nil || order.IsEmpty() {
+		return
+	}
+
+	type key struct {
+		name string
+		asc  bool
+	}
+
+	keys := make([]key, 0, len(order.Columns()))
+
+	for _, col := range order.Columns() {
+		o, ok := col.(OrderedExpression)
+		if !ok {
+			continue
+		}
+
+		keys = append(keys, key{name: sp.columnName(o.SortExpression()), asc: o.IsAsc()})
+	}
+
+	sort.SliceStable(rows, func(a, b int) bool {
+		for _, k := range keys {
+			c := compareValues(lookupColumn(rows[a], k.name), lookupColumn(rows[b], k.name))
+			if c == 0 {
+				continue
+			}
+
+			return (c < 0) == k.asc
+		}
+
+		return false
+	})
+}
+
+// columnName 返回表达式在结果中的列名：标识符为列名，其他表达式为按 qb 的方言生成的 SQL。
+func (sp *SplitPlan) columnName(e Expression) string {
+	switch t := e.(type) {
+	case IdentifierExpression:
+		if col, ok := t.GetCol().(string); ok {
+			return col
+		}
+	case AliasedExpression:
+		if col, ok := t.GetAs().GetCol().(string); ok {
+			return col
+		}
+	}
+
+	sb := newSQLBuilder(false)
+	newExpressionSQLGenerator(sp.qb.dialectOptions).Generate(sb, e)
+	name, _ := sb.ToSQL()
+
+	return name
+}
+
+func lookupColumn(row map[string]any, name string) any {
+	if v, ok := row[name]; ok {
+		return v
+	}
+
+	for col, v := range row {
+		if strings.EqualFold(col, name) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// compareValues 比较两个结果值，NULL 最小，数字按大小、其他值按字符串比较。
+func compareValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	x, okA := toFloat(a)
+	y, okB := toFloat(b)
+
+	if okA && okB {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+
+		return 0
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// alignment 返回 INTERVAL 窗口的步长和偏移，没有窗口时步长为 0，步长不固定（如 1n、1y）时返回错误。
+func (sp *SplitPlan) alignment() (time.Duration, time.Duration, error) {
+	w := sp.qb.timezoneWindow()
+	if w == nil {
+		w = sp.qb.clauses.Window()
+	}
+
+	if w == nil {
+		return 0, 0, nil
+	}
+
+	if w.Type() != IntervalWindowType {
+		return 0, 0, fmt.Errorf("%w: %v windows", ErrSplitNotSupported, w.Type())
+	}
+
+	step, ok := windowDuration(w.Interval())
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: INTERVAL(%v) has no fixed length", ErrSplitNotSupported, w.Interval())
+	}
+
+	offset, _ := windowDuration(w.Offset())
+
+	return step, offset, nil
+}
+
+type splitMerger func(parts [][]map[string]any) []map[string]any
+
+// merger 返回合并子查询结果的方法，reaggregate 为 true 时需要重新聚合各时间段的部分结果。
+func (sp *SplitPlan) merger() (splitMerger, bool, error) {
+	if sp.qb.clauses.Window() != nil || sp.qb.clauses.IsDefaultSelect() {
+		return concatRows, false, nil
+	}
+
+	aggs := map[string]string{}
+
+	for _, col := range sp.qb.clauses.Select().Columns() {
+		var fn SQLFunctionExpression
+
+		switch t := col.(type) {
+		case SQLFunctionExpression:
+			fn = t
+		case AliasedExpression:
+			f, ok := t.Aliased().(SQLFunctionExpression)
+			if !ok {
+				continue
+			}
+
+			fn = f
+		default:
+			continue
+		}
+
+		name := sp.columnName(col.(Expression))
+
+		switch strings.ToUpper(fn.Name()) {
+		case "SUM", "COUNT":
+			aggs[strings.ToLower(name)] = "SUM"
+		case "MIN", "MAX":
+			aggs[strings.ToLower(name)] = strings.ToUpper(fn.Name())
+		default:
+			return nil, false, fmt.Errorf("%w: %s", ErrSplitNotMergeable, fn.Name())
+		}
+	}
+
+	if len(aggs) == 0 {
+		return concatRows, false, nil
+	}
+
+	if having := sp.qb.clauses.Having(); having != nil && !having.IsEmpty() {
+		return nil, false, fmt.Errorf("%w: HAVING on re-aggregated results", ErrSplitNotSupported)
+	}
+
+	return func(parts [][]map[string]any) []map[string]any {
+		return reaggregateRows(parts, aggs)
+	}, true, nil
+}
+
+func concatRows(parts [][]map[string]any) []map[string]any {
+	var rows []map[string]any
+	for _, p := range parts {
+		rows = append(rows, p...)
+	}
+
+	return rows
+}
+
+// reaggregateRows 按非聚合列分组，合并各子时间段的聚合结果，分组保持首次出现的顺序。
+func reaggregateRows(parts [][]map[string]any, aggs map[string]string) []map[string]any {
+	var (
+		rows []map[string]any
+		keys = map[string]int{}
+	)
+
+	for _, p := range parts {
+		for _, row := range p {
+			key := groupKey(row, aggs)
+
+			i, ok := keys[key]
+			if !ok {
+				keys[key] = len(rows)
+				rows = append(rows, row)
+
+				continue
+			}
+
+			for col, v := range row {
+				agg, ok := aggs[strings.ToLower(col)]
+				if !ok {
+					continue
+				}
+
+				rows[i][col] = combine(agg, rows[i][col], v)
+			}
+		}
+	}
+
+	return rows
+}
+
+func groupKey(row map[string]any, aggs map[string]string) string {
+	cols := make(map[string]interface{}, len(row))
+	for col, v := range row {
+		if _, ok := aggs[strings.ToLower(col)]; !ok {
+			cols[col] = v
+		}
+	}
+
+	var b strings.Builder
+
+	for _, col := range getExMapKeys(cols) {
+		fmt.Fprintf(&b, "%s=%v;", col, cols[col])
+	}
+
+	return b.String()
+}
+
+func combine(agg string, a, b any) any {
+	x, okA := toFloat(a)
+	y, okB := toFloat(b)
+
+	if !okA {
+		return b
+	}
+
+	if !okB {
+		return a
+	}
+
+	switch agg {
+	case "MIN":
+		if y < x {
+			return y
+		}
+
+		return x
+	case "MAX":
+		if y > x {
+			return y
+		}
+
+		return x
+	}
+
+	return x + y
+}
+
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	}
+
+	return 0, false
+}
+
+// windowDuration 解析窗口参数，支持 time.Duration 和 1d、15m 这样的固定时长字面量。
+func windowDuration(v interface{}) (time.Duration, bool) {
+	switch t := v.(type) {
+	case time.Duration:
+		return t, t > 0
+	case string:
+		return parseDurationLiteral(t)
+	}
+
+	return 0, false
+}
+
+var durationLiteralUnits = map[string]time.Duration{
+	"b":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"a":  time.Millisecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+func parseDurationLiteral(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	if i == 0 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	unit, ok := durationLiteralUnits[s[i:]]
+	if !ok {
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, n > 0
+}
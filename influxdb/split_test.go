@@ -0,0 +1,226 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+	"github.com/jiurenm/mare/influxdb/influxdbtest"
+)
+
+func TestSplitPlanQuery(t *testing.T) {
+	end := epoch.Add(10 * time.Minute)
+
+	tests := []struct {
+		name    string
+		qb      *influxdb.QueryBuilder
+		col     string
+		want    []string
+		wantErr error
+	}{
+		{
+			// 各时间段分别按倒序取 3 行，合并后重新排序，取全局最新的 3 行。
+			name: "order by desc then limit",
+			qb:   influxdb.From("cpu").Select("ts", "value").Order(influxdb.C("ts").Desc()).Limit(3),
+			col:  "value",
+			want: []string{"9", "8", "7"},
+		},
+		{
+			name: "order by value asc",
+			qb:   influxdb.From("cpu").Select("ts", "value").Order(influxdb.C("value").Asc()).Limit(2),
+			col:  "value",
+			want: []string{"0", "1"},
+		},
+		{
+			name: "sum by host",
+			qb: influxdb.From("cpu").Select("host", influxdb.Sum("value").As("s")).
+				GroupBy("host").Order(influxdb.C("host").Asc()),
+			col:  "s",
+			want: []string{"45"},
+		},
+		{
+			name: "unaliased count",
+			qb:   influxdb.From("cpu").Select(influxdb.Count("value")),
+			col:  "COUNT(value)",
+			want: []string{"10"},
+		},
+		{
+			name:    "offset",
+			qb:      influxdb.From("cpu").Select("ts", "value").Limit(2).Offset(1),
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name:    "series limit",
+			qb:      influxdb.From("cpu").Select("ts", "value").SLimit(1),
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name: "sliding",
+			qb: influxdb.From("cpu").Select(influxdb.Sum("value")).
+				Window(influxdb.NewIntervalWindow(2 * time.Minute).WithSliding(time.Minute)),
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name:    "calendar interval",
+			qb:      influxdb.From("cpu").Select(influxdb.Sum("value")).Window(influxdb.NewIntervalWindow("1n")),
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name:    "state window",
+			qb:      influxdb.From("cpu").Select(influxdb.Count("value")).Window(influxdb.NewStateWindow("host")),
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name: "having on reaggregated results",
+			qb: influxdb.From("cpu").Select(influxdb.Sum("value").As("s")).
+				Having(influxdb.C("s").Gt(10)),
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name:    "avg",
+			qb:      influxdb.From("cpu").Select(influxdb.Avg("value")),
+			wantErr: influxdb.ErrSplitNotMergeable,
+		},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t)
+		insertCPU(srv, []string{"a"}, 10)
+
+		var rows []map[string]any
+
+		err := test.qb.Split("ts", epoch, end, 2).Query(context.Background(), db, &rows)
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s: error = %v; want %v", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		got := make([]string, 0, len(rows))
+		for _, row := range rows {
+			got = append(got, fmt.Sprint(row[test.col]))
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: %s = %v; want %v", test.name, test.col, got, test.want)
+		}
+	}
+}
+
+func TestSplitPlanMinWithNulls(t *testing.T) {
+	db, srv := newTestDB(t)
+
+	// 第一个时间段的 value 全部为 NULL，MIN 的部分结果为 NULL，不能按 0 合并。
+	for j := 0; j < 10; j++ {
+		fields := map[string]any{"other": float64(j)}
+		if j >= 5 {
+			fields["value"] = float64(j)
+		}
+
+		srv.Insert(influxdbtest.Point{
+			Measurement: "cpu",
+			Time:        epoch.Add(time.Duration(j) * time.Minute),
+			Tags:        map[string]string{"host": "a"},
+			Fields:      fields,
+		})
+	}
+
+	var rows []map[string]any
+
+	err := influxdb.From("cpu").Select(influxdb.Min("value").As("m")).
+		Split("ts", epoch, epoch.Add(10*time.Minute), 2).
+		Query(context.Background(), db, &rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 1 || fmt.Sprint(rows[0]["m"]) != "5" {
+		t.Errorf("MIN(value) = %v; want 5", rows)
+	}
+}
+
+func TestSplitRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		qb      *influxdb.QueryBuilder
+		parts   int
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "no window",
+			qb:    influxdb.From("cpu"),
+			parts: 3,
+			want:  []string{"00:00-00:20", "00:20-00:40", "00:40-01:00"},
+		},
+		{
+			name:  "aligned to interval",
+			qb:    influxdb.From("cpu").Window(influxdb.NewIntervalWindow(15 * time.Minute)),
+			parts: 3,
+			want:  []string{"00:00-00:15", "00:15-00:30", "00:30-01:00"},
+		},
+		{
+			name:    "calendar interval",
+			qb:      influxdb.From("cpu").Window(influxdb.NewIntervalWindow("1y")),
+			parts:   2,
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name:    "state window",
+			qb:      influxdb.From("cpu").Window(influxdb.NewStateWindow("status")),
+			parts:   2,
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name:    "session window",
+			qb:      influxdb.From("cpu").Window(influxdb.NewSessionWindow("ts", 10*time.Second)),
+			parts:   2,
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+		{
+			name: "event window",
+			qb: influxdb.From("cpu").Window(influxdb.NewEventWindow(
+				influxdb.C("value").Gt(10), influxdb.C("value").Lt(5),
+			)),
+			parts:   2,
+			wantErr: influxdb.ErrSplitNotSupported,
+		},
+	}
+
+	for _, test := range tests {
+		ranges, err := test.qb.Split("ts", epoch, epoch.Add(time.Hour), test.parts).Ranges()
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s: error = %v; want %v", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		got := make([]string, 0, len(ranges))
+		for _, r := range ranges {
+			got = append(got, r.Start.Format("15:04")+"-"+r.End.Format("15:04"))
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: Ranges() = %v; want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -1,21 +1,30 @@
 package influxdb
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
+)
+
+var (
+	ErrHavingNotSupported  = errors.New("HAVING clause not supported by dialect")
+	ErrIntoNotSupported    = errors.New("SELECT INTO not supported by dialect")
+	ErrSlidingNotSupported = errors.New("SLIDING window not supported by dialect")
 )
 
 type SQLDialect interface {
 	ToSelectSQL(sb SQLBuilder, clauses SelectClauses)
+	ToDeleteSQL(sb SQLBuilder, clauses DeleteClauses)
 }
 
 type sqlDialect struct {
 	selectGen SelectSQLGenerator
+	deleteGen DeleteSQLGenerator
 }
 
 func newDialect(do *SQLDialectOptions) SQLDialect {
 	return &sqlDialect{
 		selectGen: newSelectSQLGenerator(do),
+		deleteGen: newDeleteSQLGenerator(do),
 	}
 }
 
@@ -23,6 +32,10 @@ func (sd *sqlDialect) ToSelectSQL(sb SQLBuilder, clauses SelectClauses) {
 	sd.selectGen.Generate(sb, clauses)
 }
 
+func (sd *sqlDialect) ToDeleteSQL(sb SQLBuilder, clauses DeleteClauses) {
+	sd.deleteGen.Generate(sb, clauses)
+}
+
 type SelectSQLGenerator interface {
 	Generate(sb SQLBuilder, clauses SelectClauses)
 }
@@ -48,6 +61,8 @@ func (ssg *selectSQLGenerator) Generate(sb SQLBuilder, clauses SelectClauses) {
 		switch f {
 		case SelectSQLFragment:
 			ssg.SelectSQL(sb, clauses)
+		case IntoSQLFragment:
+			ssg.IntoSQL(sb, clauses.Into())
 		case FromSQLFragment:
 			ssg.FromSQL(sb, clauses.From())
 		case WhereSQLFragment:
@@ -55,17 +70,23 @@ func (ssg *selectSQLGenerator) Generate(sb SQLBuilder, clauses SelectClauses) {
 		case PartitionBySQLFragment:
 			ssg.PartitionBySQL(sb, clauses.PartitionBy())
 		case GroupBySQLFragment:
-			ssg.GroupBySQL(sb, clauses.GroupBy())
+			ssg.GroupBySQL(sb, clauses.GroupBy(), clauses.Window())
 		case IntervalFragment:
-			ssg.IntervalSQL(sb, clauses.Interval())
+			ssg.WindowSQL(sb, clauses.Window())
 		case FillSQLFragment:
 			ssg.FillSQL(sb, clauses.Fill())
+		case HavingSQLFragment:
+			ssg.HavingSQL(sb, clauses.Having())
 		case OrderSQLFragment:
 			ssg.OrderSQL(sb, clauses.Order())
 		case LimitSQLFragment:
 			ssg.LimitSQL(sb, clauses.Limit())
 		case OffsetSQLFragment:
 			ssg.OffsetSQL(sb, clauses.Offset())
+		case SLimitSQLFragment:
+			ssg.SLimitSQL(sb, clauses.SLimit())
+		case SOffsetSQLFragment:
+			ssg.SOffsetSQL(sb, clauses.SOffset())
 		case TimezoneSQLFragment:
 			// ssg.TimezoneSQL(sb, clauses.Timezone())
 		default:
@@ -87,6 +108,20 @@ func (ssg *selectSQLGenerator) SelectSQL(sb SQLBuilder, clauses SelectClauses) {
 	ssg.selectSQLCommon(sb, clauses)
 }
 
+// IntoSQL 输出 InfluxQL 的 SELECT ... INTO，into 原样输出以支持 :MEASUREMENT 等反向引用。
+func (ssg *selectSQLGenerator) IntoSQL(sb SQLBuilder, into string) {
+	if into == "" {
+		return
+	}
+
+	if ssg.DialectOptions().IntoFragment == nil {
+		sb.SetError(ErrIntoNotSupported)
+		return
+	}
+
+	sb.Write(ssg.DialectOptions().IntoFragment).WriteStrings(into)
+}
+
 func (ssg *selectSQLGenerator) PartitionBySQL(sb SQLBuilder, partitionBy ColumnListExpression) {
 	if partitionBy != nil && len(partitionBy.Columns()) > 0 {
 		sb.Write(ssg.DialectOptions().PartitionByFragment)
@@ -94,42 +129,166 @@ func (ssg *selectSQLGenerator) PartitionBySQL(sb SQLBuilder, partitionBy ColumnL
 	}
 }
 
-func (ssg *selectSQLGenerator) GroupBySQL(sb SQLBuilder, groupBy ColumnListExpression) {
-	if groupBy != nil && len(groupBy.Columns()) > 0 {
-		sb.Write(ssg.DialectOptions().GroupByFragment)
-		ssg.ExpressionSQLGenerator().Generate(sb, groupBy)
+func (ssg *selectSQLGenerator) GroupBySQL(sb SQLBuilder, groupBy ColumnListExpression, w Window) {
+	hasGroupBy := groupBy != nil && len(groupBy.Columns()) > 0
+	hasTimeWindow := ssg.DialectOptions().GroupByTimeWindow && w != nil
+
+	if !hasGroupBy && !hasTimeWindow {
+		return
 	}
-}
 
-func (ssg *selectSQLGenerator) FillSQL(sb SQLBuilder, fill interface{}) {
-	if fill != nil {
-		sb.Write(ssg.DialectOptions().FillFragment)
+	sb.Write(ssg.DialectOptions().GroupByFragment)
+
+	if hasTimeWindow {
+		if w.Type() != IntervalWindowType {
+			sb.SetError(errUnsupportedWindowType(w.Type()))
+
+			return
+		}
+
+		if w.Sliding() != nil {
+			sb.SetError(ErrSlidingNotSupported)
+
+			return
+		}
+
+		sb.Write(ssg.DialectOptions().TimeFunctionFragment)
 		sb.WriteRunes(ssg.DialectOptions().LeftParenRune)
+		ssg.windowArgSQL(sb, w.Interval())
 
-		switch v := fill.(type) {
-		case string:
-			sb.WriteStrings(v)
-		case int:
-			sb.WriteStrings("VALUE, ")
-			sb.WriteStrings(strconv.Itoa(v))
-		case float64:
-			sb.WriteStrings("VALUE, ")
-			sb.WriteStrings(strconv.FormatFloat(v, 'b', 10, 64))
-		default:
-			ssg.ExpressionSQLGenerator().Generate(sb, fill)
+		if w.Offset() != nil {
+			sb.WriteRunes(ssg.DialectOptions().CommaRune, ssg.DialectOptions().SpaceRune)
+			ssg.windowArgSQL(sb, w.Offset())
 		}
+
 		sb.WriteRunes(ssg.DialectOptions().RightParenRune)
+
+		if hasGroupBy {
+			sb.WriteRunes(ssg.DialectOptions().CommaRune, ssg.DialectOptions().SpaceRune)
+		}
+	}
+
+	if hasGroupBy {
+		ssg.ExpressionSQLGenerator().Generate(sb, groupBy)
 	}
 }
 
-func (ssg *selectSQLGenerator) IntervalSQL(sb SQLBuilder, interval string) {
-	if interval != "" {
-		sb.Write(ssg.DialectOptions().IntervalFragment)
-		sb.WriteRunes(ssg.DialectOptions().LeftParenRune)
+func (ssg *selectSQLGenerator) FillSQL(sb SQLBuilder, fill FillExpression) {
+	if fill == nil {
+		return
+	}
 
-		sb.WriteStrings(interval)
-		sb.WriteRunes(ssg.DialectOptions().RightParenRune)
+	do := ssg.DialectOptions()
+
+	mode, ok := do.FillModeLookup[fill.Mode()]
+	if !ok {
+		sb.SetError(errUnsupportedFillMode(fill.Mode()))
+
+		return
 	}
+
+	vals := fill.Values()
+	if fill.Mode() == ValueFill && (len(vals) == 0 || (do.FillValueLimit > 0 && len(vals) > do.FillValueLimit)) {
+		sb.SetError(errFillValues(len(vals), do.FillValueLimit))
+
+		return
+	}
+
+	sb.Write(do.FillFragment)
+	sb.WriteRunes(do.LeftParenRune)
+	sb.Write(mode)
+
+	if fill.Mode() == ValueFill {
+		for i, v := range vals {
+			if i > 0 || len(mode) > 0 {
+				sb.WriteRunes(do.CommaRune, do.SpaceRune)
+			}
+
+			ssg.ExpressionSQLGenerator().Generate(sb, v)
+		}
+	}
+
+	sb.WriteRunes(do.RightParenRune)
+}
+
+func (ssg *selectSQLGenerator) HavingSQL(sb SQLBuilder, having ExpressionList) {
+	if having == nil || having.IsEmpty() {
+		return
+	}
+
+	if len(ssg.DialectOptions().HavingFragment) == 0 {
+		sb.SetError(ErrHavingNotSupported)
+
+		return
+	}
+
+	sb.Write(ssg.DialectOptions().HavingFragment)
+	ssg.ExpressionSQLGenerator().Generate(sb, having)
+}
+
+func (ssg *selectSQLGenerator) WindowSQL(sb SQLBuilder, w Window) {
+	if w == nil || ssg.DialectOptions().GroupByTimeWindow {
+		return
+	}
+
+	do := ssg.DialectOptions()
+
+	switch w.Type() {
+	case IntervalWindowType:
+		sb.Write(do.IntervalFragment)
+		sb.WriteRunes(do.LeftParenRune)
+		ssg.windowArgSQL(sb, w.Interval())
+
+		if w.Offset() != nil {
+			sb.WriteRunes(do.CommaRune, do.SpaceRune)
+			ssg.windowArgSQL(sb, w.Offset())
+		}
+
+		sb.WriteRunes(do.RightParenRune)
+
+		if w.Sliding() != nil {
+			if len(do.SlidingFragment) == 0 {
+				sb.SetError(ErrSlidingNotSupported)
+
+				return
+			}
+
+			sb.Write(do.SlidingFragment)
+			sb.WriteRunes(do.LeftParenRune)
+			ssg.windowArgSQL(sb, w.Sliding())
+			sb.WriteRunes(do.RightParenRune)
+		}
+	case StateWindowType:
+		sb.Write(do.StateWindowFragment)
+		sb.WriteRunes(do.LeftParenRune)
+		ssg.ExpressionSQLGenerator().Generate(sb, w.Col())
+		sb.WriteRunes(do.RightParenRune)
+	case SessionWindowType:
+		sb.Write(do.SessionFragment)
+		sb.WriteRunes(do.LeftParenRune)
+		ssg.ExpressionSQLGenerator().Generate(sb, w.Col())
+		sb.WriteRunes(do.CommaRune, do.SpaceRune)
+		ssg.windowArgSQL(sb, w.Tolerance())
+		sb.WriteRunes(do.RightParenRune)
+	case EventWindowType:
+		sb.Write(do.EventWindowStartFragment)
+		ssg.ExpressionSQLGenerator().Generate(sb, w.StartWith())
+		sb.Write(do.EventWindowEndFragment)
+		ssg.ExpressionSQLGenerator().Generate(sb, w.EndWith())
+	default:
+		sb.SetError(errUnsupportedWindowType(w.Type()))
+	}
+}
+
+// windowArgSQL 输出窗口参数，字符串原样输出，如 "1d"。
+func (ssg *selectSQLGenerator) windowArgSQL(sb SQLBuilder, arg interface{}) {
+	if s, ok := arg.(string); ok {
+		sb.WriteStrings(s)
+
+		return
+	}
+
+	ssg.ExpressionSQLGenerator().Generate(sb, arg)
 }
 
 func (ssg *selectSQLGenerator) OffsetSQL(sb SQLBuilder, offset uint) {
@@ -139,6 +298,51 @@ func (ssg *selectSQLGenerator) OffsetSQL(sb SQLBuilder, offset uint) {
 	}
 }
 
+func (ssg *selectSQLGenerator) SLimitSQL(sb SQLBuilder, slimit interface{}) {
+	if slimit != nil {
+		sb.Write(ssg.DialectOptions().SLimitFragment)
+		ssg.ExpressionSQLGenerator().Generate(sb, slimit)
+	}
+}
+
+func (ssg *selectSQLGenerator) SOffsetSQL(sb SQLBuilder, soffset uint) {
+	if soffset > 0 {
+		sb.Write(ssg.DialectOptions().SOffsetFragment)
+		ssg.ExpressionSQLGenerator().Generate(sb, soffset)
+	}
+}
+
+type DeleteSQLGenerator interface {
+	Generate(sb SQLBuilder, clauses DeleteClauses)
+}
+
+type deleteSQLGenerator struct {
+	CommonSQLGenerator
+}
+
+func newDeleteSQLGenerator(do *SQLDialectOptions) DeleteSQLGenerator {
+	return &deleteSQLGenerator{newCommonSQLGenerator(do)}
+}
+
+func (dsg *deleteSQLGenerator) Generate(sb SQLBuilder, clauses DeleteClauses) {
+	for _, f := range dsg.DialectOptions().DeleteSQLOrder {
+		if sb.Error() != nil {
+			return
+		}
+
+		switch f {
+		case DeleteBeginSQLFragment:
+			sb.Write(dsg.DialectOptions().DeleteClause)
+		case FromSQLFragment:
+			dsg.FromSQL(sb, clauses.From())
+		case WhereSQLFragment:
+			dsg.WhereSQL(sb, clauses.Where())
+		default:
+			sb.SetError(ErrNotSupportedFragment("DELETE", f))
+		}
+	}
+}
+
 type CommonSQLGenerator interface {
 	DialectOptions() *SQLDialectOptions
 	ExpressionSQLGenerator() ExpressionSQLGenerator
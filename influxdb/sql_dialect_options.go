@@ -1,7 +1,21 @@
 package influxdb
 
+import "time"
+
 type SQLFragmentType int
 
+// DurationUnit maps a time.Duration to the suffix used by the backend's duration literals.
+type DurationUnit struct {
+	Duration time.Duration
+	Suffix   string
+}
+
+// FunctionArity is the number of arguments a SQL function accepts, a negative Max means no upper bound.
+type FunctionArity struct {
+	Min int
+	Max int
+}
+
 type SQLDialectOptions struct {
 	// EscapedRunes is a map of a rune and the corresponding escape sequence in bytes. Used when escaping text
 	// types.
@@ -43,6 +57,10 @@ type SQLDialectOptions struct {
 	LimitFragment []byte
 	// The SQL OFFSET BY clause fragment(DEFAULT=[]byte(" OFFSET "))
 	OffsetFragment []byte
+	// The SQL SLIMIT clause fragment(DEFAULT=[]byte(" SLIMIT "))
+	SLimitFragment []byte
+	// The SQL SOFFSET clause fragment(DEFAULT=[]byte(" SOFFSET "))
+	SOffsetFragment []byte
 	// The SQL TZ BY clause fragment(DEFAULT=[]byte(" TZ"))
 	TimezoneFragment []byte
 	// The SQL AS fragment when aliasing an Expression(DEFAULT=[]byte(" AS "))
@@ -51,6 +69,35 @@ type SQLDialectOptions struct {
 	AscFragment []byte
 	// The NULL literal to use when interpolating nulls values (DEFAULT=[]byte("NULL"))
 	Null []byte
+	// The TRUE literal to use when interpolating bool values (DEFAULT=[]byte("true"))
+	True []byte
+	// The FALSE literal to use when interpolating bool values (DEFAULT=[]byte("false"))
+	False []byte
+	// The layout used when interpolating time.Time values (DEFAULT=time.RFC3339Nano)
+	TimeFormat string
+	// The location time.Time values are converted to before formatting, nil keeps the value's own zone
+	// (DEFAULT=nil)
+	TimeLocation *time.Location
+	// The precision time.Time values are truncated to before formatting, 0 keeps full precision (DEFAULT=0)
+	TimePrecision time.Duration
+	// The unit of integer timestamps compared with time columns, used by Guardrails to evaluate the time range of a
+	// query (DEFAULT=time.Millisecond)
+	EpochPrecision time.Duration
+	// The catalog functions (Sum, Percentile, ...) supported by the dialect and their argument counts. Catalog
+	// functions missing from the map are rejected, Func and the deprecated upper case helpers are never checked,
+	// nil disables the check. (Default=tdengineFunctionArities)
+	FunctionArities map[string]FunctionArity
+	// The units used when interpolating time.Duration values, largest first. The last unit is used for 0.
+	// (Default=[]DurationUnit{
+	// 		{Duration: 24 * time.Hour, Suffix: "d"},
+	// 		{Duration: time.Hour, Suffix: "h"},
+	// 		{Duration: time.Minute, Suffix: "m"},
+	// 		{Duration: time.Second, Suffix: "s"},
+	// 		{Duration: time.Millisecond, Suffix: "a"},
+	// 		{Duration: time.Microsecond, Suffix: "u"},
+	// 		{Duration: time.Nanosecond, Suffix: "b"},
+	// 	})
+	DurationUnits []DurationUnit
 	// The DESC fragment when specifying column order (DEFAULT=[]byte(" DESC"))
 	DescFragment []byte
 	// The SQL PARTITION BY clause fragment(DEFAULT=[]byte(" PARTITION BY "))
@@ -76,6 +123,71 @@ type SQLDialectOptions struct {
 	// 		ForSQLFragment,
 	// 	})
 	SelectSQLOrder []SQLFragmentType
+	// The DELETE fragment to use when generating sql. (DEFAULT=[]byte("DELETE"))
+	DeleteClause []byte
+	// The order of SQL fragments when creating a DELETE statement
+	// (DEFAULT=[]SQLFragmentType{DeleteBeginSQLFragment, FromSQLFragment, WhereSQLFragment})
+	DeleteSQLOrder []SQLFragmentType
+
+	// DDL fragments, a nil fragment means the statement or option is not supported by the dialect.
+	// (DEFAULT=[]byte("CREATE DATABASE "))
+	CreateDatabaseFragment []byte
+	// (DEFAULT=[]byte("DROP DATABASE "))
+	DropDatabaseFragment []byte
+	// (DEFAULT=[]byte("CREATE STABLE "))
+	CreateSTableFragment []byte
+	// (DEFAULT=[]byte("DROP STABLE "))
+	DropSTableFragment []byte
+	// (DEFAULT=[]byte("ALTER STABLE "))
+	AlterSTableFragment []byte
+	// (DEFAULT=[]byte("CREATE TABLE "))
+	CreateTableFragment []byte
+	// (DEFAULT=[]byte("DROP TABLE "))
+	DropTableFragment []byte
+	// (DEFAULT=nil)
+	CreateRetentionPolicyFragment []byte
+	// (DEFAULT=nil)
+	DropRetentionPolicyFragment []byte
+	// (DEFAULT=[]byte("IF NOT EXISTS "))
+	IfNotExistsFragment []byte
+	// (DEFAULT=[]byte("IF EXISTS "))
+	IfExistsFragment []byte
+	// Written once before the database options (DEFAULT=nil)
+	DatabaseOptionsFragment []byte
+	// The data retention of a database or retention policy (DEFAULT=[]byte(" KEEP "))
+	KeepFragment []byte
+	// The time span of a data file or shard (DEFAULT=[]byte(" DURATION "))
+	DatabaseDurationFragment []byte
+	// (DEFAULT=[]byte(" PRECISION "))
+	PrecisionFragment []byte
+	// (DEFAULT=[]byte(" REPLICA "))
+	ReplicationFragment []byte
+	// (DEFAULT=[]byte(" USING "))
+	UsingFragment []byte
+	// (DEFAULT=[]byte(" TAGS "))
+	TagsFragment []byte
+	// (DEFAULT=[]byte(" ADD COLUMN "))
+	AddColumnFragment []byte
+	// (DEFAULT=[]byte(" DROP COLUMN "))
+	DropColumnFragment []byte
+	// (DEFAULT=[]byte(" ADD TAG "))
+	AddTagFragment []byte
+	// (DEFAULT=[]byte(" DROP TAG "))
+	DropTagFragment []byte
+	// (DEFAULT=[]byte(" ON "))
+	OnFragment []byte
+	// (DEFAULT=nil)
+	DefaultPolicyFragment []byte
+	// (DEFAULT=nil)
+	CreateContinuousQueryFragment []byte
+	// (DEFAULT=nil)
+	DropContinuousQueryFragment []byte
+	// (DEFAULT=[]byte("CREATE STREAM "))
+	CreateStreamFragment []byte
+	// (DEFAULT=[]byte("DROP STREAM "))
+	DropStreamFragment []byte
+	// The SELECT ... INTO fragment (DEFAULT=nil)
+	IntoFragment []byte
 	// The SQL FROM clause fragment (DEFAULT=[]byte(" FROM"))
 	FromFragment []byte
 	// The SQL ORDER BY clause fragment(DEFAULT=[]byte(" ORDER BY "))
@@ -84,10 +196,43 @@ type SQLDialectOptions struct {
 	PlaceHolderFragment []byte
 	// The SQL FILL clause fragment(DEFAULT=[]byte(" FILL"))
 	FillFragment []byte
+	// A map used to look up FillModes and their SQL equivalents, modes missing from the map are not supported.
+	// An empty ValueFill keyword writes the values only.
+	// (Default= map[FillMode][]byte{
+	// 		NoneFill:    []byte("NONE"),
+	// 		NullFill:    []byte("NULL"),
+	// 		PrevFill:    []byte("PREV"),
+	// 		NextFill:    []byte("NEXT"),
+	// 		LinearFill:  []byte("LINEAR"),
+	// 		ValueFill:   []byte("VALUE"),
+	// 		NearestFill: []byte("NEAR"),
+	// 	})
+	FillModeLookup map[FillMode][]byte
+	// The maximum number of values accepted by a ValueFill, 0 means no limit (DEFAULT=0)
+	FillValueLimit int
 	// The SQL INTERVAL clause fragment(DEFAULT=[]byte(" INTERVAL"))
 	IntervalFragment []byte
+	// The SQL SLIDING clause fragment(DEFAULT=[]byte(" SLIDING"))
+	SlidingFragment []byte
+	// The SQL STATE_WINDOW clause fragment(DEFAULT=[]byte(" STATE_WINDOW"))
+	StateWindowFragment []byte
+	// The SQL SESSION clause fragment(DEFAULT=[]byte(" SESSION"))
+	SessionFragment []byte
+	// The SQL EVENT_WINDOW clause fragment(DEFAULT=[]byte(" EVENT_WINDOW START WITH "))
+	EventWindowStartFragment []byte
+	// The END WITH fragment of an EVENT_WINDOW clause(DEFAULT=[]byte(" END WITH "))
+	EventWindowEndFragment []byte
+	// Set to true to render interval windows as GROUP BY time(interval[, offset]) like InfluxQL (DEFAULT=false)
+	GroupByTimeWindow bool
+	// Set to true when the statements are InfluxQL, they are run with InfluxDB.Query and the /query endpoint
+	// instead of Query2 and /rest/sql (DEFAULT=false)
+	InfluxQL bool
+	// The function used for GROUP BY time windows (DEFAULT=[]byte("time"))
+	TimeFunctionFragment []byte
 	// The SQL WHERE clause fragment (DEFAULT=[]byte(" WHERE "))
 	WhereFragment []byte
+	// The SQL HAVING clause fragment, nil when the dialect does not support HAVING (DEFAULT=[]byte(" HAVING "))
+	HavingFragment []byte
 	// The operator to use when setting values in an update statement (DEFAULT='=')
 	SetOperatorRune rune
 	// Left paren rune (DEFAULT='(')
@@ -102,7 +247,8 @@ type SQLDialectOptions struct {
 	SpaceRune rune
 	// Comma rune (DEFAULT=',')
 	CommaRune rune
-	// The quote rune to use when quoting identifiers(DEFAULT='"')
+	// The quote rune to use when quoting identifiers, only identifiers that are not plain names are quoted
+	// (DEFAULT='`')
 	QuoteRune rune
 	// The quote rune to use when quoting string literals (DEFAULT='\'')
 	StringQuote rune
@@ -120,38 +266,79 @@ const (
 	LimitSQLFragment
 	OffsetSQLFragment
 	TimezoneSQLFragment
+	HavingSQLFragment
+	SLimitSQLFragment
+	SOffsetSQLFragment
+	DeleteBeginSQLFragment
+	IntoSQLFragment
 )
 
 func DefaultDialectOptions() *SQLDialectOptions {
 	return &SQLDialectOptions{
-		SelectClause:        []byte("SELECT"),
-		FromFragment:        []byte(" FROM"),
-		WhereFragment:       []byte(" WHERE "),
-		PartitionByFragment: []byte(" PARTITION BY "),
-		GroupByFragment:     []byte(" GROUP BY "),
-		IntervalFragment:    []byte(" INTERVAL"),
-		FillFragment:        []byte(" FILL"),
-		OrderByFragment:     []byte(" ORDER BY "),
-		LimitFragment:       []byte(" LIMIT "),
-		OffsetFragment:      []byte(" OFFSET "),
-		TimezoneFragment:    []byte(" TZ"),
-		AsFragment:          []byte(" AS "),
-		AscFragment:         []byte(" ASC"),
-		Null:                []byte("NULL"),
-		DescFragment:        []byte(" DESC"),
-		AndFragment:         []byte(" AND "),
-		OrFragment:          []byte(" OR "),
-		StringQuote:         '\'',
-		SetOperatorRune:     '=',
-		QuoteRune:           '"',
-		PlaceHolderFragment: []byte("?"),
-		EmptyString:         "",
-		CommaRune:           ',',
-		SpaceRune:           ' ',
-		LeftParenRune:       '(',
-		RightParenRune:      ')',
-		StarRune:            '*',
-		PeriodRune:          '.',
+		SelectClause:             []byte("SELECT"),
+		DeleteClause:             []byte("DELETE"),
+		CreateDatabaseFragment:   []byte("CREATE DATABASE "),
+		DropDatabaseFragment:     []byte("DROP DATABASE "),
+		CreateSTableFragment:     []byte("CREATE STABLE "),
+		DropSTableFragment:       []byte("DROP STABLE "),
+		AlterSTableFragment:      []byte("ALTER STABLE "),
+		CreateTableFragment:      []byte("CREATE TABLE "),
+		DropTableFragment:        []byte("DROP TABLE "),
+		IfNotExistsFragment:      []byte("IF NOT EXISTS "),
+		IfExistsFragment:         []byte("IF EXISTS "),
+		KeepFragment:             []byte(" KEEP "),
+		DatabaseDurationFragment: []byte(" DURATION "),
+		PrecisionFragment:        []byte(" PRECISION "),
+		ReplicationFragment:      []byte(" REPLICA "),
+		UsingFragment:            []byte(" USING "),
+		TagsFragment:             []byte(" TAGS "),
+		AddColumnFragment:        []byte(" ADD COLUMN "),
+		DropColumnFragment:       []byte(" DROP COLUMN "),
+		AddTagFragment:           []byte(" ADD TAG "),
+		DropTagFragment:          []byte(" DROP TAG "),
+		OnFragment:               []byte(" ON "),
+		CreateStreamFragment:     []byte("CREATE STREAM "),
+		DropStreamFragment:       []byte("DROP STREAM "),
+		FromFragment:             []byte(" FROM"),
+		WhereFragment:            []byte(" WHERE "),
+		HavingFragment:           []byte(" HAVING "),
+		PartitionByFragment:      []byte(" PARTITION BY "),
+		GroupByFragment:          []byte(" GROUP BY "),
+		IntervalFragment:         []byte(" INTERVAL"),
+		SlidingFragment:          []byte(" SLIDING"),
+		StateWindowFragment:      []byte(" STATE_WINDOW"),
+		SessionFragment:          []byte(" SESSION"),
+		EventWindowStartFragment: []byte(" EVENT_WINDOW START WITH "),
+		EventWindowEndFragment:   []byte(" END WITH "),
+		TimeFunctionFragment:     []byte("time"),
+		FillFragment:             []byte(" FILL"),
+		OrderByFragment:          []byte(" ORDER BY "),
+		LimitFragment:            []byte(" LIMIT "),
+		OffsetFragment:           []byte(" OFFSET "),
+		SLimitFragment:           []byte(" SLIMIT "),
+		SOffsetFragment:          []byte(" SOFFSET "),
+		TimezoneFragment:         []byte(" TZ"),
+		AsFragment:               []byte(" AS "),
+		AscFragment:              []byte(" ASC"),
+		Null:                     []byte("NULL"),
+		True:                     []byte("true"),
+		False:                    []byte("false"),
+		TimeFormat:               time.RFC3339Nano,
+		EpochPrecision:           time.Millisecond,
+		DescFragment:             []byte(" DESC"),
+		AndFragment:              []byte(" AND "),
+		OrFragment:               []byte(" OR "),
+		StringQuote:              '\'',
+		SetOperatorRune:          '=',
+		QuoteRune:                '`',
+		PlaceHolderFragment:      []byte("?"),
+		EmptyString:              "",
+		CommaRune:                ',',
+		SpaceRune:                ' ',
+		LeftParenRune:            '(',
+		RightParenRune:           ')',
+		StarRune:                 '*',
+		PeriodRune:               '.',
 		BooleanOperatorLookup: map[BooleanOperation][]byte{
 			EqOp:             []byte("="),
 			NeqOp:            []byte("!="),
@@ -164,29 +351,181 @@ func DefaultDialectOptions() *SQLDialectOptions {
 			RegexpILikeOp:    []byte("~*"),
 			RegexpNotILikeOp: []byte("!~*"),
 			InOp:             []byte("IN"),
+			NotInOp:          []byte("NOT IN"),
+			IsOp:             []byte("IS"),
+			IsNotOp:          []byte("IS NOT"),
 		},
 		ComputeOperatorLookup: map[Operator][]byte{
 			Plus:  []byte("+"),
 			Minus: []byte("-"),
 			Multi: []byte("*"),
 		},
+		FillModeLookup: map[FillMode][]byte{
+			NoneFill:    []byte("NONE"),
+			NullFill:    []byte("NULL"),
+			PrevFill:    []byte("PREV"),
+			NextFill:    []byte("NEXT"),
+			LinearFill:  []byte("LINEAR"),
+			ValueFill:   []byte("VALUE"),
+			NearestFill: []byte("NEAR"),
+		},
+		FunctionArities: tdengineFunctionArities,
+		DurationUnits: []DurationUnit{
+			{Duration: 24 * time.Hour, Suffix: "d"},
+			{Duration: time.Hour, Suffix: "h"},
+			{Duration: time.Minute, Suffix: "m"},
+			{Duration: time.Second, Suffix: "s"},
+			{Duration: time.Millisecond, Suffix: "a"},
+			{Duration: time.Microsecond, Suffix: "u"},
+			{Duration: time.Nanosecond, Suffix: "b"},
+		},
 		EscapedRunes: map[rune][]byte{
 			'\'': []byte("''"),
 		},
 		SelectSQLOrder: []SQLFragmentType{
 			SelectSQLFragment,
+			IntoSQLFragment,
 			FromSQLFragment,
 			WhereSQLFragment,
 			PartitionBySQLFragment,
 			GroupBySQLFragment,
 			IntervalFragment,
 			FillSQLFragment,
+			HavingSQLFragment,
 			OrderSQLFragment,
+			SLimitSQLFragment,
+			SOffsetSQLFragment,
 			LimitSQLFragment,
 			OffsetSQLFragment,
 			TimezoneSQLFragment,
 		},
+		DeleteSQLOrder: []SQLFragmentType{
+			DeleteBeginSQLFragment,
+			FromSQLFragment,
+			WhereSQLFragment,
+		},
+	}
+}
+
+// InfluxQLDialectOptions returns the options for InfluxDB 1.x InfluxQL.
+func InfluxQLDialectOptions() *SQLDialectOptions {
+	do := DefaultDialectOptions()
+	do.FunctionArities = influxQLFunctionArities
+	do.GroupByTimeWindow = true
+	do.InfluxQL = true
+	do.EpochPrecision = time.Nanosecond
+	// InfluxQL 的 SLIMIT、SOFFSET 在 LIMIT、OFFSET 之后。
+	do.SelectSQLOrder = []SQLFragmentType{
+		SelectSQLFragment,
+		IntoSQLFragment,
+		FromSQLFragment,
+		WhereSQLFragment,
+		PartitionBySQLFragment,
+		GroupBySQLFragment,
+		IntervalFragment,
+		FillSQLFragment,
+		HavingSQLFragment,
+		OrderSQLFragment,
+		LimitSQLFragment,
+		OffsetSQLFragment,
+		SLimitSQLFragment,
+		SOffsetSQLFragment,
+		TimezoneSQLFragment,
+	}
+	do.HavingFragment = nil
+	do.QuoteRune = '"'
+	do.FillModeLookup = map[FillMode][]byte{
+		NoneFill:   []byte("none"),
+		NullFill:   []byte("null"),
+		PrevFill:   []byte("previous"),
+		LinearFill: []byte("linear"),
+		ValueFill:  {},
+	}
+	do.FillValueLimit = 1
+	do.CreateSTableFragment = nil
+	do.DropSTableFragment = nil
+	do.AlterSTableFragment = nil
+	do.CreateTableFragment = nil
+	do.DropTableFragment = []byte("DROP MEASUREMENT ")
+	do.CreateRetentionPolicyFragment = []byte("CREATE RETENTION POLICY ")
+	do.DropRetentionPolicyFragment = []byte("DROP RETENTION POLICY ")
+	do.IfNotExistsFragment = nil
+	do.IfExistsFragment = nil
+	do.DatabaseOptionsFragment = []byte(" WITH")
+	do.KeepFragment = []byte(" DURATION ")
+	do.DatabaseDurationFragment = []byte(" SHARD DURATION ")
+	do.PrecisionFragment = nil
+	do.ReplicationFragment = []byte(" REPLICATION ")
+	do.DefaultPolicyFragment = []byte(" DEFAULT")
+	do.IntoFragment = []byte(" INTO ")
+	do.CreateContinuousQueryFragment = []byte("CREATE CONTINUOUS QUERY ")
+	do.DropContinuousQueryFragment = []byte("DROP CONTINUOUS QUERY ")
+	do.CreateStreamFragment = nil
+	do.DropStreamFragment = nil
+	do.DurationUnits = []DurationUnit{
+		{Duration: 7 * 24 * time.Hour, Suffix: "w"},
+		{Duration: 24 * time.Hour, Suffix: "d"},
+		{Duration: time.Hour, Suffix: "h"},
+		{Duration: time.Minute, Suffix: "m"},
+		{Duration: time.Second, Suffix: "s"},
+		{Duration: time.Millisecond, Suffix: "ms"},
+		{Duration: time.Microsecond, Suffix: "u"},
+		{Duration: time.Nanosecond, Suffix: "ns"},
 	}
+
+	return do
+}
+
+var tdengineFunctionArities = map[string]FunctionArity{
+	"COUNT":      {Min: 1, Max: 1},
+	"SUM":        {Min: 1, Max: 1},
+	"AVG":        {Min: 1, Max: 1},
+	"MIN":        {Min: 1, Max: 1},
+	"MAX":        {Min: 1, Max: 1},
+	"SPREAD":     {Min: 1, Max: 1},
+	"STDDEV":     {Min: 1, Max: 1},
+	"PERCENTILE": {Min: 2, Max: 11},
+	"MODE":       {Min: 1, Max: 1},
+	"TOP":        {Min: 2, Max: 2},
+	"BOTTOM":     {Min: 2, Max: 2},
+	"FIRST":      {Min: 1, Max: -1},
+	"LAST":       {Min: 1, Max: -1},
+	"ABS":        {Min: 1, Max: 1},
+	"TWA":        {Min: 1, Max: 1},
+	"ELAPSED":    {Min: 1, Max: 2},
+	"DERIVATIVE": {Min: 3, Max: 3},
+	"DIFF":       {Min: 1, Max: 2},
+	"CSUM":       {Min: 1, Max: 1},
+	"MAVG":       {Min: 2, Max: 2},
+	"STATECOUNT": {Min: 3, Max: 3},
 }
 
-var defaultDialectOptions = DefaultDialectOptions()
+var influxQLFunctionArities = map[string]FunctionArity{
+	"COUNT":                   {Min: 1, Max: 1},
+	"SUM":                     {Min: 1, Max: 1},
+	"MEAN":                    {Min: 1, Max: 1},
+	"MIN":                     {Min: 1, Max: 1},
+	"MAX":                     {Min: 1, Max: 1},
+	"SPREAD":                  {Min: 1, Max: 1},
+	"STDDEV":                  {Min: 1, Max: 1},
+	"PERCENTILE":              {Min: 2, Max: 2},
+	"MEDIAN":                  {Min: 1, Max: 1},
+	"MODE":                    {Min: 1, Max: 1},
+	"TOP":                     {Min: 2, Max: -1},
+	"BOTTOM":                  {Min: 2, Max: -1},
+	"FIRST":                   {Min: 1, Max: 1},
+	"LAST":                    {Min: 1, Max: 1},
+	"ABS":                     {Min: 1, Max: 1},
+	"ELAPSED":                 {Min: 1, Max: 2},
+	"INTEGRAL":                {Min: 1, Max: 2},
+	"DERIVATIVE":              {Min: 1, Max: 2},
+	"NON_NEGATIVE_DERIVATIVE": {Min: 1, Max: 2},
+	"DIFFERENCE":              {Min: 1, Max: 1},
+	"CUMULATIVE_SUM":          {Min: 1, Max: 1},
+	"MOVING_AVERAGE":          {Min: 2, Max: 2},
+}
+
+var (
+	defaultDialectOptions  = DefaultDialectOptions()
+	influxQLDialectOptions = InfluxQLDialectOptions()
+)
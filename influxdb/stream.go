@@ -0,0 +1,301 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+)
+
+// ContinuousQueryBuilder 构建 InfluxQL 的 CREATE CONTINUOUS QUERY 和 DROP CONTINUOUS QUERY 语句，
+// 默认使用 InfluxQLDialectOptions()。
+type ContinuousQueryBuilder struct {
+	do       *SQLDialectOptions
+	query    *QueryBuilder
+	every    interface{}
+	forTime  interface{}
+	name     string
+	database string
+	into     string
+	drop     bool
+}
+
+// CreateContinuousQuery 以 query 为主体创建连续查询，query 需要包含 GROUP BY time() 窗口，ToSQL 不会回收 query。
+func CreateContinuousQuery(name, database string, query *QueryBuilder) *ContinuousQueryBuilder {
+	return &ContinuousQueryBuilder{name: name, database: database, query: query, do: influxQLDialectOptions}
+}
+
+func DropContinuousQuery(name, database string) *ContinuousQueryBuilder {
+	return &ContinuousQueryBuilder{name: name, database: database, drop: true, do: influxQLDialectOptions}
+}
+
+func (b *ContinuousQueryBuilder) Dialect(do *SQLDialectOptions) *ContinuousQueryBuilder {
+	b.do = do
+
+	return b
+}
+
+// Into 设置结果写入的 measurement，原样输出，如 "db"."rp"."cpu_1h" 或 "db"."rp".:MEASUREMENT。
+func (b *ContinuousQueryBuilder) Into(into string) *ContinuousQueryBuilder {
+	b.into = into
+
+	return b
+}
+
+// ResampleEvery 设置执行间隔，默认与 GROUP BY time() 的窗口相同。
+func (b *ContinuousQueryBuilder) ResampleEvery(every interface{}) *ContinuousQueryBuilder {
+	b.every = every
+
+	return b
+}
+
+// ResampleFor 设置每次执行覆盖的时间范围，默认与 GROUP BY time() 的窗口相同。
+func (b *ContinuousQueryBuilder) ResampleFor(forTime interface{}) *ContinuousQueryBuilder {
+	b.forTime = forTime
+
+	return b
+}
+
+func (b *ContinuousQueryBuilder) ToSQL() (string, error) {
+	d := newDDLSQL(b.do)
+
+	if b.drop {
+		d.begin("DROP CONTINUOUS QUERY", d.do.DropContinuousQueryFragment, false, nil)
+		d.ident(b.name)
+		d.sb.Write(d.do.OnFragment)
+		d.ident(b.database)
+
+		return d.toSQL()
+	}
+
+	if b.query == nil {
+		return "", errors.New("CREATE CONTINUOUS QUERY requires a query")
+	}
+
+	d.begin("CREATE CONTINUOUS QUERY", d.do.CreateContinuousQueryFragment, false, nil)
+	d.ident(b.name)
+	d.sb.Write(d.do.OnFragment)
+	d.ident(b.database)
+
+	if b.every != nil || b.forTime != nil {
+		d.sb.WriteStrings(" RESAMPLE")
+		d.option("EVERY", []byte(" EVERY "), b.every)
+		d.option("FOR", []byte(" FOR "), b.forTime)
+	}
+
+	// 方言不支持该语句时直接返回，避免被查询主体的错误掩盖。
+	if err := d.sb.Error(); err != nil {
+		return "", err
+	}
+
+	body, _, err := b.query.Clone().Dialect(b.do).Into(b.into).ToSQL()
+	if err != nil {
+		return "", err
+	}
+
+	d.sb.WriteStrings(" BEGIN ", body, " END")
+
+	return d.toSQL()
+}
+
+func (b *ContinuousQueryBuilder) Exec(ctx context.Context, conn Executor) error {
+	return execDDL(ctx, conn, b.do, b.ToSQL)
+}
+
+type ContinuousQuery struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// ContinuousQueries 返回所有连续查询。
+func (i *InfluxDB) ContinuousQueries(ctx context.Context) ([]ContinuousQuery, error) {
+	var cqs []ContinuousQuery
+
+	err := i.Query(WithoutCache(ctx), "SHOW CONTINUOUS QUERIES", &cqs)
+	if errors.Is(err, ErrNoSeries) {
+		return nil, nil
+	}
+
+	return cqs, err
+}
+
+type StreamTrigger int8
+
+const (
+	// AtOnceTrigger 写入数据后立即计算。
+	AtOnceTrigger StreamTrigger = iota + 1
+	// WindowCloseTrigger 窗口关闭时计算。
+	WindowCloseTrigger
+	// MaxDelayTrigger 窗口关闭时计算，未关闭的窗口最多延迟 MaxDelay 后计算。
+	MaxDelayTrigger
+)
+
+// StreamBuilder 构建 TDengine 的 CREATE STREAM 和 DROP STREAM 语句。
+type StreamBuilder struct {
+	do            *SQLDialectOptions
+	query         *QueryBuilder
+	maxDelay      interface{}
+	watermark     interface{}
+	ignoreExpired interface{}
+	fillHistory   interface{}
+	subTable      Expression
+	name          string
+	into          string
+	trigger       StreamTrigger
+	cond          bool
+	drop          bool
+}
+
+// CreateStream 以 query 为主体创建流计算，ToSQL 不会回收 query。
+func CreateStream(name string, query *QueryBuilder) *StreamBuilder {
+	return &StreamBuilder{name: name, query: query}
+}
+
+func DropStream(name string) *StreamBuilder {
+	return &StreamBuilder{name: name, drop: true}
+}
+
+func (b *StreamBuilder) Dialect(do *SQLDialectOptions) *StreamBuilder {
+	b.do = do
+
+	return b
+}
+
+// IfNotExists 在创建时添加 IF NOT EXISTS，删除时添加 IF EXISTS。
+func (b *StreamBuilder) IfNotExists() *StreamBuilder {
+	b.cond = true
+
+	return b
+}
+
+func (b *StreamBuilder) IfExists() *StreamBuilder {
+	return b.IfNotExists()
+}
+
+// Into 设置结果写入的超级表。
+func (b *StreamBuilder) Into(stable string) *StreamBuilder {
+	b.into = stable
+
+	return b
+}
+
+func (b *StreamBuilder) Trigger(trigger StreamTrigger) *StreamBuilder {
+	b.trigger = trigger
+
+	return b
+}
+
+// MaxDelay 设置 MaxDelayTrigger 的最大延迟。
+func (b *StreamBuilder) MaxDelay(delay interface{}) *StreamBuilder {
+	b.trigger = MaxDelayTrigger
+	b.maxDelay = delay
+
+	return b
+}
+
+func (b *StreamBuilder) Watermark(watermark interface{}) *StreamBuilder {
+	b.watermark = watermark
+
+	return b
+}
+
+func (b *StreamBuilder) IgnoreExpired(ignore bool) *StreamBuilder {
+	b.ignoreExpired = boolFlag(ignore)
+
+	return b
+}
+
+// FillHistory 为 true 时同时计算创建流之前写入的数据。
+func (b *StreamBuilder) FillHistory(fill bool) *StreamBuilder {
+	b.fillHistory = boolFlag(fill)
+
+	return b
+}
+
+// SubTable 设置结果子表的表名表达式，如 SubTable(Func("CONCAT", "avg_", C("tbname")))。
+func (b *StreamBuilder) SubTable(exp Expression) *StreamBuilder {
+	b.subTable = exp
+
+	return b
+}
+
+func (b *StreamBuilder) ToSQL() (string, error) {
+	d := newDDLSQL(b.do)
+
+	if b.drop {
+		d.begin("DROP STREAM", d.do.DropStreamFragment, b.cond, d.do.IfExistsFragment)
+		d.ident(b.name)
+
+		return d.toSQL()
+	}
+
+	if b.query == nil || b.into == "" {
+		return "", errors.New("CREATE STREAM requires a query and a target table")
+	}
+
+	d.begin("CREATE STREAM", d.do.CreateStreamFragment, b.cond, d.do.IfNotExistsFragment)
+	d.ident(b.name)
+
+	switch b.trigger {
+	case AtOnceTrigger:
+		d.sb.WriteStrings(" TRIGGER AT_ONCE")
+	case WindowCloseTrigger:
+		d.sb.WriteStrings(" TRIGGER WINDOW_CLOSE")
+	case MaxDelayTrigger:
+		d.option("MAX_DELAY", []byte(" TRIGGER MAX_DELAY "), b.maxDelay)
+	}
+
+	d.option("WATERMARK", []byte(" WATERMARK "), b.watermark)
+	d.option("IGNORE EXPIRED", []byte(" IGNORE EXPIRED "), b.ignoreExpired)
+	d.option("FILL_HISTORY", []byte(" FILL_HISTORY "), b.fillHistory)
+	d.sb.WriteStrings(" INTO ")
+	d.ident(b.into)
+
+	if b.subTable != nil {
+		d.sb.WriteStrings(" SUBTABLE")
+		d.sb.WriteRunes(d.do.LeftParenRune)
+		d.esg.Generate(d.sb, b.subTable)
+		d.sb.WriteRunes(d.do.RightParenRune)
+	}
+
+	if err := d.sb.Error(); err != nil {
+		return "", err
+	}
+
+	body, _, err := b.query.Clone().Dialect(d.do).ToSQL()
+	if err != nil {
+		return "", err
+	}
+
+	d.sb.WriteStrings(" AS ", body)
+
+	return d.toSQL()
+}
+
+func (b *StreamBuilder) Exec(ctx context.Context, conn Executor) error {
+	return execDDL(ctx, conn, b.do, b.ToSQL)
+}
+
+type Stream struct {
+	Name   string `json:"stream_name"`
+	SQL    string `json:"sql"`
+	Status string `json:"status"`
+}
+
+// Streams 返回所有流计算。
+func (i *InfluxDB) Streams(ctx context.Context) ([]Stream, error) {
+	var streams []Stream
+
+	err := i.Query2(WithoutCache(ctx), "SELECT stream_name, sql, status FROM information_schema.ins_streams", &streams)
+	if errors.Is(err, ErrNoData) {
+		return nil, nil
+	}
+
+	return streams, err
+}
+
+func boolFlag(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
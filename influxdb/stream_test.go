@@ -0,0 +1,204 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+)
+
+func TestContinuousQueryToSQL(t *testing.T) {
+	mean := func() *influxdb.QueryBuilder {
+		return influxdb.From("cpu").Select(influxdb.Mean("value").As("value")).
+			GroupBy("host").Window(influxdb.NewIntervalWindow(time.Hour))
+	}
+
+	tests := []struct {
+		name    string
+		b       *influxdb.ContinuousQueryBuilder
+		sql     string
+		wantErr error
+	}{
+		{
+			name: "create",
+			b:    influxdb.CreateContinuousQuery("cq_1h", "telegraf", mean()).Into("cpu_1h"),
+			sql: "CREATE CONTINUOUS QUERY cq_1h ON telegraf BEGIN " +
+				"SELECT MEAN(value) AS value INTO cpu_1h FROM cpu GROUP BY time(1h), host END",
+		},
+		{
+			name: "resample",
+			b: influxdb.CreateContinuousQuery("cq_1h", "telegraf", mean()).Into(`"telegraf"."autogen".:MEASUREMENT`).
+				ResampleEvery(30 * time.Minute).ResampleFor(2 * time.Hour),
+			sql: "CREATE CONTINUOUS QUERY cq_1h ON telegraf RESAMPLE EVERY 30m FOR 2h BEGIN " +
+				`SELECT MEAN(value) AS value INTO "telegraf"."autogen".:MEASUREMENT FROM cpu GROUP BY time(1h), host END`,
+		},
+		{
+			name: "resample every only",
+			b:    influxdb.CreateContinuousQuery("cq_1h", "telegraf", mean()).Into("cpu_1h").ResampleEvery("15m"),
+			sql: "CREATE CONTINUOUS QUERY cq_1h ON telegraf RESAMPLE EVERY 15m BEGIN " +
+				"SELECT MEAN(value) AS value INTO cpu_1h FROM cpu GROUP BY time(1h), host END",
+		},
+		{
+			name: "drop",
+			b:    influxdb.DropContinuousQuery("cq_1h", "telegraf"),
+			sql:  "DROP CONTINUOUS QUERY cq_1h ON telegraf",
+		},
+		{
+			name:    "tdengine",
+			b:       influxdb.CreateContinuousQuery("cq_1h", "power", mean()).Dialect(influxdb.DefaultDialectOptions()),
+			wantErr: errors.New("CREATE CONTINUOUS QUERY not supported by dialect"),
+		},
+		{
+			name:    "no query",
+			b:       influxdb.CreateContinuousQuery("cq_1h", "telegraf", nil),
+			wantErr: errors.New("CREATE CONTINUOUS QUERY requires a query"),
+		},
+	}
+
+	for _, test := range tests {
+		sql, err := test.b.ToSQL()
+		if test.wantErr != nil {
+			if err == nil || err.Error() != test.wantErr.Error() {
+				t.Errorf("%s: error = %v; want %v", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if sql != test.sql {
+			t.Errorf("%s:\n got: %s\nwant: %s", test.name, sql, test.sql)
+		}
+	}
+}
+
+func TestStreamToSQL(t *testing.T) {
+	avg := func() *influxdb.QueryBuilder {
+		return influxdb.From("meters").Select(influxdb.C("_wstart"), influxdb.Avg("voltage").As("voltage")).
+			PartitionBy("tbname").Window(influxdb.NewIntervalWindow(time.Minute))
+	}
+
+	tests := []struct {
+		name    string
+		b       *influxdb.StreamBuilder
+		sql     string
+		wantErr error
+	}{
+		{
+			name: "create",
+			b:    influxdb.CreateStream("avg_vol", avg()).Into("avg_vol_s"),
+			sql: "CREATE STREAM avg_vol INTO avg_vol_s AS " +
+				"SELECT _wstart, AVG(voltage) AS voltage FROM meters PARTITION BY tbname INTERVAL(1m)",
+		},
+		{
+			name: "options",
+			b: influxdb.CreateStream("avg_vol", avg()).IfNotExists().Into("avg_vol_s").
+				Trigger(influxdb.WindowCloseTrigger).Watermark(10 * time.Second).IgnoreExpired(false).FillHistory(true).
+				SubTable(influxdb.Func("CONCAT", "avg_", influxdb.C("tbname"))),
+			sql: "CREATE STREAM IF NOT EXISTS avg_vol TRIGGER WINDOW_CLOSE WATERMARK 10s IGNORE EXPIRED 0 " +
+				"FILL_HISTORY 1 INTO avg_vol_s SUBTABLE(CONCAT('avg_', tbname)) AS " +
+				"SELECT _wstart, AVG(voltage) AS voltage FROM meters PARTITION BY tbname INTERVAL(1m)",
+		},
+		{
+			name: "max delay",
+			b:    influxdb.CreateStream("avg_vol", avg()).Into("avg_vol_s").MaxDelay(5 * time.Second),
+			sql: "CREATE STREAM avg_vol TRIGGER MAX_DELAY 5s INTO avg_vol_s AS " +
+				"SELECT _wstart, AVG(voltage) AS voltage FROM meters PARTITION BY tbname INTERVAL(1m)",
+		},
+		{
+			name: "drop",
+			b:    influxdb.DropStream("avg_vol").IfExists(),
+			sql:  "DROP STREAM IF EXISTS avg_vol",
+		},
+		{
+			name:    "no target",
+			b:       influxdb.CreateStream("avg_vol", avg()),
+			wantErr: errors.New("CREATE STREAM requires a query and a target table"),
+		},
+		{
+			name:    "influxql",
+			b:       influxdb.CreateStream("avg_vol", avg()).Into("avg_vol_s").Dialect(influxdb.InfluxQLDialectOptions()),
+			wantErr: errors.New("CREATE STREAM not supported by dialect"),
+		},
+		{
+			name:    "select into",
+			b:       influxdb.CreateStream("avg_vol", avg().Into("x")).Into("avg_vol_s"),
+			wantErr: influxdb.ErrIntoNotSupported,
+		},
+	}
+
+	for _, test := range tests {
+		sql, err := test.b.ToSQL()
+		if test.wantErr != nil {
+			if err == nil || !errors.Is(err, test.wantErr) && err.Error() != test.wantErr.Error() {
+				t.Errorf("%s: error = %v; want %v", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+
+			continue
+		}
+
+		if sql != test.sql {
+			t.Errorf("%s:\n got: %s\nwant: %s", test.name, sql, test.sql)
+		}
+	}
+}
+
+func TestStreamsAndContinuousQueries(t *testing.T) {
+	ctx := context.Background()
+	query := influxdb.From("cpu").Select(influxdb.Mean("value").As("value")).Window(influxdb.NewIntervalWindow(time.Hour))
+
+	db, _ := newTestDB(t, func(cfg *influxdb.Config) { cfg.Backend = influxdb.InfluxQLBackend })
+
+	if err := influxdb.CreateContinuousQuery("cq_1h", "test", query).Into("cpu_1h").Exec(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	cqs, err := db.ContinuousQueries(ctx)
+	if err != nil || len(cqs) != 1 || cqs[0].Name != "cq_1h" {
+		t.Errorf("ContinuousQueries() = %v, %v; want cq_1h", cqs, err)
+	}
+
+	if err = influxdb.DropContinuousQuery("cq_1h", "test").Exec(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if cqs, err = db.ContinuousQueries(ctx); err != nil || len(cqs) != 0 {
+		t.Errorf("ContinuousQueries() after drop = %v, %v; want none", cqs, err)
+	}
+
+	db, _ = newTestDB(t)
+
+	query = influxdb.From("cpu").Select(influxdb.Avg("value").As("value")).Window(influxdb.NewIntervalWindow(time.Hour))
+
+	err = influxdb.CreateStream("avg_1h", query).Into("cpu_1h").Trigger(influxdb.AtOnceTrigger).Exec(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	streams, err := db.Streams(ctx)
+	if err != nil || fmt.Sprint(len(streams)) != "1" || streams[0].Name != "avg_1h" {
+		t.Errorf("Streams() = %v, %v; want avg_1h", streams, err)
+	}
+
+	if err = influxdb.DropStream("avg_1h").Exec(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if streams, err = db.Streams(ctx); err != nil || len(streams) != 0 {
+		t.Errorf("Streams() after drop = %v, %v; want none", streams, err)
+	}
+}
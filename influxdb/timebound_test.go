@@ -0,0 +1,75 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoundTime(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	shanghai := time.FixedZone("CST", 8*3600)
+
+	withLocation := DefaultDialectOptions()
+	withLocation.TimeLocation = shanghai
+
+	tests := []struct {
+		name string
+		v    interface{}
+		do   *SQLDialectOptions
+		want time.Time
+		// ago 不为 0 时结果应为当前时间减去 ago。
+		ago time.Duration
+		bad bool
+	}{
+		{name: "time", v: epoch, want: epoch},
+		{name: "rfc3339", v: "2024-01-01T00:00:00Z", want: epoch},
+		{name: "tdengine literal", v: "2024-01-01 00:00:00.250", do: withLocation,
+			want: time.Date(2024, 1, 1, 0, 0, 0, 250e6, shanghai)},
+		{name: "date", v: "2024-01-01", do: withLocation, want: time.Date(2024, 1, 1, 0, 0, 0, 0, shanghai)},
+		{name: "epoch milliseconds", v: epoch.UnixMilli(), want: epoch},
+		{name: "int epoch", v: int(epoch.UnixMilli()), want: epoch},
+		{name: "influxql epoch", v: epoch.UnixNano(), do: InfluxQLDialectOptions(), want: epoch},
+		{name: "now", v: Now(), ago: time.Nanosecond},
+		{name: "now offset", v: Now("-1h"), ago: time.Hour},
+		{name: "now function offset", v: newLiteralExpression("now() - 1h30m"), ago: 90 * time.Minute},
+		{name: "now plus", v: Now("+1d"), ago: -24 * time.Hour},
+		{name: "now function", v: Func("NOW"), ago: time.Nanosecond},
+		{name: "computed", v: Func("NOW").Sub(2 * time.Hour), ago: 2 * time.Hour},
+		{name: "influxql units", v: Now("-5ms"), do: InfluxQLDialectOptions(), ago: 5 * time.Millisecond},
+		{name: "unknown unit", v: Now("-1n"), bad: true},
+		{name: "missing offset", v: Now("-"), bad: true},
+		{name: "other literal", v: Star(), bad: true},
+		{name: "column", v: C("created"), bad: true},
+		{name: "text", v: "yesterday", bad: true},
+		{name: "or bounds", v: orBounds{epoch}, bad: true},
+	}
+
+	for _, test := range tests {
+		got, ok := boundTime(test.v, test.do)
+		if test.bad {
+			if ok {
+				t.Errorf("%s: boundTime = %v; want not evaluable", test.name, got)
+			}
+
+			continue
+		}
+
+		if !ok {
+			t.Errorf("%s: boundTime not evaluable", test.name)
+
+			continue
+		}
+
+		if test.ago != 0 {
+			if d := time.Since(got) - test.ago; d < -time.Second || d > time.Second {
+				t.Errorf("%s: boundTime = %v; want about %v ago", test.name, got, test.ago)
+			}
+
+			continue
+		}
+
+		if !got.Equal(test.want) {
+			t.Errorf("%s: boundTime = %v; want %v", test.name, got, test.want)
+		}
+	}
+}
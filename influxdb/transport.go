@@ -0,0 +1,95 @@
+package influxdb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const defaultTimeout = time.Minute
+
+// TLSConfig 为 HTTPS 连接的证书配置。
+type TLSConfig struct {
+	// CAFile 为 PEM 格式的 CA 证书文件，为空时使用系统证书。
+	CAFile string
+	// CertFile 和 KeyFile 为客户端证书及私钥文件。
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+func (tc *TLSConfig) build() (*tls.Config, error) {
+	c := &tls.Config{
+		InsecureSkipVerify: tc.InsecureSkipVerify,
+	}
+
+	if tc.CAFile != "" {
+		pem, err := os.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", tc.CAFile)
+		}
+
+		c.RootCAs = pool
+	}
+
+	if tc.CertFile != "" || tc.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	return c, nil
+}
+
+// newHTTPClient 按 Config 创建 http.Client，优先使用 HTTPClient，其次使用 Transport。
+func newHTTPClient(cfg Config) (*http.Client, error) {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient, nil
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	if cfg.Transport != nil {
+		return &http.Client{Timeout: timeout, Transport: cfg.Transport}, nil
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+
+		tr.TLSClientConfig = tlsConfig
+	}
+
+	if cfg.MaxIdleConns > 0 {
+		tr.MaxIdleConns = cfg.MaxIdleConns
+	}
+
+	if cfg.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+
+	if cfg.DisableHTTP2 {
+		tr.ForceAttemptHTTP2 = false
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Timeout: timeout, Transport: tr}, nil
+}
@@ -0,0 +1,114 @@
+package influxdb
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, http.ErrNotSupported
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	custom := &http.Client{Timeout: time.Second}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		check   func(c *http.Client) bool
+		wantErr bool
+	}{
+		{
+			name:  "custom client",
+			cfg:   Config{HTTPClient: custom, Transport: stubTransport{}},
+			check: func(c *http.Client) bool { return c == custom },
+		},
+		{
+			name: "custom transport",
+			cfg:  Config{Transport: stubTransport{}, Timeout: time.Second},
+			check: func(c *http.Client) bool {
+				_, ok := c.Transport.(stubTransport)
+				return ok && c.Timeout == time.Second
+			},
+		},
+		{
+			name: "defaults",
+			cfg:  Config{},
+			check: func(c *http.Client) bool {
+				tr := c.Transport.(*http.Transport)
+				return c.Timeout == defaultTimeout && tr.ForceAttemptHTTP2
+			},
+		},
+		{
+			name: "pool sizes",
+			cfg:  Config{MaxIdleConns: 7, MaxIdleConnsPerHost: 3},
+			check: func(c *http.Client) bool {
+				tr := c.Transport.(*http.Transport)
+				return tr.MaxIdleConns == 7 && tr.MaxIdleConnsPerHost == 3
+			},
+		},
+		{
+			name: "disable http2",
+			cfg:  Config{DisableHTTP2: true},
+			check: func(c *http.Client) bool {
+				tr := c.Transport.(*http.Transport)
+				return !tr.ForceAttemptHTTP2 && tr.TLSNextProto != nil && len(tr.TLSNextProto) == 0
+			},
+		},
+		{
+			name: "insecure tls",
+			cfg:  Config{TLS: &TLSConfig{InsecureSkipVerify: true}},
+			check: func(c *http.Client) bool {
+				tc := c.Transport.(*http.Transport).TLSClientConfig
+				return tc != nil && tc.InsecureSkipVerify && tc.RootCAs == nil
+			},
+		},
+		{
+			name:    "missing ca file",
+			cfg:     Config{TLS: &TLSConfig{CAFile: filepath.Join(t.TempDir(), "ca.pem")}},
+			wantErr: true,
+		},
+		{
+			name:    "missing client cert",
+			cfg:     Config{TLS: &TLSConfig{CertFile: "cert.pem"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		c, err := newHTTPClient(test.cfg)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: newHTTPClient() succeeded; want error", test.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: newHTTPClient() error: %v", test.name, err)
+
+			continue
+		}
+
+		if !test.check(c) {
+			t.Errorf("%s: unexpected client %+v", test.name, c)
+		}
+	}
+}
+
+func TestTLSConfigInvalidCA(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(file, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&TLSConfig{CAFile: file}).build(); err == nil {
+		t.Error("build() with an invalid CA file succeeded; want error")
+	}
+}
@@ -6,6 +6,10 @@ func IsInvalid(k reflect.Kind) bool {
 	return k == reflect.Invalid
 }
 
+func IsBool(k reflect.Kind) bool {
+	return k == reflect.Bool
+}
+
 func IsSlice(k reflect.Kind) bool {
 	return k == reflect.Slice
 }
@@ -0,0 +1,148 @@
+package influxdb
+
+import "fmt"
+
+type WindowType int
+
+const (
+	// INTERVAL(interval[, offset]) [SLIDING(sliding)], GROUP BY time(interval[, offset]) in InfluxQL.
+	IntervalWindowType WindowType = iota
+	// STATE_WINDOW(col).
+	StateWindowType
+	// SESSION(ts, tolerance).
+	SessionWindowType
+	// EVENT_WINDOW START WITH start END WITH end.
+	EventWindowType
+)
+
+func (wt WindowType) String() string {
+	switch wt {
+	case IntervalWindowType:
+		return "interval"
+	case StateWindowType:
+		return "state_window"
+	case SessionWindowType:
+		return "session"
+	case EventWindowType:
+		return "event_window"
+	}
+
+	return fmt.Sprintf("%d", wt)
+}
+
+func errUnsupportedWindowType(wt WindowType) error {
+	return fmt.Errorf("window type '%v' not supported by dialect", wt)
+}
+
+// Window 为时间窗口子句。interval、offset、sliding 和 tolerance 可以是 string（原样输出）、
+// time.Duration 或 Expression。
+type Window interface {
+	Type() WindowType
+	Interval() interface{}
+	Offset() interface{}
+	Sliding() interface{}
+	Col() Expression
+	Tolerance() interface{}
+	StartWith() Expression
+	EndWith() Expression
+
+	WithOffset(offset interface{}) Window
+	WithSliding(sliding interface{}) Window
+}
+
+type window struct {
+	interval  interface{}
+	offset    interface{}
+	sliding   interface{}
+	col       Expression
+	tolerance interface{}
+	startWith Expression
+	endWith   Expression
+	wType     WindowType
+}
+
+func NewIntervalWindow(interval interface{}) Window {
+	return window{wType: IntervalWindowType, interval: interval}
+}
+
+func NewStateWindow(col interface{}) Window {
+	return window{wType: StateWindowType, col: windowCol(col)}
+}
+
+func NewSessionWindow(ts interface{}, tolerance interface{}) Window {
+	return window{wType: SessionWindowType, col: windowCol(ts), tolerance: tolerance}
+}
+
+func NewEventWindow(startWith, endWith Expression) Window {
+	return window{wType: EventWindowType, startWith: startWith, endWith: endWith}
+}
+
+func windowCol(col interface{}) Expression {
+	switch t := col.(type) {
+	case string:
+		return ParseIdentifier(t)
+	case Expression:
+		return t
+	}
+
+	panic(fmt.Sprintf("Cannot create window column from %+v", col))
+}
+
+func (w window) Type() WindowType {
+	return w.wType
+}
+
+func (w window) Interval() interface{} {
+	return w.interval
+}
+
+func (w window) Offset() interface{} {
+	return w.offset
+}
+
+func (w window) Sliding() interface{} {
+	return w.sliding
+}
+
+func (w window) Col() Expression {
+	return w.col
+}
+
+func (w window) Tolerance() interface{} {
+	return w.tolerance
+}
+
+func (w window) StartWith() Expression {
+	return w.startWith
+}
+
+func (w window) EndWith() Expression {
+	return w.endWith
+}
+
+func (w window) WithOffset(offset interface{}) Window {
+	w.offset = offset
+
+	return w
+}
+
+func (w window) WithSliding(sliding interface{}) Window {
+	w.sliding = sliding
+
+	return w
+}
+
+// WStart 为窗口起始时间伪列 _wstart。
+func WStart() LiteralExpression {
+	return newLiteralExpression("_wstart")
+}
+
+// WEnd 为窗口结束时间伪列 _wend。
+func WEnd() LiteralExpression {
+	return newLiteralExpression("_wend")
+}
+
+// WDuration 为窗口时长伪列 _wduration。
+func WDuration() LiteralExpression {
+	return newLiteralExpression("_wduration")
+}
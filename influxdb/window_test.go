@@ -0,0 +1,55 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowSQL(t *testing.T) {
+	influxQL := InfluxQLDialectOptions()
+
+	runSQLTests(t, []sqlTest{
+		{
+			name: "interval",
+			qb:   From("cpu").Select(WStart(), Avg("v")).Window(NewIntervalWindow(10 * time.Minute)),
+			sql:  "SELECT _wstart, AVG(v) FROM cpu INTERVAL(10m)",
+		},
+		{
+			name: "interval offset sliding",
+			qb: From("cpu").Select(Avg("v")).
+				Window(NewIntervalWindow("1h").WithOffset(5 * time.Minute).WithSliding(30 * time.Minute)),
+			sql: "SELECT AVG(v) FROM cpu INTERVAL(1h, 5m) SLIDING(30m)",
+		},
+		{
+			name: "state window",
+			qb:   From("cpu").Select(Count(Star())).PartitionBy("tbname").Window(NewStateWindow("status")),
+			sql:  "SELECT COUNT(*) FROM cpu PARTITION BY tbname STATE_WINDOW(status)",
+		},
+		{
+			name: "session window",
+			qb:   From("cpu").Select(Count(Star())).Window(NewSessionWindow("ts", 10*time.Second)),
+			sql:  "SELECT COUNT(*) FROM cpu SESSION(ts, 10s)",
+		},
+		{
+			name: "event window",
+			qb:   From("cpu").Select(Count(Star())).Window(NewEventWindow(C("v").Gt(10), C("v").Lt(5))),
+			sql:  "SELECT COUNT(*) FROM cpu EVENT_WINDOW START WITH (v > 10) END WITH (v < 5)",
+		},
+		{
+			name: "influxql group by time",
+			qb: From("cpu").Dialect(influxQL).Select(Mean("v")).GroupBy("host").
+				Window(NewIntervalWindow(time.Hour).WithOffset(15 * time.Minute)),
+			sql: "SELECT MEAN(v) FROM cpu GROUP BY time(1h, 15m), host",
+		},
+		{
+			name:    "influxql sliding",
+			qb:      From("cpu").Dialect(influxQL).Select(Mean("v")).Window(NewIntervalWindow(time.Hour).WithSliding(time.Minute)),
+			wantErr: ErrSlidingNotSupported.Error(),
+		},
+		{
+			name:    "influxql state window",
+			qb:      From("cpu").Dialect(influxQL).Select(Count("v")).Window(NewStateWindow("status")),
+			wantErr: "window type 'state_window' not supported by dialect",
+		},
+	})
+}
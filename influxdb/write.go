@@ -1,13 +1,18 @@
 package influxdb
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+const writeBufferSize = 32 * 1024
+
 type Writable interface {
 	Measurement() string
 	Tags() []byte
@@ -16,51 +21,150 @@ type Writable interface {
 }
 
 func (i *InfluxDB) Write(data []Writable) error {
+	return i.WriteContext(context.Background(), data)
+}
+
+// WriteContext 写入数据点，ctx 用于限流、并发排队的等待和取消写入请求。
+func (i *InfluxDB) WriteContext(ctx context.Context, data []Writable) error {
 	if len(data) == 0 {
 		return nil
 	}
 
-	b := make([]byte, 0, len(data)*1024)
-	for i := 0; i < len(data); i++ {
-		b = append(b, data[i].Measurement()...)
-		b = append(b, ',')
-		b = append(b, data[i].Tags()...)
-		b = append(b, ' ')
-		b = append(b, data[i].Fields()...)
-		b = append(b, ' ')
-		b = strconv.AppendInt(b, data[i].Timestamp(), 10)
-		b = append(b, '\n')
+	ctx, info := i.before(ctx, WriteOperation, "")
+	info.Rows = len(data)
+	err := i.write(ctx, info, data)
+	i.after(ctx, info, err)
+
+	return err
+}
+
+// write 依次在写入节点上尝试写入，连接失败时切换到下一个节点。
+func (i *InfluxDB) write(ctx context.Context, info *OpInfo, data []Writable) error {
+	err := i.waitWrite(ctx, i.writeCost(len(data)))
+	if err != nil {
+		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, i.Conn.writeHost, bytes.NewBuffer(b))
+	release, err := i.acquire(ctx)
 	if err != nil {
 		return err
 	}
-	defer req.Body.Close()
+	defer release()
+
+	for _, n := range i.Conn.writes.candidates() {
+		var retry bool
+
+		retry, err = i.writeTo(ctx, n, info, data)
+		if !retry {
+			return err
+		}
+
+		n.down.Store(true)
+	}
+
+	return err
+}
+
+// writeTo 将数据点编码为行协议后经 io.Pipe 流式发送到 n，开启 gzip 时边编码边压缩。
+// 连接失败时返回 retry 为 true，ctx 结束时不重试。
+func (i *InfluxDB) writeTo(ctx context.Context, n *node, info *OpInfo, data []Writable) (retry bool, err error) {
+	pr, pw := io.Pipe()
+	encoded := make(chan int, 1)
+
+	go func() {
+		size, err := i.encodePoints(pw, data)
+		encoded <- size
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.writeHost, pr)
+	if err != nil {
+		pr.CloseWithError(err)
+		info.Bytes = <-encoded
+
+		return false, err
+	}
 
 	req.Header.Set("Content-Type", "")
+
+	if i.Conn.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
 	if i.Conn.username != "" {
 		req.SetBasicAuth(i.Conn.username, i.Conn.password)
 	}
 
+	start := time.Now()
 	resp, err := i.Conn.client.Do(req)
+
+	// Do 出错时 Transport 会关闭请求体，编码协程随之退出。
+	pr.Close()
+	info.Bytes = <-encoded
+
 	if err != nil {
-		return err
+		// ctx 取消或超时不是节点的问题，不再切换节点。
+		return ctx.Err() == nil, err
 	}
-
-	b = nil
 	defer resp.Body.Close()
 
+	n.down.Store(false)
+	n.observe(time.Since(start))
+
 	body := new(bytes.Buffer)
 	_, err = io.Copy(body, resp.Body)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
 		var err = errors.New(body.String())
-		return err
+		return false, err
+	}
+
+	return false, nil
+}
+
+// encodePoints 将 data 以行协议写入 w，返回压缩前的字节数。
+func (i *InfluxDB) encodePoints(w io.Writer, data []Writable) (int, error) {
+	if i.Conn.gzip {
+		zw := getGzipWriter(w)
+		defer putGzipWriter(zw)
+
+		n, err := writePoints(zw, data)
+		if err != nil {
+			return n, err
+		}
+
+		return n, zw.Close()
+	}
+
+	return writePoints(w, data)
+}
+
+func writePoints(w io.Writer, data []Writable) (int, error) {
+	bw := bufio.NewWriterSize(w, writeBufferSize)
+	line := make([]byte, 0, 1024)
+	total := 0
+
+	for i := 0; i < len(data); i++ {
+		line = line[:0]
+		line = append(line, data[i].Measurement()...)
+		line = append(line, ',')
+		line = append(line, data[i].Tags()...)
+		line = append(line, ' ')
+		line = append(line, data[i].Fields()...)
+		line = append(line, ' ')
+		line = strconv.AppendInt(line, data[i].Timestamp(), 10)
+		line = append(line, '\n')
+
+		n, err := bw.Write(line)
+		total += n
+
+		if err != nil {
+			return total, err
+		}
 	}
 
-	return nil
+	return total, bw.Flush()
 }
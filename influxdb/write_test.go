@@ -0,0 +1,163 @@
+package influxdb_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiurenm/mare/influxdb"
+	"github.com/jiurenm/mare/influxdb/influxdbtest"
+)
+
+func TestWriteGzip(t *testing.T) {
+	tests := []struct {
+		name   string
+		gzip   bool
+		points int
+	}{
+		{name: "plain", points: 3},
+		{name: "gzip", gzip: true, points: 3},
+		{name: "gzip bulk", gzip: true, points: 5000},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.Gzip = test.gzip })
+
+		data := make([]influxdb.Writable, 0, test.points)
+		for j := 0; j < test.points; j++ {
+			data = append(data, cpuPoint{ts: epoch.Add(time.Duration(j) * time.Second), host: "a", value: float64(j)})
+		}
+
+		if err := db.WriteContext(context.Background(), data); err != nil {
+			t.Errorf("%s: write error: %v", test.name, err)
+
+			continue
+		}
+
+		lines := srv.Lines()
+		if len(lines) != test.points {
+			t.Errorf("%s: server received %d lines; want %d", test.name, len(lines), test.points)
+
+			continue
+		}
+
+		if want := "cpu,host=a value=0 " + strconv.FormatInt(epoch.Unix(), 10); lines[0] != want {
+			t.Errorf("%s: first line = %q; want %q", test.name, lines[0], want)
+		}
+	}
+}
+
+// hangingServer 返回一直阻塞到请求的 context 结束的服务配置。
+func hangingServer(t *testing.T) influxdb.Config {
+	t.Helper()
+
+	stop := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-stop:
+		}
+	}))
+	t.Cleanup(func() {
+		close(stop)
+		srv.Close()
+	})
+
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	p, _ := strconv.Atoi(port)
+
+	return influxdb.Config{Host: "http://" + host, Port: p, Database: "test", WriteDatabase: "test", Timeout: 2 * time.Second}
+}
+
+func TestRequestsHonorContext(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, db *influxdb.InfluxDB) error
+	}{
+		{
+			name: "write",
+			run: func(ctx context.Context, db *influxdb.InfluxDB) error {
+				return db.WriteContext(ctx, []influxdb.Writable{cpuPoint{ts: epoch, host: "a", value: 1}})
+			},
+		},
+		{
+			name: "query2",
+			run: func(ctx context.Context, db *influxdb.InfluxDB) error {
+				var rows []map[string]any
+				return db.Query2(ctx, "SELECT ts FROM cpu", &rows)
+			},
+		},
+		{
+			name: "query",
+			run: func(ctx context.Context, db *influxdb.InfluxDB) error {
+				var rows []map[string]any
+				return db.Query(ctx, "SELECT value FROM cpu", &rows)
+			},
+		},
+		{
+			name: "exec",
+			run: func(ctx context.Context, db *influxdb.InfluxDB) error {
+				_, err := db.Exec(ctx, "DROP TABLE cpu")
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		db, closeDB, err := influxdb.NewInfluxDB(hangingServer(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err = test.run(ctx, db)
+
+		cancel()
+		closeDB()
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: error = %v; want context.DeadlineExceeded", test.name, err)
+		}
+
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("%s: returned after %v; want the request cancelled with ctx", test.name, d)
+		}
+	}
+}
+
+func TestWriteDatabase(t *testing.T) {
+	tests := []struct {
+		name     string
+		database string
+		wantErr  string
+	}{
+		{name: "configured database", database: influxdbtest.DefaultDatabase},
+		// 未设置 WriteDatabase 时仍写入 hypon。
+		{name: "default database", database: "", wantErr: "database not found: hypon"},
+		{name: "unknown database", database: "metrics", wantErr: "database not found: metrics"},
+	}
+
+	for _, test := range tests {
+		db, srv := newTestDB(t, func(cfg *influxdb.Config) { cfg.WriteDatabase = test.database })
+		err := db.WriteContext(context.Background(), []influxdb.Writable{cpuPoint{ts: epoch, host: "a", value: 1}})
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: error = %v; want %q", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil || len(srv.Points("cpu")) != 1 {
+			t.Errorf("%s: write = %v, points %v; want 1 point", test.name, err, srv.Points("cpu"))
+		}
+	}
+}
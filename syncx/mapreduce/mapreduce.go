@@ -111,6 +111,12 @@ func mapReduceVoid(generate func(source chan<- any), mapper func(item any, write
 	return err
 }
 
+func WithContext(ctx context.Context) Option {
+	return func(opts *mapReduceOptions) {
+		opts.ctx = ctx
+	}
+}
+
 func WithWorkers(workers int) Option {
 	return func(opts *mapReduceOptions) {
 		if workers < minWorkers {
@@ -376,7 +382,10 @@ func buildOptions(opts ...Option) *mapReduceOptions {
 }
 
 func newOptions() *mapReduceOptions {
-	return &mapReduceOptions{workers: defaultWorkers}
+	return &mapReduceOptions{
+		ctx:     context.Background(),
+		workers: defaultWorkers,
+	}
 }
 
 func once(fn func(error)) func(error) {
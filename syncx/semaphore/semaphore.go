@@ -0,0 +1,219 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrWeightExceeded 表示请求的权重超过了信号量的容量，永远无法获取。
+var ErrWeightExceeded = errors.New("semaphore: weight exceeds size")
+
+// Priority 为等待队列的优先级，数值越小优先级越高。
+type Priority int8
+
+const (
+	// Interactive 为交互式调用方，容量释放时优先唤醒。
+	Interactive Priority = iota
+	// Batch 为批处理调用方，只有 Interactive 队列为空时才会被唤醒。
+	Batch
+
+	numPriorities
+)
+
+type waiter struct {
+	ready chan struct{}
+	n     int64
+}
+
+// Weighted 为带优先级队列的加权信号量，同一优先级内按 FIFO 顺序获取。
+type Weighted struct {
+	waiters   [numPriorities]list.List
+	size      int64
+	cur       int64
+	maxQueued int
+	mu        sync.Mutex
+}
+
+func NewWeighted(n int64) *Weighted {
+	return &Weighted{size: n}
+}
+
+// Acquire 以 Interactive 优先级获取权重 n，阻塞直到成功或 ctx 结束。
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	return s.AcquirePriority(ctx, n, Interactive)
+}
+
+// AcquirePriority 以优先级 p 获取权重 n，阻塞直到成功或 ctx 结束，失败时不占用任何权重。
+func (s *Weighted) AcquirePriority(ctx context.Context, n int64, p Priority) error {
+	if p < 0 || p >= numPriorities {
+		p = Batch
+	}
+
+	done := ctx.Done()
+
+	s.mu.Lock()
+	select {
+	case <-done:
+		s.mu.Unlock()
+		return ctx.Err()
+	default:
+	}
+
+	if n > s.size {
+		s.mu.Unlock()
+		return ErrWeightExceeded
+	}
+
+	if s.size-s.cur >= n && s.queuedBefore(p) == 0 {
+		s.cur += n
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	ready := make(chan struct{})
+	elem := s.waiters[p].PushBack(waiter{n: n, ready: ready})
+
+	if q := s.queued(); q > s.maxQueued {
+		s.maxQueued = q
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// 取消的同时被唤醒，归还权重。
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			isFront := s.front() == elem
+			s.waiters[p].Remove(elem)
+
+			// 队首被移除后，后面的等待者可能已经可以获取。
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+
+		return ctx.Err()
+	case <-ready:
+		select {
+		case <-done:
+			s.Release(n)
+			return ctx.Err()
+		default:
+		}
+
+		return nil
+	}
+}
+
+// TryAcquire 不阻塞地获取权重 n，成功时返回 true。
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	ok := s.size-s.cur >= n && s.queued() == 0
+	if ok {
+		s.cur += n
+	}
+	s.mu.Unlock()
+
+	return ok
+}
+
+// Release 归还权重 n。
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	s.cur -= n
+	if s.cur < 0 {
+		s.mu.Unlock()
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+	s.mu.Unlock()
+}
+
+// Size 返回信号量的容量。
+func (s *Weighted) Size() int64 {
+	return s.size
+}
+
+// InFlight 返回当前已获取的权重。
+func (s *Weighted) InFlight() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cur
+}
+
+// Queued 返回优先级 p 队列中等待的调用方数量。
+func (s *Weighted) Queued(p Priority) int {
+	if p < 0 || p >= numPriorities {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.waiters[p].Len()
+}
+
+// MaxQueued 返回所有队列等待数量之和的历史最大值。
+func (s *Weighted) MaxQueued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.maxQueued
+}
+
+func (s *Weighted) queued() int {
+	return s.queuedBefore(numPriorities - 1)
+}
+
+// queuedBefore 返回优先级不低于 p 的等待者数量。
+func (s *Weighted) queuedBefore(p Priority) int {
+	n := 0
+	for i := Priority(0); i <= p; i++ {
+		n += s.waiters[i].Len()
+	}
+
+	return n
+}
+
+func (s *Weighted) front() *list.Element {
+	for i := range s.waiters {
+		if e := s.waiters[i].Front(); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// notifyWaiters 按优先级依次唤醒等待者，队首放不下时停止，避免大权重的等待者被饿死。
+func (s *Weighted) notifyWaiters() {
+	for {
+		e := s.front()
+		if e == nil {
+			return
+		}
+
+		w := e.Value.(waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+
+		s.cur += w.n
+		for i := range s.waiters {
+			if s.waiters[i].Front() == e {
+				s.waiters[i].Remove(e)
+				break
+			}
+		}
+		close(w.ready)
+	}
+}